@@ -0,0 +1,223 @@
+// A http.RoundTripper that writes one JSON line per HTTP round trip to an
+// audit log file, so the API calls made during an apply and their latency can
+// be analysed after the fact.
+//
+// The audit log is enabled with the provider's audit_log_file field or the
+// GOOGLE_AUDIT_LOG_FILE environment variable. It sits inside the retry
+// transport, so each attempt of a request gets its own line, numbered with
+// its attempt. Request and response bodies, headers and query parameters are
+// never written; only the fields of auditLogEntry are.
+
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type retryAttemptContextKeyType struct{}
+
+// retryAttemptContextKey is set on each request sent by the retry transport to
+// the number of the attempt, starting at 1.
+var retryAttemptContextKey = retryAttemptContextKeyType{}
+
+func retryAttemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(retryAttemptContextKey).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+type auditLogEntry struct {
+	Time         string  `json:"time"`
+	Method       string  `json:"method"`
+	Host         string  `json:"host"`
+	URLTemplate  string  `json:"url_template"`
+	Resource     string  `json:"resource,omitempty"`
+	Module       string  `json:"module,omitempty"`
+	QuotaProject string  `json:"quota_project,omitempty"`
+	Status       int     `json:"status"`
+	Attempt      int     `json:"attempt"`
+	LatencyMs    float64 `json:"latency_ms"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// auditLogWriter appends lines to one audit log file. Writers are shared by
+// every provider instance writing to the same path.
+type auditLogWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var (
+	auditLogWritersMu sync.Mutex
+	auditLogWriters   = make(map[string]*auditLogWriter)
+)
+
+func getAuditLogWriter(path string) (*auditLogWriter, error) {
+	auditLogWritersMu.Lock()
+	defer auditLogWritersMu.Unlock()
+
+	if w, ok := auditLogWriters[path]; ok {
+		return w, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open audit log file %q: %s", path, err)
+	}
+	w := &auditLogWriter{file: f}
+	auditLogWriters[path] = w
+	return w, nil
+}
+
+func (w *auditLogWriter) write(entry *auditLogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[WARN] Audit Log Transport: unable to encode entry: %s", err)
+		return
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.file.Write(line); err != nil {
+		log.Printf("[WARN] Audit Log Transport: unable to write entry: %s", err)
+	}
+}
+
+type auditLogTransport struct {
+	internal http.RoundTripper
+	writer   *auditLogWriter
+	// baseUserAgent is the provider's own user agent. Anything a request's
+	// user agent adds to it identifies the calling module.
+	baseUserAgent string
+}
+
+// NewTransportWithAuditLog constructs an auditLogTransport writing to the
+// file at path.
+func NewTransportWithAuditLog(t http.RoundTripper, path, baseUserAgent string) (*auditLogTransport, error) {
+	w, err := getAuditLogWriter(path)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLogTransport{
+		internal:      t,
+		writer:        w,
+		baseUserAgent: baseUserAgent,
+	}, nil
+}
+
+// RoundTrip implements the RoundTripper interface method.
+func (t *auditLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.internal.RoundTrip(req)
+	latency := time.Since(start)
+
+	template, resource := auditLogURLTemplate(req.URL.EscapedPath())
+	entry := &auditLogEntry{
+		Time:         start.UTC().Format(time.RFC3339Nano),
+		Method:       req.Method,
+		Host:         req.URL.Host,
+		URLTemplate:  template,
+		Resource:     resource,
+		Module:       auditLogModule(req.Header.Get("User-Agent"), t.baseUserAgent),
+		QuotaProject: req.Header.Get("X-Goog-User-Project"),
+		Attempt:      retryAttemptFromContext(req.Context()),
+		LatencyMs:    float64(latency) / float64(time.Millisecond),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	t.writer.write(entry)
+
+	return resp, err
+}
+
+// Matches the API version segment of a URL path, e.g. "v1", "v1beta1",
+// "v1b3" or "beta".
+var auditLogVersionRegex = regexp.MustCompile(`^(v\d+(p\d+)?((alpha|beta|b)\d*)?|alpha|beta)$`)
+
+// Path segments that scope a collection rather than name a collection of
+// resources, so they aren't followed by a resource ID.
+var auditLogScopeSegments = map[string]bool{
+	"global":     true,
+	"aggregated": true,
+}
+
+// auditLogURLTemplate turns a Google API URL path into a template with the
+// resource IDs replaced by placeholders, e.g.
+// "/compute/v1/projects/my-project/zones/us-central1-a/instances/foo"
+// becomes "/compute/v1/projects/{project}/zones/{zone}/instances/{instance}".
+// It also returns the resource name found in the path, i.e. the path after
+// the API version, without a trailing custom method.
+func auditLogURLTemplate(path string) (string, string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	start := 0
+	for i, segment := range segments {
+		if auditLogVersionRegex.MatchString(segment) {
+			start = i + 1
+			break
+		}
+	}
+
+	// Past the version, paths alternate between collections and IDs, and may
+	// end with a custom method like "setMetadata".
+	var resource []string
+	isID := false
+	for i := start; i < len(segments); i++ {
+		if !isID {
+			resource = append(resource, segments[i])
+			// Scopes like Compute's "global" aren't followed by an ID.
+			isID = !auditLogScopeSegments[segments[i]]
+			continue
+		}
+		isID = false
+
+		id, verb := segments[i], ""
+		if idx := strings.LastIndex(id, ":"); idx >= 0 {
+			id, verb = id[:idx], id[idx:]
+		}
+		resource = append(resource, id)
+		segments[i] = fmt.Sprintf("{%s}%s", singularCollectionName(segments[i-1]), verb)
+	}
+
+	// A trailing collection or custom method isn't part of the resource name.
+	if isID {
+		resource = resource[:len(resource)-1]
+	}
+
+	return "/" + strings.Join(segments, "/"), strings.Join(resource, "/")
+}
+
+func singularCollectionName(collection string) string {
+	switch {
+	case strings.HasSuffix(collection, "ies"):
+		return strings.TrimSuffix(collection, "ies") + "y"
+	case strings.HasSuffix(collection, "sses"), strings.HasSuffix(collection, "xes"):
+		return strings.TrimSuffix(collection, "es")
+	case strings.HasSuffix(collection, "s") && len(collection) > 1:
+		return strings.TrimSuffix(collection, "s")
+	}
+	return collection
+}
+
+// auditLogModule returns the module name a resource added to the provider's
+// user agent through provider_meta, if any.
+func auditLogModule(userAgent, baseUserAgent string) string {
+	if baseUserAgent == "" || !strings.HasPrefix(userAgent, baseUserAgent) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(userAgent, baseUserAgent))
+}
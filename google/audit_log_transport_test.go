@@ -0,0 +1,150 @@
+package google
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuditLogURLTemplate(t *testing.T) {
+	cases := map[string]struct {
+		Path             string
+		ExpectedTemplate string
+		ExpectedResource string
+	}{
+		"compute instance": {
+			Path:             "/compute/v1/projects/my-project/zones/us-central1-a/instances/foo",
+			ExpectedTemplate: "/compute/v1/projects/{project}/zones/{zone}/instances/{instance}",
+			ExpectedResource: "projects/my-project/zones/us-central1-a/instances/foo",
+		},
+		"compute list": {
+			Path:             "/compute/v1/projects/my-project/global/networks",
+			ExpectedTemplate: "/compute/v1/projects/{project}/global/networks",
+			ExpectedResource: "projects/my-project/global",
+		},
+		"custom method segment": {
+			Path:             "/compute/beta/projects/my-project/zones/us-central1-a/instances/foo/setMetadata",
+			ExpectedTemplate: "/compute/beta/projects/{project}/zones/{zone}/instances/{instance}/setMetadata",
+			ExpectedResource: "projects/my-project/zones/us-central1-a/instances/foo",
+		},
+		"custom method verb": {
+			Path:             "/v1/projects/my-project:getIamPolicy",
+			ExpectedTemplate: "/v1/projects/{project}:getIamPolicy",
+			ExpectedResource: "projects/my-project",
+		},
+		"plural collection names": {
+			Path:             "/v1/projects/p/locations/l/keyRings/k/cryptoKeys/c/policies/x/addresses/a",
+			ExpectedTemplate: "/v1/projects/{project}/locations/{location}/keyRings/{keyRing}/cryptoKeys/{cryptoKey}/policies/{policy}/addresses/{address}",
+			ExpectedResource: "projects/p/locations/l/keyRings/k/cryptoKeys/c/policies/x/addresses/a",
+		},
+		"storage object": {
+			Path:             "/storage/v1/b/my-bucket/o/dir%2Fobject",
+			ExpectedTemplate: "/storage/v1/b/{b}/o/{o}",
+			ExpectedResource: "b/my-bucket/o/dir%2Fobject",
+		},
+		"dataflow version": {
+			Path:             "/v1b3/projects/p/jobs/j",
+			ExpectedTemplate: "/v1b3/projects/{project}/jobs/{job}",
+			ExpectedResource: "projects/p/jobs/j",
+		},
+	}
+
+	for tn, tc := range cases {
+		template, resource := auditLogURLTemplate(tc.Path)
+		if template != tc.ExpectedTemplate {
+			t.Errorf("%s: expected template %q, got %q", tn, tc.ExpectedTemplate, template)
+		}
+		if resource != tc.ExpectedResource {
+			t.Errorf("%s: expected resource %q, got %q", tn, tc.ExpectedResource, resource)
+		}
+	}
+}
+
+func TestAuditLogModule(t *testing.T) {
+	base := "Terraform/1.0.0 terraform-provider-google/dev"
+	if got := auditLogModule(base+" blueprints/terraform/my-module/v1.0.0", base); got != "blueprints/terraform/my-module/v1.0.0" {
+		t.Errorf("unexpected module %q", got)
+	}
+	if got := auditLogModule(base, base); got != "" {
+		t.Errorf("expected no module, got %q", got)
+	}
+	if got := auditLogModule("google-api-go-client/0.5", base); got != "" {
+		t.Errorf("expected no module, got %q", got)
+	}
+}
+
+func TestAuditLogTransport_writesOneLinePerAttempt(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(testRetryTransportCodeRetry)
+			return
+		}
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditTransport, err := NewTransportWithAuditLog(http.DefaultTransport, path, "terraform-provider-google/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ts.Client()
+	client.Transport = &retryTransport{
+		internal:        auditTransport,
+		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+	}
+
+	req, err := http.NewRequest("POST", ts.URL+"/compute/v1/projects/p/global/networks?access_token=secret", strings.NewReader(`{"name": "secret-body"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "terraform-provider-google/dev my-module")
+	req.Header.Set("X-Goog-User-Project", "quota-project")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	testRetryTransport_checkSuccess(t, resp, err)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []auditLogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), "secret") {
+			t.Errorf("expected audit log to be redacted, got %s", scanner.Text())
+		}
+		var entry auditLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("unable to parse audit log line %q: %s", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Attempt != i+1 {
+			t.Errorf("expected entry %d to be attempt %d, got %d", i, i+1, entry.Attempt)
+		}
+		if entry.Method != "POST" || entry.URLTemplate != "/compute/v1/projects/{project}/global/networks" {
+			t.Errorf("unexpected request in entry %d: %+v", i, entry)
+		}
+		if entry.Module != "my-module" || entry.QuotaProject != "quota-project" {
+			t.Errorf("unexpected module or quota project in entry %d: %+v", i, entry)
+		}
+	}
+	if entries[0].Status != testRetryTransportCodeRetry || entries[1].Status != testRetryTransportCodeSuccess {
+		t.Errorf("unexpected statuses %d, %d", entries[0].Status, entries[1].Status)
+	}
+}
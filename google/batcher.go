@@ -49,6 +49,13 @@ type (
 		// Bodies.
 		SendF BatcherSendFunc
 
+		// IsolateErrorF optionally reports whether an error returned by SendF
+		// for a combined batch may have been caused by only some of the requests
+		// in it. If so, the failed batch is split and its parts retried, so the
+		// error is only returned to the requests causing it. If nil, failed
+		// batches are always split.
+		IsolateErrorF BatcherIsolateErrorFunc
+
 		// ID for debugging request. This should be specific to a single request
 		// (i.e. per Terraform resource)
 		DebugId string
@@ -59,6 +66,10 @@ type (
 
 	// BatcherSendFunc is a function type for sending a batch request
 	BatcherSendFunc func(resourceName string, body interface{}) (interface{}, error)
+
+	// BatcherIsolateErrorFunc is a function type for deciding whether a batch
+	// failure can be isolated to some of its requests by splitting the batch
+	BatcherIsolateErrorFunc func(err error) bool
 )
 
 // batchResponse bundles an API response (data, error) tuple.
@@ -165,10 +176,12 @@ func (b *RequestBatcher) SendRequestWithTimeout(batchKey string, request *BatchR
 		return request.SendF(request.ResourceName, request.Body)
 	}
 
+	start := time.Now()
 	respCh, err := b.registerBatchRequest(batchKey, request)
 	if err != nil {
 		return nil, fmt.Errorf("error adding request to batch: %s", err)
 	}
+	defer metricBatchWaitSeconds.observeDuration(start, b.debugId)
 
 	ctx, cancel := context.WithTimeout(b.parentCtx, timeout)
 	defer cancel()
@@ -231,11 +244,12 @@ func (b *RequestBatcher) registerBatchRequest(batchKey string, newRequest *Batch
 	// Create a new batch with copy of the given batch request.
 	b.batches[batchKey] = &startedBatch{
 		BatchRequest: &BatchRequest{
-			ResourceName: newRequest.ResourceName,
-			Body:         newRequest.Body,
-			CombineF:     newRequest.CombineF,
-			SendF:        newRequest.SendF,
-			DebugId:      fmt.Sprintf("Combined batch for started batch %q", batchKey),
+			ResourceName:  newRequest.ResourceName,
+			Body:          newRequest.Body,
+			CombineF:      newRequest.CombineF,
+			SendF:         newRequest.SendF,
+			IsolateErrorF: newRequest.IsolateErrorF,
+			DebugId:       fmt.Sprintf("Combined batch for started batch %q", batchKey),
 		},
 		batchKey:    batchKey,
 		subscribers: []batchSubscriber{sub},
@@ -249,22 +263,40 @@ func (b *RequestBatcher) registerBatchRequest(batchKey string, newRequest *Batch
 			respCh <- newRequest.send()
 			close(respCh)
 		} else {
-			b.sendBatchWithSingleRetry(batchKey, batch)
+			b.sendBatchIsolatingFailures(batchKey, batch)
 		}
 	})
 
 	return respCh, nil
 }
 
-func (b *RequestBatcher) sendBatchWithSingleRetry(batchKey string, batch *startedBatch) {
+func (b *RequestBatcher) sendBatchIsolatingFailures(batchKey string, batch *startedBatch) {
 	log.Printf("[DEBUG] Sending batch %q combining %d requests)", batchKey, len(batch.subscribers))
+	metricBatchSize.observe(float64(len(batch.subscribers)), b.debugId)
 	resp := batch.send()
+	batch.respond(batch.subscribers, resp)
+}
+
+// respond sends the response of a batch combining the given subscribers'
+// requests to them. If the batch failed, combines more than one request and
+// the error can be isolated, the subscribers are split in two halves which are
+// sent again as smaller batches, down to single requests, so an error caused
+// by one request is only returned to that request.
+func (batch *startedBatch) respond(subscribers []batchSubscriber, resp batchResponse) {
+	if !resp.IsError() || len(subscribers) == 1 || !batch.canIsolateError(resp.err) {
+		for _, sub := range subscribers {
+			sub.respCh <- resp
+			close(sub.respCh)
+		}
+		return
+	}
 
-	// If the batch failed and combines more than one request, retry each single request.
-	if resp.IsError() && len(batch.subscribers) > 1 {
-		log.Printf("[DEBUG] Batch failed with error: %v", resp.err)
-		log.Printf("[DEBUG] Sending each request in batch separately")
-		for _, sub := range batch.subscribers {
+	log.Printf("[DEBUG] Batch of %d requests failed with error: %v", len(subscribers), resp.err)
+	log.Printf("[DEBUG] Splitting batch to isolate failing requests")
+	mid := len(subscribers) / 2
+	for _, part := range [][]batchSubscriber{subscribers[:mid], subscribers[mid:]} {
+		if len(part) == 1 {
+			sub := part[0]
 			log.Printf("[DEBUG] Retrying single request %q", sub.singleRequest.DebugId)
 			singleResp := sub.singleRequest.send()
 			log.Printf("[DEBUG] Retried single request %q returned response: %v", sub.singleRequest.DebugId, singleResp)
@@ -276,14 +308,41 @@ func (b *RequestBatcher) sendBatchWithSingleRetry(batchKey string, batch *starte
 			}
 			sub.respCh <- singleResp
 			close(sub.respCh)
+			continue
 		}
-	} else {
-		// Send result to all subscribers
-		for _, sub := range batch.subscribers {
-			sub.respCh <- resp
-			close(sub.respCh)
+
+		log.Printf("[DEBUG] Retrying batch of %d requests", len(part))
+		batch.respond(part, batch.sendSubset(part))
+	}
+}
+
+func (batch *startedBatch) canIsolateError(err error) bool {
+	if batch.IsolateErrorF == nil {
+		return true
+	}
+	return batch.IsolateErrorF(err)
+}
+
+// sendSubset sends one request combining the requests of the given
+// subscribers.
+func (batch *startedBatch) sendSubset(subscribers []batchSubscriber) batchResponse {
+	body := subscribers[0].singleRequest.Body
+	for _, sub := range subscribers[1:] {
+		var err error
+		body, err = batch.CombineF(body, sub.singleRequest.Body)
+		if err != nil {
+			return batchResponse{
+				err: fmt.Errorf("Provider Error: Unable to combine request %q data into retried batch %q: %v", sub.singleRequest.DebugId, batch.batchKey, err),
+			}
 		}
 	}
+
+	req := &BatchRequest{
+		ResourceName: batch.ResourceName,
+		Body:         body,
+		SendF:        batch.SendF,
+	}
+	return req.send()
 }
 
 // popBatch safely gets and removes a batch with given batchkey from the
@@ -200,6 +200,128 @@ func TestRequestBatcher_errInSend(t *testing.T) {
 	wg.Wait()
 }
 
+func TestRequestBatcher_errInSendIsolatesFailingRequest(t *testing.T) {
+	testBatcher := NewRequestBatcher(
+		"testBatcher",
+		context.Background(),
+		&batchingConfig{
+			sendAfter:      time.Duration(1) * time.Second,
+			enableBatching: true,
+		})
+
+	testCombine := func(body interface{}, toAdd interface{}) (interface{}, error) {
+		return append(body.([]int), toAdd.([]int)...), nil
+	}
+
+	failIdx := 5
+	expectedErrMsg := fmt.Sprintf("Error - batch contains idx %d", failIdx)
+
+	var sendsMu sync.Mutex
+	sends := 0
+	testSendBatch := func(resourceName string, body interface{}) (interface{}, error) {
+		sendsMu.Lock()
+		sends++
+		sendsMu.Unlock()
+		for _, v := range body.([]int) {
+			if v == failIdx {
+				return nil, fmt.Errorf(expectedErrMsg)
+			}
+		}
+		return nil, nil
+	}
+
+	numRequests := 16
+
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+
+	for i := 0; i < numRequests; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			req := &BatchRequest{
+				DebugId:      fmt.Sprintf("sendError %d", idx),
+				ResourceName: "test-resource",
+				Body:         []int{idx},
+				CombineF:     testCombine,
+				SendF:        testSendBatch,
+			}
+
+			_, err := testBatcher.SendRequestWithTimeout("batchSendIsolateError", req, time.Duration(10)*time.Second)
+			if idx == failIdx {
+				if err == nil || !strings.Contains(err.Error(), expectedErrMsg) {
+					t.Errorf("expected error %q for request %d, got %v", expectedErrMsg, idx, err)
+				}
+			} else if err != nil {
+				t.Errorf("expected request %d to succeed, got error: %v", idx, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	// The batch is bisected down to the failing request: the full batch, then
+	// two halves at each of 4 levels.
+	if sends != 9 {
+		t.Errorf("expected 9 sends to isolate the failing request, got %d", sends)
+	}
+}
+
+func TestRequestBatcher_errInSendNotIsolatable(t *testing.T) {
+	testBatcher := NewRequestBatcher(
+		"testBatcher",
+		context.Background(),
+		&batchingConfig{
+			sendAfter:      time.Duration(1) * time.Second,
+			enableBatching: true,
+		})
+
+	testCombine := func(body interface{}, toAdd interface{}) (interface{}, error) {
+		return append(body.([]int), toAdd.([]int)...), nil
+	}
+
+	expectedErrMsg := "Error - permission denied"
+	var sendsMu sync.Mutex
+	sends := 0
+	testSendBatch := func(resourceName string, body interface{}) (interface{}, error) {
+		sendsMu.Lock()
+		sends++
+		sendsMu.Unlock()
+		return nil, fmt.Errorf(expectedErrMsg)
+	}
+
+	numRequests := 4
+
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+
+	for i := 0; i < numRequests; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			req := &BatchRequest{
+				DebugId:       fmt.Sprintf("sendError %d", idx),
+				ResourceName:  "test-resource",
+				Body:          []int{idx},
+				CombineF:      testCombine,
+				SendF:         testSendBatch,
+				IsolateErrorF: func(err error) bool { return false },
+			}
+
+			_, err := testBatcher.SendRequestWithTimeout("batchSendNotIsolatable", req, time.Duration(10)*time.Second)
+			if err == nil || !strings.Contains(err.Error(), expectedErrMsg) {
+				t.Errorf("expected error %q for request %d, got %v", expectedErrMsg, idx, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if sends != 1 {
+		t.Errorf("expected the failed batch to not be retried, got %d sends", sends)
+	}
+}
+
 func TestRequestBatcher_errTimeout(t *testing.T) {
 	testBatcher := NewRequestBatcher(
 		"testBatcher",
@@ -13,10 +13,17 @@ type BigtableClientFactory struct {
 	TokenSource oauth2.TokenSource
 }
 
+// userAgent is the factory's user agent without a span tag, as gRPC requests
+// aren't made through the provider's HTTP transports, which remove it.
+func (s BigtableClientFactory) userAgent() string {
+	userAgent, _ := splitUserAgentSpan(s.UserAgent)
+	return userAgent
+}
+
 func (s BigtableClientFactory) NewInstanceAdminClient(project string) (*bigtable.InstanceAdminClient, error) {
-	return bigtable.NewInstanceAdminClient(context.Background(), project, option.WithTokenSource(s.TokenSource), option.WithUserAgent(s.UserAgent))
+	return bigtable.NewInstanceAdminClient(context.Background(), project, option.WithTokenSource(s.TokenSource), option.WithUserAgent(s.userAgent()))
 }
 
 func (s BigtableClientFactory) NewAdminClient(project, instance string) (*bigtable.AdminClient, error) {
-	return bigtable.NewAdminClient(context.Background(), project, instance, option.WithTokenSource(s.TokenSource), option.WithUserAgent(s.UserAgent))
+	return bigtable.NewAdminClient(context.Background(), project, instance, option.WithTokenSource(s.TokenSource), option.WithUserAgent(s.userAgent()))
 }
@@ -1,8 +1,11 @@
 package google
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -136,6 +139,35 @@ func CommonRefreshFunc(w Waiter) resource.StateRefreshFunc {
 }
 
 func OperationWait(w Waiter, activity string, timeout time.Duration, pollInterval time.Duration) error {
+	return OperationWaitWithCtx(operationWaiterContext(w), w, activity, timeout, pollInterval)
+}
+
+// operationWaiterContext returns the context of the provider configuration
+// and the traced CRUD function a waiter polls for. Generated waiters have
+// the *Config and user agent they poll with as their Config and UserAgent
+// fields.
+func operationWaiterContext(w Waiter) context.Context {
+	ctx := context.Background()
+	v := reflect.Indirect(reflect.ValueOf(w))
+	if v.Kind() != reflect.Struct {
+		return ctx
+	}
+	if f := v.FieldByName("Config"); f.IsValid() {
+		if config, ok := f.Interface().(*Config); ok && config != nil && config.context != nil {
+			ctx = config.context
+		}
+	}
+	if f := v.FieldByName("UserAgent"); f.IsValid() && f.Kind() == reflect.String {
+		ctx = contextWithUserAgentSpan(ctx, f.String())
+	}
+	return ctx
+}
+
+// OperationWaitWithCtx is OperationWait, tracing the wait as a child of the
+// span in ctx, if any. Polls start at a short interval and back off up to a
+// cap, as configured by the provider's operation_polling block carried by
+// ctx. If pollInterval is set, it further caps the interval between polls.
+func OperationWaitWithCtx(ctx context.Context, w Waiter, activity string, timeout time.Duration, pollInterval time.Duration) (err error) {
 	if OperationDone(w) {
 		if w.Error() != nil {
 			return w.Error()
@@ -143,15 +175,24 @@ func OperationWait(w Waiter, activity string, timeout time.Duration, pollInterva
 		return nil
 	}
 
-	c := &resource.StateChangeConf{
-		Pending:      w.PendingStates(),
-		Target:       w.TargetStates(),
-		Refresh:      CommonRefreshFunc(w),
-		Timeout:      timeout,
-		MinTimeout:   2 * time.Second,
-		PollInterval: pollInterval,
-	}
-	opRaw, err := c.WaitForState()
+	service := operationWaiterService(w)
+	_, span := startSpan(ctx, fmt.Sprintf("OperationWait %s", activity), spanKindInternal)
+	span.setAttribute("operation.service", service)
+	var polls int32
+	defer func() {
+		span.setAttribute("operation.polls", int(atomic.LoadInt32(&polls)))
+		span.finish(err)
+	}()
+
+	refresh := CommonRefreshFunc(w)
+	poller := newOperationPoller(operationPollingFromContext(ctx), operationPollingServiceKey(service), pollInterval)
+	start := time.Now()
+	opRaw, err := poller.waitForState(w, func() (interface{}, string, error) {
+		metricOperationPolls.inc(service)
+		atomic.AddInt32(&polls, 1)
+		return refresh()
+	}, timeout)
+	metricOperationWaitSeconds.observeDuration(start, service)
 	if err != nil {
 		return fmt.Errorf("Error waiting for %s: %s", activity, err)
 	}
@@ -36,13 +36,16 @@ func PollingWaitTime(pollF PollReadFunc, checkResponse PollCheckResponseFunc, ac
 	timeout time.Duration, targetOccurrences int) error {
 	log.Printf("[DEBUG] %s: Polling until expected state is read", activity)
 	log.Printf("[DEBUG] Target occurrences: %d", targetOccurrences)
+	defer metricPollingWaitSeconds.observeDuration(time.Now())
 	if targetOccurrences == 1 {
 		return resource.Retry(timeout, func() *resource.RetryError {
+			metricPollingWaitPolls.inc()
 			readResp, readErr := pollF()
 			return checkResponse(readResp, readErr)
 		})
 	}
 	return RetryWithTargetOccurrences(timeout, targetOccurrences, func() *resource.RetryError {
+		metricPollingWaitPolls.inc()
 		readResp, readErr := pollF()
 		return checkResponse(readResp, readErr)
 	})
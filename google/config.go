@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"regexp"
 	"strings"
@@ -68,11 +69,40 @@ type Config struct {
 	Zone                               string
 	Scopes                             []string
 	BatchingConfig                     *batchingConfig
+	RetryConfig                        *retryConfig
+	RateLimits                         []*rateLimitConfig
 	UserProjectOverride                bool
 	RequestTimeout                     time.Duration
-	// PollInterval is passed to resource.StateChangeConf in common_operation.go
-	// It controls the interval at which we poll for successful operations
+	// DefaultLabels are merged into the labels of every resource that has a
+	// "labels" field. Labels set on the resource take priority.
+	DefaultLabels map[string]string
+	// DefaultDeletionProtection is used by resources with the shared
+	// deletion_protection field when it isn't set on the resource.
+	DefaultDeletionProtection bool
+	// AuditLogFile is the path of a file to write one JSON line to for every
+	// HTTP request made by the provider. Empty disables the audit log.
+	AuditLogFile string
+	// MetricsFile is the path to write the provider's metrics to when it shuts
+	// down. Empty disables writing metrics.
+	MetricsFile string
+	// TraceFile and TraceEndpoint are where to export OTLP spans to: a file,
+	// and the base URL of an OTLP/HTTP collector. Tracing is disabled if both
+	// are empty.
+	TraceFile     string
+	TraceEndpoint string
+	// OperationPolling controls the interval at which we poll for successful
+	// operations in common_operation.go.
+	OperationPolling *operationPollingConfig
+	// PollInterval, if set, caps the interval at which we poll for successful
+	// operations, overriding OperationPolling. It's set low to speed up tests.
 	PollInterval time.Duration
+	// IamPolicyMutexDisabled stops IAM policy read-modify-write cycles from
+	// holding an in-process lock per policy, leaving concurrent changes to be
+	// resolved by retrying on etag conflicts.
+	IamPolicyMutexDisabled bool
+	// IamGuardrails, if set, restricts the roles and members IAM resources
+	// may grant.
+	IamGuardrails *iamGuardrails
 
 	client    *http.Client
 	context   context.Context
@@ -160,8 +190,9 @@ type Config struct {
 	StorageTransferBasePath   string
 	BigtableAdminBasePath     string
 
-	requestBatcherServiceUsage *RequestBatcher
-	requestBatcherIam          *RequestBatcher
+	requestBatcherServiceUsage    *RequestBatcher
+	requestBatcherIam             *RequestBatcher
+	requestBatcherComputeMetadata *RequestBatcher
 
 	// start DCLBasePaths
 	// dataprocBasePath is implemented in mm
@@ -370,13 +401,33 @@ func (c *Config) LoadAndValidate(ctx context.Context) error {
 	// 2. Logging Transport - ensure we log HTTP requests to GCP APIs.
 	loggingTransport := logging.NewTransport("Google", client.Transport)
 
-	// 3. Retry Transport - retries common temporary errors
+	// 3. Audit Log Transport - optionally writes a JSON line for each request
+	// attempt, with its latency as seen on the wire.
+	var auditedTransport http.RoundTripper = loggingTransport
+	if c.AuditLogFile != "" {
+		auditedTransport, err = NewTransportWithAuditLog(loggingTransport, c.AuditLogFile, c.userAgent)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 4. Rate Limit Transport - limits the rate of requests per API service.
+	// Wrapped by the retry transport so each retried attempt is limited too.
+	limitedTransport := auditedTransport
+	if len(c.RateLimits) > 0 {
+		for _, l := range c.RateLimits {
+			log.Printf("[INFO] Limiting request rate for %s", l)
+		}
+		limitedTransport = NewTransportWithRateLimits(auditedTransport, c.RateLimits)
+	}
+
+	// 5. Retry Transport - retries common temporary errors
 	// Keep order for wrapping logging so we log each retried request as well.
 	// This value should be used if needed to create shallow copies with additional retry predicates.
 	// See ClientWithAdditionalRetries
-	retryTransport := NewTransportWithDefaultRetries(loggingTransport)
+	retryTransport := NewTransportWithRetryConfig(limitedTransport, c.RetryConfig)
 
-	// 4. Header Transport - outer wrapper to inject additional headers we want to apply
+	// 6. Header Transport - outer wrapper to inject additional headers we want to apply
 	// before making requests
 	headerTransport := newTransportWithHeaders(retryTransport)
 
@@ -387,11 +438,19 @@ func (c *Config) LoadAndValidate(ctx context.Context) error {
 	client.Timeout = c.synchronousTimeout()
 
 	c.client = client
-	c.context = ctx
+	c.context = contextWithOperationPolling(ctx, c.OperationPolling)
 	c.Region = GetRegionFromRegionSelfLink(c.Region)
 	c.requestBatcherServiceUsage = NewRequestBatcher("Service Usage", ctx, c.BatchingConfig)
 	c.requestBatcherIam = NewRequestBatcher("IAM", ctx, c.BatchingConfig)
-	c.PollInterval = 10 * time.Second
+	c.requestBatcherComputeMetadata = NewRequestBatcher("Compute Metadata", ctx, c.BatchingConfig)
+	if c.MetricsFile != "" {
+		providerMetrics.setPath(c.MetricsFile)
+	}
+	if c.TraceFile != "" || c.TraceEndpoint != "" {
+		if err := enableTracing(tracingConfig{file: c.TraceFile, endpoint: c.TraceEndpoint}); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -426,6 +485,111 @@ func expandProviderBatchingConfig(v interface{}) (*batchingConfig, error) {
 	return config, nil
 }
 
+func expandProviderRetryConfig(v interface{}) (*retryConfig, error) {
+	config := defaultRetryConfig()
+
+	if v == nil {
+		return config, nil
+	}
+	ls := v.([]interface{})
+	if len(ls) == 0 || ls[0] == nil {
+		return config, nil
+	}
+
+	cfgV := ls[0].(map[string]interface{})
+	if maxAttempts, ok := cfgV["max_attempts"]; ok {
+		config.maxAttempts = maxAttempts.(int)
+	}
+
+	if initialBackoffV, ok := cfgV["initial_backoff"]; ok && initialBackoffV.(string) != "" {
+		initialBackoff, err := time.ParseDuration(initialBackoffV.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse duration from 'initial_backoff' value %q", initialBackoffV)
+		}
+		config.initialBackoff = initialBackoff
+	}
+
+	if maxBackoffV, ok := cfgV["max_backoff"]; ok && maxBackoffV.(string) != "" {
+		maxBackoff, err := time.ParseDuration(maxBackoffV.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse duration from 'max_backoff' value %q", maxBackoffV)
+		}
+		config.maxBackoff = maxBackoff
+	}
+
+	if jitter, ok := cfgV["jitter"]; ok {
+		config.jitter = jitter.(float64)
+	}
+
+	if strategy, ok := cfgV["backoff_strategy"]; ok && strategy.(string) != "" {
+		config.backoffStrategy = strategy.(string)
+	}
+
+	if codesV, ok := cfgV["retry_status_codes"]; ok {
+		var codes []int
+		for _, code := range codesV.([]interface{}) {
+			codes = append(codes, code.(int))
+		}
+		if len(codes) > 0 {
+			config.retryPredicates = append(config.retryPredicates, isRetryableErrorCodeFunc(codes))
+		}
+	}
+
+	if reasonsV, ok := cfgV["retry_error_reasons"]; ok {
+		if reasons := convertStringArr(reasonsV.([]interface{})); len(reasons) > 0 {
+			config.retryPredicates = append(config.retryPredicates, isRetryableErrorReasonFunc(reasons))
+		}
+	}
+
+	return config, nil
+}
+
+// expandProviderRateLimitConfigs expands the provider's rate_limit blocks.
+// basePathFor resolves a service name such as "compute" to the base path
+// configured for it, reporting false for unknown services.
+func expandProviderRateLimitConfigs(v interface{}, basePathFor func(service string) (string, bool)) ([]*rateLimitConfig, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var configs []*rateLimitConfig
+	seen := make(map[string]bool)
+	for _, raw := range v.([]interface{}) {
+		if raw == nil {
+			continue
+		}
+		cfgV := raw.(map[string]interface{})
+
+		service := cfgV["service"].(string)
+		if seen[service] {
+			return nil, fmt.Errorf("rate_limit for service %q is set more than once", service)
+		}
+		seen[service] = true
+
+		basePath, ok := basePathFor(service)
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q in rate_limit, expected a service with a %s_custom_endpoint", service, service)
+		}
+		if strings.Contains(basePath, "{{") {
+			return nil, fmt.Errorf("rate_limit is not supported for service %q, its base path %q depends on the resource location", service, basePath)
+		}
+
+		config := &rateLimitConfig{
+			service:           service,
+			basePath:          basePath,
+			requestsPerSecond: cfgV["requests_per_second"].(float64),
+		}
+		if burst, ok := cfgV["burst"]; ok && burst.(int) > 0 {
+			config.burst = burst.(int)
+		} else {
+			config.burst = int(math.Max(1, math.Ceil(config.requestsPerSecond)))
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
 func (c *Config) synchronousTimeout() time.Duration {
 	if c.RequestTimeout == 0 {
 		return 120 * time.Second
@@ -497,7 +661,7 @@ func (c *Config) getTokenSource(clientScopes []string, initialCredentialsOnly bo
 // of those "projects" as well. You can find out if this is required by looking at
 // the basePath value in the client library file.
 func (c *Config) NewComputeClient(userAgent string) *compute.Service {
-	computeClientBasePath := c.ComputeBasePath + "projects/"
+	computeClientBasePath := c.ComputeBasePath
 	log.Printf("[INFO] Instantiating GCE client for path %s", computeClientBasePath)
 	clientCompute, err := compute.NewService(c.context, option.WithHTTPClient(c.client))
 	if err != nil {
@@ -511,7 +675,7 @@ func (c *Config) NewComputeClient(userAgent string) *compute.Service {
 }
 
 func (c *Config) NewComputeBetaClient(userAgent string) *computeBeta.Service {
-	computeBetaClientBasePath := c.ComputeBetaBasePath + "projects/"
+	computeBetaClientBasePath := c.ComputeBetaBasePath
 	log.Printf("[INFO] Instantiating GCE Beta client for path %s", computeBetaClientBasePath)
 	clientComputeBeta, err := computeBeta.NewService(c.context, option.WithHTTPClient(c.client))
 	if err != nil {
@@ -1076,7 +1240,7 @@ func (c *Config) GetCredentials(clientScopes []string, initialCredentialsOnly bo
 
 // Remove the `/{{version}}/` from a base path if present.
 func removeBasePathVersion(url string) string {
-	re := regexp.MustCompile(`(?P<base>http[s]://.*)(?P<version>/[^/]+?/$)`)
+	re := regexp.MustCompile(`(?P<base>https?://.*)(?P<version>/[^/]+?/$)`)
 	return re.ReplaceAllString(url, "$1/")
 }
 
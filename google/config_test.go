@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -288,6 +289,54 @@ func TestConfigLoadAndValidate_customBatchingConfig(t *testing.T) {
 	}
 }
 
+func TestExpandProviderRetryConfig(t *testing.T) {
+	retryCfg, err := expandProviderRetryConfig(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retryCfg.initialBackoff != defaultRetryInitialBackoff || retryCfg.backoffStrategy != retryBackoffFibonacci {
+		t.Fatalf("expected default retry config, got %+v", retryCfg)
+	}
+
+	retryCfg, err = expandProviderRetryConfig([]interface{}{
+		map[string]interface{}{
+			"max_attempts":        5,
+			"initial_backoff":     "1s",
+			"max_backoff":         "30s",
+			"jitter":              0.2,
+			"backoff_strategy":    "exponential",
+			"retry_status_codes":  []interface{}{409},
+			"retry_error_reasons": []interface{}{"rateLimitExceeded"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retryCfg.maxAttempts != 5 {
+		t.Errorf("expected maxAttempts to be 5, got %d", retryCfg.maxAttempts)
+	}
+	if retryCfg.initialBackoff != time.Second {
+		t.Errorf("expected initialBackoff to be 1s, got %s", retryCfg.initialBackoff)
+	}
+	if retryCfg.maxBackoff != 30*time.Second {
+		t.Errorf("expected maxBackoff to be 30s, got %s", retryCfg.maxBackoff)
+	}
+	if retryCfg.jitter != 0.2 {
+		t.Errorf("expected jitter to be 0.2, got %v", retryCfg.jitter)
+	}
+	if retryCfg.backoffStrategy != retryBackoffExponential {
+		t.Errorf("expected backoffStrategy to be %q, got %q", retryBackoffExponential, retryCfg.backoffStrategy)
+	}
+	if len(retryCfg.retryPredicates) != 2 {
+		t.Errorf("expected 2 additional retry predicates, got %d", len(retryCfg.retryPredicates))
+	}
+
+	transport := NewTransportWithRetryConfig(http.DefaultTransport, retryCfg)
+	if len(transport.retryPredicates) != len(defaultErrorRetryPredicates)+2 {
+		t.Errorf("expected transport to use default and additional retry predicates, got %d", len(transport.retryPredicates))
+	}
+}
+
 func TestRemoveBasePathVersion(t *testing.T) {
 	cases := []struct {
 		BaseURL  string
@@ -299,6 +348,8 @@ func TestRemoveBasePathVersion(t *testing.T) {
 		{"https://staging-version.googleapis.com/", "https://staging-version.googleapis.com/"},
 		// For URLs with any parts, the last part is always removed- it's assumed to be the version.
 		{"https://runtimeconfig.googleapis.com/runtimeconfig/", "https://runtimeconfig.googleapis.com/"},
+		// Custom endpoints may be plain HTTP, e.g. a local fake of the API.
+		{"http://127.0.0.1:8080/pubsub/v1/", "http://127.0.0.1:8080/pubsub/"},
 	}
 
 	for _, c := range cases {
@@ -116,5 +116,5 @@ func containerOperationWait(config *Config, op *container.Operation, project, lo
 		return err
 	}
 
-	return OperationWait(w, activity, timeout, config.PollInterval)
+	return OperationWaitWithCtx(contextWithUserAgentSpan(config.context, userAgent), w, activity, timeout, config.PollInterval)
 }
@@ -0,0 +1,588 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// bulkImportResourceType describes how to list the resources of a type in a
+// project, and how to import them.
+type bulkImportResourceType struct {
+	// importId is the import ID of a resource, with {{field}} placeholders for
+	// its fields. It's only set for resources whose importer doesn't use
+	// parseImportId. Otherwise it's derived from the first of the formats the
+	// importer passes to parseImportId, see getBulkImportTypeInfos.
+	importId string
+	// listUrl lists the resources of a project, e.g.
+	// "{{ComputeBasePath}}projects/{{project}}/global/networks".
+	listUrl string
+	// itemsField is the field of the list response holding the resources.
+	itemsField string
+	// aggregated is set for the aggregatedList methods of Compute Engine,
+	// whose responses hold a map of zones or regions to lists of resources.
+	aggregated bool
+	// fields returns the values of the import ID's fields for a listed
+	// resource, and of any other arguments they determine, or nil to skip
+	// it. The project is set by the caller.
+	fields func(item map[string]interface{}) map[string]string
+}
+
+// bulkImportNameFields returns the name of a resource, from its name or
+// full name, e.g. "projects/p/topics/t".
+func bulkImportNameFields(item map[string]interface{}) map[string]string {
+	return map[string]string{"name": GetResourceNameFromSelfLink(bulkImportString(item, "name"))}
+}
+
+// bulkImportScopedNameFields returns the name of a regional or zonal resource,
+// and the name of its scope, e.g. its zone, from the scope's self link.
+func bulkImportScopedNameFields(scope string) func(map[string]interface{}) map[string]string {
+	return func(item map[string]interface{}) map[string]string {
+		s := bulkImportString(item, scope)
+		if s == "" {
+			return nil
+		}
+		return map[string]string{
+			"name": bulkImportString(item, "name"),
+			scope:  GetResourceNameFromSelfLink(s),
+		}
+	}
+}
+
+// bulkImportLocationNameFields returns the location and name of a resource
+// from its full name, e.g. "projects/p/locations/l/instances/i".
+func bulkImportLocationNameFields(location string) func(map[string]interface{}) map[string]string {
+	return func(item map[string]interface{}) map[string]string {
+		parts := strings.Split(bulkImportString(item, "name"), "/")
+		if len(parts) != 6 {
+			return nil
+		}
+		return map[string]string{location: parts[3], "name": parts[5]}
+	}
+}
+
+var bulkImportResourceTypes = map[string]bulkImportResourceType{
+	"google_cloudfunctions_function": {
+		importId:   "projects/{{project}}/locations/{{region}}/functions/{{name}}",
+		listUrl:    "{{CloudFunctionsBasePath}}projects/{{project}}/locations/-/functions",
+		itemsField: "functions",
+		fields:     bulkImportLocationNameFields("region"),
+	},
+	"google_compute_address": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/aggregated/addresses",
+		itemsField: "addresses",
+		aggregated: true,
+		// Global addresses are listed too, but don't have a region.
+		fields: bulkImportScopedNameFields("region"),
+	},
+	"google_compute_disk": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/aggregated/disks",
+		itemsField: "disks",
+		aggregated: true,
+		fields:     bulkImportScopedNameFields("zone"),
+	},
+	"google_compute_firewall": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/global/firewalls",
+		itemsField: "items",
+		fields:     bulkImportNameFields,
+	},
+	"google_compute_global_address": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/global/addresses",
+		itemsField: "items",
+		fields:     bulkImportNameFields,
+	},
+	"google_compute_instance": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/aggregated/instances",
+		itemsField: "instances",
+		aggregated: true,
+		fields:     bulkImportScopedNameFields("zone"),
+	},
+	"google_compute_network": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/global/networks",
+		itemsField: "items",
+		fields:     bulkImportNameFields,
+	},
+	"google_compute_subnetwork": {
+		listUrl:    "{{ComputeBasePath}}projects/{{project}}/aggregated/subnetworks",
+		itemsField: "subnetworks",
+		aggregated: true,
+		fields:     bulkImportScopedNameFields("region"),
+	},
+	"google_container_cluster": {
+		listUrl:    "{{ContainerBasePath}}projects/{{project}}/locations/-/clusters",
+		itemsField: "clusters",
+		fields: func(item map[string]interface{}) map[string]string {
+			return map[string]string{
+				"location": bulkImportString(item, "location"),
+				"name":     bulkImportString(item, "name"),
+			}
+		},
+	},
+	"google_dns_managed_zone": {
+		listUrl:    "{{DNSBasePath}}projects/{{project}}/managedZones",
+		itemsField: "managedZones",
+		fields:     bulkImportNameFields,
+	},
+	"google_pubsub_subscription": {
+		listUrl:    "{{PubsubBasePath}}projects/{{project}}/subscriptions",
+		itemsField: "subscriptions",
+		fields:     bulkImportNameFields,
+	},
+	"google_pubsub_topic": {
+		listUrl:    "{{PubsubBasePath}}projects/{{project}}/topics",
+		itemsField: "topics",
+		fields:     bulkImportNameFields,
+	},
+	"google_redis_instance": {
+		listUrl:    "{{RedisBasePath}}projects/{{project}}/locations/-/instances",
+		itemsField: "instances",
+		fields:     bulkImportLocationNameFields("region"),
+	},
+	"google_secret_manager_secret": {
+		listUrl:    "{{SecretManagerBasePath}}projects/{{project}}/secrets",
+		itemsField: "secrets",
+		fields: func(item map[string]interface{}) map[string]string {
+			return map[string]string{"secret_id": GetResourceNameFromSelfLink(bulkImportString(item, "name"))}
+		},
+	},
+	"google_service_account": {
+		listUrl:    "{{IAMBasePath}}projects/{{project}}/serviceAccounts",
+		itemsField: "accounts",
+		fields: func(item map[string]interface{}) map[string]string {
+			email := bulkImportString(item, "email")
+			return map[string]string{"email": email, "account_id": strings.SplitN(email, "@", 2)[0]}
+		},
+	},
+	"google_sql_database_instance": {
+		listUrl:    "{{SQLBasePath}}projects/{{project}}/instances",
+		itemsField: "items",
+		fields:     bulkImportNameFields,
+	},
+	"google_storage_bucket": {
+		importId:   "{{project}}/{{name}}",
+		listUrl:    "{{StorageBasePath}}b?project={{project}}",
+		itemsField: "items",
+		fields:     bulkImportNameFields,
+	},
+}
+
+func bulkImportString(item map[string]interface{}, field string) string {
+	v, _ := item[field].(string)
+	return v
+}
+
+func bulkImportResourceTypeNames() []string {
+	names := make([]string, 0, len(bulkImportResourceTypes))
+	for n := range bulkImportResourceTypes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bulkImportTypeInfo is what's needed from the provider's resource to import
+// the resources of a type.
+type bulkImportTypeInfo struct {
+	importId string
+	schema   map[string]*schema.Schema
+}
+
+var (
+	bulkImportTypeInfosOnce sync.Once
+	bulkImportTypeInfos     map[string]bulkImportTypeInfo
+	bulkImportTypeInfosErr  error
+)
+
+// getBulkImportTypeInfos returns the import ID and schema of each type of
+// resource that can be imported in bulk. They're found from the provider's
+// resources the first time they're needed.
+func getBulkImportTypeInfos() (map[string]bulkImportTypeInfo, error) {
+	bulkImportTypeInfosOnce.Do(func() {
+		resources := ResourceMap()
+		infos := make(map[string]bulkImportTypeInfo, len(bulkImportResourceTypes))
+		for name, t := range bulkImportResourceTypes {
+			r, ok := resources[name]
+			if !ok {
+				bulkImportTypeInfosErr = fmt.Errorf("%s isn't a resource of the provider", name)
+				return
+			}
+			importId := t.importId
+			if importId == "" {
+				var err error
+				if importId, err = bulkImportIdFromImporter(r); err != nil {
+					bulkImportTypeInfosErr = fmt.Errorf("Error finding the import ID of %s: %s", name, err)
+					return
+				}
+			}
+			infos[name] = bulkImportTypeInfo{importId: importId, schema: r.Schema}
+		}
+		bulkImportTypeInfos = infos
+	})
+	return bulkImportTypeInfos, bulkImportTypeInfosErr
+}
+
+// bulkImportProbeId is an import ID matching none of the formats passed to
+// parseImportId, as their fields are made of at least one character other
+// than "/".
+const bulkImportProbeId = "/"
+
+// bulkImportIdFromImporter returns the import ID of a resource, from the
+// first of the formats its importer passes to parseImportId. The formats are
+// found by importing an ID that doesn't match any of them.
+func bulkImportIdFromImporter(r *schema.Resource) (string, error) {
+	if r.Importer == nil {
+		return "", fmt.Errorf("the resource can't be imported")
+	}
+	d := r.Data(nil)
+	d.SetId(bulkImportProbeId)
+	var err error
+	if r.Importer.StateContext != nil {
+		_, err = r.Importer.StateContext(context.Background(), d, &Config{})
+	} else if r.Importer.State != nil {
+		_, err = r.Importer.State(d, &Config{})
+	}
+
+	var formatErr *importIdFormatError
+	if !errors.As(err, &formatErr) || len(formatErr.idRegexes) == 0 {
+		return "", fmt.Errorf("the resource's importer doesn't use parseImportId")
+	}
+	return bulkImportIdFromRegex(formatErr.idRegexes[0])
+}
+
+// Matches a named field of an import ID format, e.g. (?P<name>[^/]+).
+var bulkImportRegexFieldRegexp = regexp.MustCompile(`\(\?P<([a-z_]+)>\[\^/\]\+\)`)
+
+// bulkImportIdFromRegex turns an import ID format into an import ID with
+// {{field}} placeholders, e.g. "projects/(?P<project>[^/]+)/topics/(?P<name>[^/]+)"
+// into "projects/{{project}}/topics/{{name}}".
+func bulkImportIdFromRegex(idRegex string) (string, error) {
+	importId := bulkImportRegexFieldRegexp.ReplaceAllString(strings.TrimSuffix(strings.TrimPrefix(idRegex, "^"), "$"), "{{$1}}")
+	if strings.ContainsAny(bulkImportPlaceholderRegexp.ReplaceAllString(importId, ""), `\()[]{}*+?|^$`) {
+		return "", fmt.Errorf("the import ID format %q isn't a plain path", idRegex)
+	}
+	return importId, nil
+}
+
+func dataSourceGoogleBulkImport() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGoogleBulkImportRead,
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"resource_types": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateBulkImportResourceType,
+				},
+			},
+			"resources": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"resource_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"import_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"import_blocks": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"resource_blocks": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func validateBulkImportResourceType(v interface{}, k string) (ws []string, errors []error) {
+	if _, ok := bulkImportResourceTypes[v.(string)]; !ok {
+		errors = append(errors, fmt.Errorf("%q (%q) can't be imported in bulk, expected one of %s", k, v, strings.Join(bulkImportResourceTypeNames(), ", ")))
+	}
+	return
+}
+
+// bulkImportResource is an existing resource to import.
+type bulkImportResource struct {
+	resourceType string
+	// resourceName is the name of the resource in the configuration.
+	resourceName string
+	importId     string
+	fields       map[string]string
+}
+
+func dataSourceGoogleBulkImportRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return err
+	}
+
+	infos, err := getBulkImportTypeInfos()
+	if err != nil {
+		return err
+	}
+
+	resourceTypes := convertStringSet(d.Get("resource_types").(*schema.Set))
+	sort.Strings(resourceTypes)
+
+	var resources []bulkImportResource
+	for _, resourceType := range resourceTypes {
+		t := bulkImportResourceTypes[resourceType]
+		items, err := listBulkImportItems(d, config, userAgent, t)
+		if err != nil {
+			return fmt.Errorf("Error listing %s resources in project %s: %s", resourceType, project, err)
+		}
+
+		for _, item := range items {
+			fields := t.fields(item)
+			if fields == nil {
+				continue
+			}
+			fields["project"] = project
+			resources = append(resources, bulkImportResource{
+				resourceType: resourceType,
+				importId:     formatBulkImportId(infos[resourceType].importId, fields),
+				fields:       fields,
+			})
+		}
+	}
+	setBulkImportResourceNames(resources)
+
+	flattened := make([]map[string]interface{}, 0, len(resources))
+	var importBlocks, resourceBlocks []string
+	for _, r := range resources {
+		flattened = append(flattened, map[string]interface{}{
+			"resource_type": r.resourceType,
+			"resource_name": r.resourceName,
+			"import_id":     r.importId,
+		})
+		importBlocks = append(importBlocks, bulkImportBlock(r))
+		resourceBlocks = append(resourceBlocks, bulkImportResourceBlock(r, infos[r.resourceType].schema))
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", project, strings.Join(resourceTypes, ",")))
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error setting project: %s", err)
+	}
+	if err := d.Set("resources", flattened); err != nil {
+		return fmt.Errorf("Error setting resources: %s", err)
+	}
+	if err := d.Set("import_blocks", strings.Join(importBlocks, "\n")); err != nil {
+		return fmt.Errorf("Error setting import_blocks: %s", err)
+	}
+	if err := d.Set("resource_blocks", strings.Join(resourceBlocks, "\n")); err != nil {
+		return fmt.Errorf("Error setting resource_blocks: %s", err)
+	}
+	return nil
+}
+
+// listBulkImportItems lists every page of the resources of a type.
+func listBulkImportItems(d *schema.ResourceData, config *Config, userAgent string, t bulkImportResourceType) ([]map[string]interface{}, error) {
+	baseUrl, err := replaceVars(d, config, t.listUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]interface{}
+	params := make(map[string]string)
+	for {
+		url, err := addQueryParams(baseUrl, params)
+		if err != nil {
+			return nil, err
+		}
+		res, err := sendRequest(config, "GET", "", url, userAgent, nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, flattenBulkImportItems(res, t)...)
+
+		pageToken, _ := res["nextPageToken"].(string)
+		if pageToken == "" {
+			return items, nil
+		}
+		params["pageToken"] = pageToken
+	}
+}
+
+func flattenBulkImportItems(res map[string]interface{}, t bulkImportResourceType) []map[string]interface{} {
+	var lists []interface{}
+	if t.aggregated {
+		// e.g. {"items": {"zones/us-central1-a": {"instances": [...]}}}
+		scopes, _ := res["items"].(map[string]interface{})
+		names := make([]string, 0, len(scopes))
+		for name := range scopes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if scope, ok := scopes[name].(map[string]interface{}); ok {
+				lists = append(lists, scope[t.itemsField])
+			}
+		}
+	} else {
+		lists = append(lists, res[t.itemsField])
+	}
+
+	var items []map[string]interface{}
+	for _, l := range lists {
+		ls, _ := l.([]interface{})
+		for _, raw := range ls {
+			if item, ok := raw.(map[string]interface{}); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+var bulkImportPlaceholderRegexp = regexp.MustCompile(`{{([a-z_]+)}}`)
+
+// formatBulkImportId replaces the {{field}} placeholders of an import ID.
+func formatBulkImportId(tmpl string, fields map[string]string) string {
+	return bulkImportPlaceholderRegexp.ReplaceAllStringFunc(tmpl, func(s string) string {
+		return fields[bulkImportPlaceholderRegexp.FindStringSubmatch(s)[1]]
+	})
+}
+
+var bulkImportInvalidNameCharsRegexp = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// setBulkImportResourceNames names each resource in the configuration after
+// its own name. Resources of the same type sharing a name are told apart by a
+// _2, _3, ... suffix, skipping the names of every other resource of the type.
+func setBulkImportResourceNames(resources []bulkImportResource) {
+	names := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		names[r.resourceType+"."+bulkImportResourceName(r.fields)] = true
+	}
+
+	used := make(map[string]bool, len(resources))
+	for i, r := range resources {
+		base := bulkImportResourceName(r.fields)
+		name := base
+		if used[r.resourceType+"."+name] {
+			for n := 2; used[r.resourceType+"."+name] || names[r.resourceType+"."+name]; n++ {
+				name = fmt.Sprintf("%s_%d", base, n)
+			}
+		}
+		used[r.resourceType+"."+name] = true
+		resources[i].resourceName = name
+	}
+}
+
+// bulkImportResourceName returns a valid name for a resource in the
+// configuration, based on its own name.
+func bulkImportResourceName(fields map[string]string) string {
+	var name string
+	for _, k := range []string{"name", "secret_id", "account_id"} {
+		if name = fields[k]; name != "" {
+			break
+		}
+	}
+	name = strings.Trim(bulkImportInvalidNameCharsRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "r_" + name
+	}
+	return name
+}
+
+func bulkImportBlock(r bulkImportResource) string {
+	return fmt.Sprintf("import {\n  to = %s.%s\n  id = %s\n}\n", r.resourceType, r.resourceName, hclQuote(r.importId))
+}
+
+// bulkImportResourceBlock returns a skeleton resource block for a resource,
+// setting the arguments known from its import ID, and listing the other
+// required arguments and blocks in a comment.
+func bulkImportResourceBlock(r bulkImportResource, s map[string]*schema.Schema) string {
+	var known, required []string
+	for k, v := range s {
+		// Computed only attributes, e.g. the email of a service account,
+		// can't be set.
+		if _, ok := r.fields[k]; ok && (v.Required || v.Optional) {
+			known = append(known, k)
+		} else if v.Required {
+			required = append(required, k)
+		}
+	}
+	sort.Strings(known)
+	sort.Strings(required)
+
+	width := 0
+	for _, k := range known {
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "resource %q %q {\n", r.resourceType, r.resourceName)
+	for _, k := range known {
+		fmt.Fprintf(&b, "  %-*s = %s\n", width, k, hclQuote(r.fields[k]))
+	}
+	if len(required) > 0 {
+		b.WriteString("\n  # Required arguments that aren't part of the import ID:\n")
+		for _, k := range required {
+			if _, ok := s[k].Elem.(*schema.Resource); ok {
+				fmt.Fprintf(&b, "  # %s { ... }\n", k)
+			} else {
+				fmt.Fprintf(&b, "  # %s = %s\n", k, hclTypeName(s[k]))
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hclTypeName returns the HCL type of an attribute, e.g. list(string).
+func hclTypeName(s *schema.Schema) string {
+	switch s.Type {
+	case schema.TypeBool:
+		return "bool"
+	case schema.TypeInt, schema.TypeFloat:
+		return "number"
+	case schema.TypeList, schema.TypeSet, schema.TypeMap:
+		elem := "string"
+		if e, ok := s.Elem.(*schema.Schema); ok {
+			elem = hclTypeName(e)
+		}
+		return fmt.Sprintf("%s(%s)", map[schema.ValueType]string{schema.TypeList: "list", schema.TypeSet: "set", schema.TypeMap: "map"}[s.Type], elem)
+	}
+	return "string"
+}
+
+// hclQuote returns a quoted HCL string literal, with template sequences
+// escaped so they're taken literally.
+func hclQuote(s string) string {
+	q := strconv.Quote(s)
+	q = strings.Replace(q, "${", "$${", -1)
+	return strings.Replace(q, "%{", "%%{", -1)
+}
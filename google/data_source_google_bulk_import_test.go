@@ -0,0 +1,241 @@
+package google
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TestBulkImportResourceTypes checks the import IDs of each type are accepted
+// by the resource's importer, and set the fields they were formatted from.
+func TestBulkImportResourceTypes(t *testing.T) {
+	resources := ResourceMap()
+	config := &Config{Project: "default-project", Region: "default-region", Zone: "default-zone"}
+	infos, err := getBulkImportTypeInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importers that call the API after parsing the ID can't run offline.
+	skip := map[string]bool{
+		"google_container_cluster": true,
+	}
+
+	for resourceType, info := range infos {
+		if skip[resourceType] {
+			continue
+		}
+		r, ok := resources[resourceType]
+		if !ok || r.Importer == nil {
+			t.Errorf("%s: not an importable resource", resourceType)
+			continue
+		}
+
+		fields := map[string]string{"project": "test-project"}
+		for _, m := range bulkImportPlaceholderRegexp.FindAllStringSubmatch(info.importId, -1) {
+			if m[1] != "project" {
+				fields[m[1]] = "test-" + strings.Replace(m[1], "_", "-", -1)
+			}
+		}
+
+		d := r.Data(nil)
+		d.SetId(formatBulkImportId(info.importId, fields))
+		var imported []*schema.ResourceData
+		var err error
+		if r.Importer.StateContext != nil {
+			imported, err = r.Importer.StateContext(nil, d, config)
+		} else {
+			imported, err = r.Importer.State(d, config)
+		}
+		if err != nil {
+			t.Errorf("%s: error importing %q: %s", resourceType, d.Id(), err)
+			continue
+		}
+
+		// Passthrough importers leave parsing the ID to Read.
+		if imported[0].Get("name") == "" && imported[0].Id() == d.Id() {
+			continue
+		}
+		for k, v := range fields {
+			if _, ok := r.Schema[k]; !ok {
+				continue
+			}
+			if got := imported[0].Get(k); got != v {
+				t.Errorf("%s: expected importing %q to set %s to %q, got %q", resourceType, d.Id(), k, v, got)
+			}
+		}
+	}
+}
+
+func TestBulkImportIdFromImporter(t *testing.T) {
+	resources := ResourceMap()
+	cases := map[string]string{
+		"google_compute_instance":      "projects/{{project}}/zones/{{zone}}/instances/{{name}}",
+		"google_redis_instance":        "projects/{{project}}/locations/{{region}}/instances/{{name}}",
+		"google_secret_manager_secret": "projects/{{project}}/secrets/{{secret_id}}",
+		"google_service_account":       "projects/{{project}}/serviceAccounts/{{email}}",
+	}
+	for resourceType, expected := range cases {
+		got, err := bulkImportIdFromImporter(resources[resourceType])
+		if err != nil || got != expected {
+			t.Errorf("%s: expected import ID %q, got %q, %v", resourceType, expected, got, err)
+		}
+	}
+
+	// The storage bucket importer splits the ID itself.
+	if _, err := bulkImportIdFromImporter(resources["google_storage_bucket"]); err == nil {
+		t.Errorf("expected no import ID to be found for google_storage_bucket")
+	}
+
+	if _, err := bulkImportIdFromRegex("projects/(?P<project>[^/]+)/notes/(?P<name>.+)"); err == nil {
+		t.Errorf("expected a format with a field that isn't a path segment not to be turned into an import ID")
+	}
+}
+
+func TestFlattenBulkImportItems(t *testing.T) {
+	res := map[string]interface{}{
+		"items": map[string]interface{}{
+			"zones/us-central1-b": map[string]interface{}{
+				"instances": []interface{}{
+					map[string]interface{}{"name": "vm-2", "zone": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-b"},
+				},
+			},
+			"zones/us-central1-a": map[string]interface{}{
+				"instances": []interface{}{
+					map[string]interface{}{"name": "vm-1", "zone": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a"},
+				},
+			},
+			"zones/us-east1-b": map[string]interface{}{
+				"warning": map[string]interface{}{"code": "NO_RESULTS_ON_PAGE"},
+			},
+		},
+	}
+
+	bt := bulkImportResourceTypes["google_compute_instance"]
+	var got []map[string]string
+	for _, item := range flattenBulkImportItems(res, bt) {
+		got = append(got, bt.fields(item))
+	}
+	expected := []map[string]string{
+		{"name": "vm-1", "zone": "us-central1-a"},
+		{"name": "vm-2", "zone": "us-central1-b"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	res = map[string]interface{}{
+		"topics": []interface{}{
+			map[string]interface{}{"name": "projects/p/topics/my-topic"},
+		},
+	}
+	bt = bulkImportResourceTypes["google_pubsub_topic"]
+	items := flattenBulkImportItems(res, bt)
+	if len(items) != 1 || bt.fields(items[0])["name"] != "my-topic" {
+		t.Errorf("expected topic my-topic, got %v", items)
+	}
+}
+
+func TestSetBulkImportResourceNames(t *testing.T) {
+	cases := []struct {
+		resourceType string
+		fields       map[string]string
+		expected     string
+	}{
+		{"google_compute_network", map[string]string{"name": "My-Network"}, "my-network"},
+		{"google_compute_network", map[string]string{"name": "my-network"}, "my-network_3"},
+		{"google_compute_network", map[string]string{"name": "my-network_2"}, "my-network_2"},
+		{"google_compute_subnetwork", map[string]string{"name": "my-network"}, "my-network"},
+		{"google_storage_bucket", map[string]string{"name": "my.bucket.example.com"}, "my_bucket_example_com"},
+		{"google_storage_bucket", map[string]string{"name": "1st-bucket"}, "r_1st-bucket"},
+		{"google_service_account", map[string]string{"account_id": "deployer", "email": "deployer@p.iam.gserviceaccount.com"}, "deployer"},
+	}
+	resources := make([]bulkImportResource, 0, len(cases))
+	for _, tc := range cases {
+		resources = append(resources, bulkImportResource{resourceType: tc.resourceType, fields: tc.fields})
+	}
+	setBulkImportResourceNames(resources)
+	for i, tc := range cases {
+		if got := resources[i].resourceName; got != tc.expected {
+			t.Errorf("expected name %q for %s %v, got %q", tc.expected, tc.resourceType, tc.fields, got)
+		}
+	}
+}
+
+func TestBulkImportBlocks(t *testing.T) {
+	r := bulkImportResource{
+		resourceType: "google_compute_subnetwork",
+		resourceName: "default",
+		importId:     "projects/my-project/regions/us-central1/subnetworks/default",
+		fields:       map[string]string{"project": "my-project", "region": "us-central1", "name": "default"},
+	}
+
+	expected := `import {
+  to = google_compute_subnetwork.default
+  id = "projects/my-project/regions/us-central1/subnetworks/default"
+}
+`
+	if got := bulkImportBlock(r); got != expected {
+		t.Errorf("expected import block:\n%s\ngot:\n%s", expected, got)
+	}
+
+	expected = `resource "google_compute_subnetwork" "default" {
+  name    = "default"
+  project = "my-project"
+  region  = "us-central1"
+
+  # Required arguments that aren't part of the import ID:
+  # ip_cidr_range = string
+  # network = string
+}
+`
+	if got := bulkImportResourceBlock(r, ResourceMap()["google_compute_subnetwork"].Schema); got != expected {
+		t.Errorf("expected resource block:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestHclQuote(t *testing.T) {
+	if got, expected := hclQuote(`a "b" ${c} %{d}`), `"a \"b\" $${c} %%{d}"`; got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestAccDataSourceGoogleBulkImport_basic(t *testing.T) {
+	t.Parallel()
+
+	network := fmt.Sprintf("tf-test-%s", randString(t, 10))
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGoogleBulkImport_basic(network),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.google_bulk_import.networks", "resources.0.import_id"),
+					resource.TestMatchResourceAttr("data.google_bulk_import.networks", "import_blocks", regexp.MustCompile("global/networks/"+network)),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGoogleBulkImport_basic(network string) string {
+	return fmt.Sprintf(`
+resource "google_compute_network" "network" {
+  name                    = "%s"
+  auto_create_subnetworks = false
+}
+
+data "google_bulk_import" "networks" {
+  resource_types = ["google_compute_network"]
+
+  depends_on = [google_compute_network.network]
+}
+`, network)
+}
@@ -0,0 +1,296 @@
+package google
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// iamPolicyLevel is a resource in the resource hierarchy with the IAM policy
+// attached to it.
+type iamPolicyLevel struct {
+	// level is the type of the resource, e.g. "project" or "folder".
+	level string
+	// source is the resource's name, e.g. "projects/my-project".
+	source string
+	policy *cloudresourcemanager.Policy
+}
+
+var iamEffectivePolicyResourceArgs = []string{"bucket", "project", "folder", "organization"}
+
+var iamEffectivePolicyConditionSchema = &schema.Schema{
+	Type:     schema.TypeList,
+	Computed: true,
+	Elem: &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"expression": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"title": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	},
+}
+
+func dataSourceGoogleIamEffectivePolicy() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGoogleIamEffectivePolicyRead,
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: iamEffectivePolicyResourceArgs,
+			},
+			"project": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: iamEffectivePolicyResourceArgs,
+			},
+			"folder": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: iamEffectivePolicyResourceArgs,
+			},
+			"organization": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: iamEffectivePolicyResourceArgs,
+			},
+			"sources": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"bindings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"role": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"members": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"condition": iamEffectivePolicyConditionSchema,
+					},
+				},
+			},
+			"members": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"role": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"member": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"condition": iamEffectivePolicyConditionSchema,
+						"source": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"level": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGoogleIamEffectivePolicyRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	var start iamPolicyLevel
+	switch {
+	case d.Get("bucket").(string) != "":
+		start = iamPolicyLevel{level: "bucket", source: "b/" + strings.TrimPrefix(d.Get("bucket").(string), "b/")}
+	case d.Get("project").(string) != "":
+		start = iamPolicyLevel{level: "project", source: "projects/" + strings.TrimPrefix(d.Get("project").(string), "projects/")}
+	case d.Get("folder").(string) != "":
+		start = iamPolicyLevel{level: "folder", source: canonicalFolderId(d.Get("folder").(string))}
+	default:
+		start = iamPolicyLevel{level: "organization", source: "organizations/" + strings.TrimPrefix(d.Get("organization").(string), "organizations/")}
+	}
+
+	levels, err := iamPolicyHierarchy(config, userAgent, start)
+	if err != nil {
+		return err
+	}
+	for i := range levels {
+		updater, err := newIamPolicyLevelUpdater(config, levels[i])
+		if err != nil {
+			return err
+		}
+		levels[i].policy, err = iamPolicyReadWithRetry(config, updater)
+		if err != nil {
+			// A missing level would leave out the bindings it grants, so it's
+			// an error rather than an empty result.
+			return fmt.Errorf("Error reading IAM policy of %s: %s", updater.DescribeResource(), err)
+		}
+	}
+
+	sources := make([]string, len(levels))
+	for i, l := range levels {
+		sources[i] = l.source
+	}
+	if err := d.Set("sources", sources); err != nil {
+		return fmt.Errorf("Error setting sources: %s", err)
+	}
+	if err := d.Set("bindings", flattenIamEffectiveBindings(levels)); err != nil {
+		return fmt.Errorf("Error setting bindings: %s", err)
+	}
+	if err := d.Set("members", flattenIamEffectiveMembers(levels)); err != nil {
+		return fmt.Errorf("Error setting members: %s", err)
+	}
+
+	d.SetId(start.source)
+	return nil
+}
+
+// iamPolicyHierarchy returns the resource start and its ancestors that IAM
+// policies are inherited from, from start up to the organization.
+func iamPolicyHierarchy(config *Config, userAgent string, start iamPolicyLevel) ([]iamPolicyLevel, error) {
+	levels := []iamPolicyLevel{start}
+
+	switch start.level {
+	case "bucket":
+		bucket, err := config.NewStorageClient(userAgent).Buckets.Get(strings.TrimPrefix(start.source, "b/")).Do()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading bucket %q: %s", start.source, err)
+		}
+		ancestors, err := iamPolicyHierarchy(config, userAgent, iamPolicyLevel{
+			level:  "project",
+			source: "projects/" + strconv.FormatUint(bucket.ProjectNumber, 10),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(levels, ancestors...), nil
+
+	case "project":
+		pid := strings.TrimPrefix(start.source, "projects/")
+		ancestry, err := config.NewResourceManagerClient(userAgent).Projects.GetAncestry(pid, &cloudresourcemanager.GetAncestryRequest{}).Do()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading ancestry of project %q: %s", pid, err)
+		}
+		for _, a := range ancestry.Ancestor {
+			if a.ResourceId == nil || a.ResourceId.Type == "project" {
+				continue
+			}
+			levels = append(levels, iamPolicyLevel{
+				level:  a.ResourceId.Type,
+				source: fmt.Sprintf("%ss/%s", a.ResourceId.Type, a.ResourceId.Id),
+			})
+		}
+
+	case "folder":
+		for parent := start.source; strings.HasPrefix(parent, "folders/"); {
+			folder, err := config.NewResourceManagerV2Client(userAgent).Folders.Get(parent).Do()
+			if err != nil {
+				return nil, fmt.Errorf("Error reading folder %q: %s", parent, err)
+			}
+			parent = folder.Parent
+			switch {
+			case strings.HasPrefix(parent, "folders/"):
+				levels = append(levels, iamPolicyLevel{level: "folder", source: parent})
+			case strings.HasPrefix(parent, "organizations/"):
+				levels = append(levels, iamPolicyLevel{level: "organization", source: parent})
+			}
+		}
+	}
+
+	return levels, nil
+}
+
+// newIamPolicyLevelUpdater returns the ResourceIamUpdater for the IAM policy of
+// a resource in the hierarchy.
+func newIamPolicyLevelUpdater(config *Config, l iamPolicyLevel) (ResourceIamUpdater, error) {
+	var (
+		s          map[string]*schema.Schema
+		field, id  string
+		newUpdater newResourceIamUpdaterFunc
+	)
+	switch l.level {
+	case "bucket":
+		s, field, id, newUpdater = StorageBucketIamSchema, "bucket", strings.TrimPrefix(l.source, "b/"), StorageBucketIamUpdaterProducer
+	case "project":
+		s, field, id, newUpdater = IamProjectSchema, "project", strings.TrimPrefix(l.source, "projects/"), NewProjectIamPolicyUpdater
+	case "folder":
+		s, field, id, newUpdater = IamFolderSchema, "folder", l.source, NewFolderIamUpdater
+	case "organization":
+		s, field, id, newUpdater = IamOrganizationSchema, "org_id", strings.TrimPrefix(l.source, "organizations/"), NewOrganizationIamUpdater
+	default:
+		return nil, fmt.Errorf("IAM policies of %s resources aren't supported", l.level)
+	}
+
+	d := (&schema.Resource{Schema: s}).Data(nil)
+	if err := d.Set(field, id); err != nil {
+		return nil, fmt.Errorf("Error setting %s: %s", field, err)
+	}
+	return newUpdater(d, config)
+}
+
+// flattenIamEffectiveBindings merges the bindings of every level, so each
+// role and condition has a single binding with the members granted it at any
+// level.
+func flattenIamEffectiveBindings(levels []iamPolicyLevel) []map[string]interface{} {
+	var all []*cloudresourcemanager.Binding
+	for _, l := range levels {
+		all = append(all, l.policy.Bindings...)
+	}
+
+	bindings := make([]map[string]interface{}, 0)
+	for _, b := range mergeBindings(all) {
+		bindings = append(bindings, map[string]interface{}{
+			"role":      b.Role,
+			"members":   b.Members,
+			"condition": flattenIamCondition(b.Condition),
+		})
+	}
+	return bindings
+}
+
+// flattenIamEffectiveMembers returns one entry per role, member and condition
+// granted at each level, starting from the resource and going up.
+func flattenIamEffectiveMembers(levels []iamPolicyLevel) []map[string]interface{} {
+	members := make([]map[string]interface{}, 0)
+	for _, l := range levels {
+		for _, b := range mergeBindings(l.policy.Bindings) {
+			for _, m := range b.Members {
+				members = append(members, map[string]interface{}{
+					"role":      b.Role,
+					"member":    m,
+					"condition": flattenIamCondition(b.Condition),
+					"source":    l.source,
+					"level":     l.level,
+				})
+			}
+		}
+	}
+	return members
+}
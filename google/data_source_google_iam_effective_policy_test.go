@@ -0,0 +1,145 @@
+package google
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+func testIamEffectivePolicyLevels() []iamPolicyLevel {
+	return []iamPolicyLevel{
+		{
+			level:  "project",
+			source: "projects/my-project",
+			policy: &cloudresourcemanager.Policy{
+				Bindings: []*cloudresourcemanager.Binding{
+					{Role: "roles/viewer", Members: []string{"user:jane@example.com"}},
+				},
+			},
+		},
+		{
+			level:  "folder",
+			source: "folders/123",
+			policy: &cloudresourcemanager.Policy{
+				Bindings: []*cloudresourcemanager.Binding{
+					{Role: "roles/viewer", Members: []string{"group:eng@example.com", "user:jane@example.com"}},
+					{
+						Role:      "roles/editor",
+						Members:   []string{"user:oncall@example.com"},
+						Condition: &cloudresourcemanager.Expr{Title: "business-hours", Expression: "request.time.getHours() < 18"},
+					},
+				},
+			},
+		},
+		{
+			level:  "organization",
+			source: "organizations/456",
+			policy: &cloudresourcemanager.Policy{},
+		},
+	}
+}
+
+func TestFlattenIamEffectiveBindings(t *testing.T) {
+	expected := []map[string]interface{}{
+		{
+			"role":    "roles/editor",
+			"members": []string{"user:oncall@example.com"},
+			"condition": []map[string]interface{}{
+				{"title": "business-hours", "description": "", "expression": "request.time.getHours() < 18"},
+			},
+		},
+		{
+			"role":      "roles/viewer",
+			"members":   []string{"group:eng@example.com", "user:jane@example.com"},
+			"condition": []map[string]interface{}(nil),
+		},
+	}
+
+	if got := flattenIamEffectiveBindings(testIamEffectivePolicyLevels()); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected bindings %v, got %v", expected, got)
+	}
+}
+
+func TestFlattenIamEffectiveMembers(t *testing.T) {
+	got := flattenIamEffectiveMembers(testIamEffectivePolicyLevels())
+
+	type row struct{ role, member, source, level string }
+	expected := []row{
+		{"roles/viewer", "user:jane@example.com", "projects/my-project", "project"},
+		{"roles/editor", "user:oncall@example.com", "folders/123", "folder"},
+		{"roles/viewer", "group:eng@example.com", "folders/123", "folder"},
+		{"roles/viewer", "user:jane@example.com", "folders/123", "folder"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d members, got %d: %v", len(expected), len(got), got)
+	}
+	for i, e := range expected {
+		r := row{got[i]["role"].(string), got[i]["member"].(string), got[i]["source"].(string), got[i]["level"].(string)}
+		if r != e {
+			t.Errorf("expected member %d to be %v, got %v", i, e, r)
+		}
+	}
+	if c := got[1]["condition"].([]map[string]interface{}); len(c) != 1 || c[0]["title"] != "business-hours" {
+		t.Errorf("expected member 1 to have a condition, got %v", got[1]["condition"])
+	}
+}
+
+func TestAccDataSourceGoogleIamEffectivePolicy_project(t *testing.T) {
+	t.Parallel()
+
+	project := getTestProjectFromEnv()
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGoogleIamEffectivePolicy_project(project),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.google_iam_effective_policy.project", "sources.0", "projects/"+project),
+					resource.TestCheckResourceAttrSet("data.google_iam_effective_policy.project", "bindings.#"),
+					resource.TestCheckResourceAttrSet("data.google_iam_effective_policy.project", "members.0.role"),
+					resource.TestCheckResourceAttr("data.google_iam_effective_policy.project", "members.0.level", "project"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGoogleIamEffectivePolicy_project(project string) string {
+	return fmt.Sprintf(`
+data "google_iam_effective_policy" "project" {
+  project = "%s"
+}
+`, project)
+}
+
+func TestNewIamPolicyLevelUpdater(t *testing.T) {
+	cases := []struct {
+		level    iamPolicyLevel
+		expected string
+	}{
+		{iamPolicyLevel{level: "bucket", source: "b/my-bucket"}, "b/my-bucket"},
+		{iamPolicyLevel{level: "project", source: "projects/my-project"}, "my-project"},
+		{iamPolicyLevel{level: "folder", source: "folders/123"}, "folders/123"},
+		{iamPolicyLevel{level: "organization", source: "organizations/456"}, "456"},
+	}
+
+	for _, tc := range cases {
+		updater, err := newIamPolicyLevelUpdater(&Config{}, tc.level)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.level.source, err)
+			continue
+		}
+		if got := updater.GetResourceId(); got != tc.expected {
+			t.Errorf("%s: expected resource id %q, got %q", tc.level.source, tc.expected, got)
+		}
+	}
+
+	if _, err := newIamPolicyLevelUpdater(&Config{}, iamPolicyLevel{level: "dataset", source: "datasets/d"}); err == nil {
+		t.Errorf("expected error for unsupported level")
+	}
+}
@@ -0,0 +1,189 @@
+package google
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// iamPermissionsTestableResource is what's needed to build the
+// ResourceIamUpdater of a resource from the ID its IAM resources import.
+type iamPermissionsTestableResource struct {
+	schema     map[string]*schema.Schema
+	newUpdater newResourceIamUpdaterFunc
+	idParser   resourceIdParserFunc
+}
+
+// iamPermissionsTestableResources are the resource types, by the prefix of
+// their IAM resources' names, whose updaters implement
+// ResourceIamPermissionsTester.
+var iamPermissionsTestableResources = map[string]iamPermissionsTestableResource{
+	"google_bigquery_table":                {BigQueryTableIamSchema, BigQueryTableIamUpdaterProducer, BigQueryTableIdParseFunc},
+	"google_bigtable_instance":             {IamBigtableInstanceSchema, NewBigtableInstanceUpdater, BigtableInstanceIdParseFunc},
+	"google_bigtable_table":                {IamBigtableTableSchema, NewBigtableTableUpdater, BigtableTableIdParseFunc},
+	"google_billing_account":               {IamBillingAccountSchema, NewBillingAccountIamUpdater, BillingAccountIdParseFunc},
+	"google_binary_authorization_attestor": {BinaryAuthorizationAttestorIamSchema, BinaryAuthorizationAttestorIamUpdaterProducer, BinaryAuthorizationAttestorIdParseFunc},
+	"google_cloud_run_service":             {CloudRunServiceIamSchema, CloudRunServiceIamUpdaterProducer, CloudRunServiceIdParseFunc},
+	"google_cloudfunctions_function":       {CloudFunctionsCloudFunctionIamSchema, CloudFunctionsCloudFunctionIamUpdaterProducer, CloudFunctionsCloudFunctionIdParseFunc},
+	"google_compute_disk":                  {ComputeDiskIamSchema, ComputeDiskIamUpdaterProducer, ComputeDiskIdParseFunc},
+	"google_compute_image":                 {ComputeImageIamSchema, ComputeImageIamUpdaterProducer, ComputeImageIdParseFunc},
+	"google_compute_instance":              {ComputeInstanceIamSchema, ComputeInstanceIamUpdaterProducer, ComputeInstanceIdParseFunc},
+	"google_compute_region_disk":           {ComputeRegionDiskIamSchema, ComputeRegionDiskIamUpdaterProducer, ComputeRegionDiskIdParseFunc},
+	"google_compute_subnetwork":            {ComputeSubnetworkIamSchema, ComputeSubnetworkIamUpdaterProducer, ComputeSubnetworkIdParseFunc},
+	"google_data_catalog_entry_group":      {DataCatalogEntryGroupIamSchema, DataCatalogEntryGroupIamUpdaterProducer, DataCatalogEntryGroupIdParseFunc},
+	"google_data_catalog_tag_template":     {DataCatalogTagTemplateIamSchema, DataCatalogTagTemplateIamUpdaterProducer, DataCatalogTagTemplateIdParseFunc},
+	"google_dataproc_cluster":              {IamDataprocClusterSchema, NewDataprocClusterUpdater, DataprocClusterIdParseFunc},
+	"google_dataproc_job":                  {IamDataprocJobSchema, NewDataprocJobUpdater, DataprocJobIdParseFunc},
+	"google_endpoints_service":             {ServiceManagementServiceIamSchema, ServiceManagementServiceIamUpdaterProducer, ServiceManagementServiceIdParseFunc},
+	"google_folder":                        {IamFolderSchema, NewFolderIamUpdater, FolderIdParseFunc},
+	"google_healthcare_consent_store":      {HealthcareConsentStoreIamSchema, HealthcareConsentStoreIamUpdaterProducer, HealthcareConsentStoreIdParseFunc},
+	"google_healthcare_dataset":            {IamHealthcareDatasetSchema, NewHealthcareDatasetIamUpdater, DatasetIdParseFunc},
+	"google_healthcare_dicom_store":        {IamHealthcareDicomStoreSchema, NewHealthcareDicomStoreIamUpdater, DicomStoreIdParseFunc},
+	"google_healthcare_fhir_store":         {IamHealthcareFhirStoreSchema, NewHealthcareFhirStoreIamUpdater, FhirStoreIdParseFunc},
+	"google_healthcare_hl7_v2_store":       {IamHealthcareHl7V2StoreSchema, NewHealthcareHl7V2StoreIamUpdater, Hl7V2StoreIdParseFunc},
+	"google_iap_app_engine_service":        {IapAppEngineServiceIamSchema, IapAppEngineServiceIamUpdaterProducer, IapAppEngineServiceIdParseFunc},
+	"google_iap_app_engine_version":        {IapAppEngineVersionIamSchema, IapAppEngineVersionIamUpdaterProducer, IapAppEngineVersionIdParseFunc},
+	"google_iap_tunnel":                    {IapTunnelIamSchema, IapTunnelIamUpdaterProducer, IapTunnelIdParseFunc},
+	"google_iap_tunnel_instance":           {IapTunnelInstanceIamSchema, IapTunnelInstanceIamUpdaterProducer, IapTunnelInstanceIdParseFunc},
+	"google_iap_web":                       {IapWebIamSchema, IapWebIamUpdaterProducer, IapWebIdParseFunc},
+	"google_iap_web_backend_service":       {IapWebBackendServiceIamSchema, IapWebBackendServiceIamUpdaterProducer, IapWebBackendServiceIdParseFunc},
+	"google_iap_web_type_app_engine":       {IapWebTypeAppEngineIamSchema, IapWebTypeAppEngineIamUpdaterProducer, IapWebTypeAppEngineIdParseFunc},
+	"google_iap_web_type_compute":          {IapWebTypeComputeIamSchema, IapWebTypeComputeIamUpdaterProducer, IapWebTypeComputeIdParseFunc},
+	"google_kms_crypto_key":                {IamKmsCryptoKeySchema, NewKmsCryptoKeyIamUpdater, CryptoIdParseFunc},
+	"google_kms_key_ring":                  {IamKmsKeyRingSchema, NewKmsKeyRingIamUpdater, KeyRingIdParseFunc},
+	"google_notebooks_instance":            {NotebooksInstanceIamSchema, NotebooksInstanceIamUpdaterProducer, NotebooksInstanceIdParseFunc},
+	"google_organization":                  {IamOrganizationSchema, NewOrganizationIamUpdater, OrgIdParseFunc},
+	"google_privateca_ca_pool":             {PrivatecaCaPoolIamSchema, PrivatecaCaPoolIamUpdaterProducer, PrivatecaCaPoolIdParseFunc},
+	"google_project":                       {IamProjectSchema, NewProjectIamUpdater, ProjectIdParseFunc},
+	"google_pubsub_subscription":           {IamPubsubSubscriptionSchema, NewPubsubSubscriptionIamUpdater, PubsubSubscriptionIdParseFunc},
+	"google_pubsub_topic":                  {PubsubTopicIamSchema, PubsubTopicIamUpdaterProducer, PubsubTopicIdParseFunc},
+	"google_runtimeconfig_config":          {RuntimeConfigConfigIamSchema, RuntimeConfigConfigIamUpdaterProducer, RuntimeConfigConfigIdParseFunc},
+	"google_secret_manager_secret":         {SecretManagerSecretIamSchema, SecretManagerSecretIamUpdaterProducer, SecretManagerSecretIdParseFunc},
+	"google_service_account":               {IamServiceAccountSchema, NewServiceAccountIamUpdater, ServiceAccountIdParseFunc},
+	"google_sourcerepo_repository":         {SourceRepoRepositoryIamSchema, SourceRepoRepositoryIamUpdaterProducer, SourceRepoRepositoryIdParseFunc},
+	"google_spanner_database":              {IamSpannerDatabaseSchema, NewSpannerDatabaseIamUpdater, SpannerDatabaseIdParseFunc},
+	"google_spanner_instance":              {IamSpannerInstanceSchema, NewSpannerInstanceIamUpdater, SpannerInstanceIdParseFunc},
+	"google_storage_bucket":                {StorageBucketIamSchema, StorageBucketIamUpdaterProducer, StorageBucketIdParseFunc},
+	"google_tags_tag_key":                  {TagsTagKeyIamSchema, TagsTagKeyIamUpdaterProducer, TagsTagKeyIdParseFunc},
+	"google_tags_tag_value":                {TagsTagValueIamSchema, TagsTagValueIamUpdaterProducer, TagsTagValueIdParseFunc},
+}
+
+func iamPermissionsTestableResourceTypes() []string {
+	types := make([]string, 0, len(iamPermissionsTestableResources))
+	for t := range iamPermissionsTestableResources {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func dataSourceGoogleIamTestPermissions() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGoogleIamTestPermissionsRead,
+		Schema: map[string]*schema.Schema{
+			"resource_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(iamPermissionsTestableResourceTypes(), false),
+			},
+			"resource_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"permissions": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"granted_permissions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"denied_permissions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"all_granted": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceGoogleIamTestPermissionsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	tester, updater, err := newIamPermissionsTester(config, d.Get("resource_type").(string), d.Get("resource_id").(string))
+	if err != nil {
+		return err
+	}
+
+	permissions := convertStringArr(d.Get("permissions").([]interface{}))
+	granted, err := tester.TestIamPermissions(permissions)
+	if err != nil {
+		return err
+	}
+	grantedPermissions, deniedPermissions := splitTestedIamPermissions(permissions, granted)
+
+	d.SetId(updater.GetResourceId())
+	if err := d.Set("granted_permissions", grantedPermissions); err != nil {
+		return fmt.Errorf("Error setting granted_permissions: %s", err)
+	}
+	if err := d.Set("denied_permissions", deniedPermissions); err != nil {
+		return fmt.Errorf("Error setting denied_permissions: %s", err)
+	}
+	if err := d.Set("all_granted", len(deniedPermissions) == 0); err != nil {
+		return fmt.Errorf("Error setting all_granted: %s", err)
+	}
+	return nil
+}
+
+// newIamPermissionsTester builds the updater of a resource from the ID its IAM
+// resources import, e.g. "projects/my-project/topics/my-topic".
+func newIamPermissionsTester(config *Config, resourceType, resourceId string) (ResourceIamPermissionsTester, ResourceIamUpdater, error) {
+	r, ok := iamPermissionsTestableResources[resourceType]
+	if !ok {
+		return nil, nil, fmt.Errorf("Testing IAM permissions of %s resources isn't supported", resourceType)
+	}
+
+	rd := (&schema.Resource{Schema: r.schema}).Data(nil)
+	rd.SetId(resourceId)
+	if err := r.idParser(rd, config); err != nil {
+		return nil, nil, fmt.Errorf("Error parsing %s ID %q: %s", resourceType, resourceId, err)
+	}
+	updater, err := r.newUpdater(rd, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tester, ok := updater.(ResourceIamPermissionsTester)
+	if !ok {
+		return nil, nil, fmt.Errorf("Testing IAM permissions of %s isn't supported", updater.DescribeResource())
+	}
+	return tester, updater, nil
+}
+
+// splitTestedIamPermissions splits permissions into those in granted and the
+// rest, keeping their order.
+func splitTestedIamPermissions(permissions, granted []string) ([]string, []string) {
+	grantedSet := make(map[string]struct{}, len(granted))
+	for _, p := range granted {
+		grantedSet[p] = struct{}{}
+	}
+
+	grantedPermissions, deniedPermissions := make([]string, 0), make([]string, 0)
+	for _, p := range permissions {
+		if _, ok := grantedSet[p]; ok {
+			grantedPermissions = append(grantedPermissions, p)
+		} else {
+			deniedPermissions = append(deniedPermissions, p)
+		}
+	}
+	return grantedPermissions, deniedPermissions
+}
@@ -0,0 +1,156 @@
+package google
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestTestIamPermissionsInChunks(t *testing.T) {
+	permissions := make([]string, 250)
+	for i := range permissions {
+		permissions[i] = fmt.Sprintf("service.resource.permission%d", i)
+	}
+
+	var chunks []int
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		chunks = append(chunks, len(chunk))
+		// Grant the first permission of every chunk.
+		return chunk[:1], nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := []int{100, 100, 50}; !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected chunks of %v permissions, got %v", expected, chunks)
+	}
+	if expected := []string{permissions[0], permissions[100], permissions[200]}; !reflect.DeepEqual(granted, expected) {
+		t.Errorf("expected granted permissions %v, got %v", expected, granted)
+	}
+}
+
+func TestFlattenTestIamPermissionsResponse(t *testing.T) {
+	granted, err := flattenTestIamPermissionsResponse(map[string]interface{}{
+		"permissions": []interface{}{"pubsub.topics.publish"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := []string{"pubsub.topics.publish"}; !reflect.DeepEqual(granted, expected) {
+		t.Errorf("expected %v, got %v", expected, granted)
+	}
+
+	// The field is omitted when no permissions are granted.
+	granted, err = flattenTestIamPermissionsResponse(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(granted) != 0 {
+		t.Errorf("expected no permissions, got %v", granted)
+	}
+}
+
+func TestSplitTestedIamPermissions(t *testing.T) {
+	granted, denied := splitTestedIamPermissions(
+		[]string{"storage.objects.create", "storage.objects.delete", "storage.objects.get"},
+		[]string{"storage.objects.get", "storage.objects.create"},
+	)
+	if expected := []string{"storage.objects.create", "storage.objects.get"}; !reflect.DeepEqual(granted, expected) {
+		t.Errorf("expected granted %v, got %v", expected, granted)
+	}
+	if expected := []string{"storage.objects.delete"}; !reflect.DeepEqual(denied, expected) {
+		t.Errorf("expected denied %v, got %v", expected, denied)
+	}
+}
+
+func TestIamPermissionsTestableResources(t *testing.T) {
+	// Values for fields that hold a whole resource ID.
+	ids := map[string]string{
+		"key_ring_id":     "test-project/us-central1/test-key-ring",
+		"crypto_key_id":   "test-project/us-central1/test-key-ring/test-key",
+		"dataset_id":      "test-project/us-central1/test-dataset",
+		"dicom_store_id":  "test-project/us-central1/test-dataset/test-store",
+		"fhir_store_id":   "test-project/us-central1/test-dataset/test-store",
+		"hl7_v2_store_id": "test-project/us-central1/test-dataset/test-store",
+		"ca_pool":         "test-project/us-central1/test-ca-pool",
+	}
+
+	resources := ResourceMap()
+	for resourceType, r := range iamPermissionsTestableResources {
+		if _, ok := resources[resourceType+"_iam_member"]; !ok {
+			t.Errorf("%s: no %s_iam_member resource", resourceType, resourceType)
+		}
+
+		d := (&schema.Resource{Schema: r.schema}).Data(nil)
+		for k, s := range r.schema {
+			if s.Type != schema.TypeString {
+				continue
+			}
+			v, ok := ids[k]
+			if !ok {
+				v = "test-" + k
+			}
+			if err := d.Set(k, v); err != nil {
+				t.Fatalf("%s: error setting %s: %s", resourceType, k, err)
+			}
+		}
+		updater, err := r.newUpdater(d, &Config{Project: "test-project", Region: "us-central1", Zone: "us-central1-a"})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", resourceType, err)
+			continue
+		}
+		if _, ok := updater.(ResourceIamPermissionsTester); !ok {
+			t.Errorf("%s: updater %T doesn't implement ResourceIamPermissionsTester", resourceType, updater)
+		}
+	}
+}
+
+func TestNewIamPermissionsTester(t *testing.T) {
+	config := &Config{Project: "default-project"}
+
+	_, updater, err := newIamPermissionsTester(config, "google_pubsub_topic", "projects/my-project/topics/my-topic")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := "projects/my-project/topics/my-topic"; updater.GetResourceId() != expected {
+		t.Errorf("expected resource id %q, got %q", expected, updater.GetResourceId())
+	}
+
+	if _, _, err := newIamPermissionsTester(config, "google_compute_network", "my-network"); err == nil {
+		t.Errorf("expected error for unsupported resource type")
+	}
+}
+
+func TestAccDataSourceGoogleIamTestPermissions_project(t *testing.T) {
+	t.Parallel()
+
+	project := getTestProjectFromEnv()
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGoogleIamTestPermissions_project(project),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.google_iam_test_permissions.project", "granted_permissions.0", "resourcemanager.projects.get"),
+					resource.TestCheckResourceAttr("data.google_iam_test_permissions.project", "denied_permissions.#", "0"),
+					resource.TestCheckResourceAttr("data.google_iam_test_permissions.project", "all_granted", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGoogleIamTestPermissions_project(project string) string {
+	return fmt.Sprintf(`
+data "google_iam_test_permissions" "project" {
+  resource_type = "google_project"
+  resource_id   = "%s"
+  permissions   = ["resourcemanager.projects.get"]
+}
+`, project)
+}
@@ -0,0 +1,32 @@
+package google
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceGoogleStorageBucket() *schema.Resource {
+	dsSchema := datasourceSchemaFromResourceSchema(resourceStorageBucket().Schema)
+
+	addRequiredFieldsToSchema(dsSchema, "name")
+
+	return &schema.Resource{
+		Read:   dataSourceGoogleStorageBucketRead,
+		Schema: dsSchema,
+	}
+}
+
+func dataSourceGoogleStorageBucketRead(d *schema.ResourceData, meta interface{}) error {
+	bucket := d.Get("name").(string)
+	d.SetId(bucket)
+
+	if err := resourceStorageBucketRead(d, meta); err != nil {
+		return err
+	}
+	// The bucket's read clears the ID if it doesn't exist.
+	if d.Id() == "" {
+		return fmt.Errorf("Storage Bucket %q not found", bucket)
+	}
+	return nil
+}
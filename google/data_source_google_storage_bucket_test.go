@@ -0,0 +1,70 @@
+package google
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFakeGoogleApi_storageBucketDataSources(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	for _, name := range []string{"logs-a", "logs-b", "assets"} {
+		testFakeApiApply(t, config, "google_storage_bucket", nil, map[string]interface{}{
+			"name":          name,
+			"location":      "EU",
+			"storage_class": "NEARLINE",
+			"labels":        map[string]interface{}{"team": strings.TrimSuffix(name, "-b")},
+			"retention_policy": []interface{}{
+				map[string]interface{}{"retention_period": 3600},
+			},
+		})
+	}
+
+	d, err := testFakeApiReadData(t, config, "google_storage_bucket", map[string]interface{}{"name": "logs-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"location":                            "EU",
+		"storage_class":                       "NEARLINE",
+		"self_link":                           "https://www.googleapis.com/storage/v1/b/logs-a",
+		"url":                                 "gs://logs-a",
+		"project":                             fakeGoogleApiProject,
+		"labels.team":                         "logs-a",
+		"retention_policy.0.retention_period": 3600,
+	}
+	for k, v := range expected {
+		if got := d.Get(k); got != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, got)
+		}
+	}
+
+	if _, err := testFakeApiReadData(t, config, "google_storage_bucket", map[string]interface{}{"name": "missing"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected reading a missing bucket to fail, got %v", err)
+	}
+
+	cases := []struct {
+		Raw      map[string]interface{}
+		Expected []string
+	}{
+		{map[string]interface{}{}, []string{"assets", "logs-a", "logs-b"}},
+		{map[string]interface{}{"prefix": "logs-"}, []string{"logs-a", "logs-b"}},
+		{map[string]interface{}{"labels": map[string]interface{}{"team": "logs"}}, []string{"logs-b"}},
+		{map[string]interface{}{"prefix": "logs-", "labels": map[string]interface{}{"team": "assets"}}, nil},
+	}
+	for _, tc := range cases {
+		d, err := testFakeApiReadData(t, config, "google_storage_buckets", tc.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, b := range d.Get("buckets").([]interface{}) {
+			names = append(names, b.(map[string]interface{})["name"].(string))
+		}
+		if strings.Join(names, ",") != strings.Join(tc.Expected, ",") {
+			t.Errorf("%v: expected buckets %v, got %v", tc.Raw, tc.Expected, names)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package google
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/api/storage/v1"
+)
+
+func dataSourceGoogleStorageBuckets() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGoogleStorageBucketsRead,
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: `The ID of the project whose buckets are listed. If it is not provided, the provider project is used.`,
+			},
+			"prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: `Only list the buckets whose names start with this prefix.`,
+			},
+			"labels": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: `Only list the buckets that have all of these labels, with the same values.`,
+			},
+			"buckets": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"self_link": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"location": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"storage_class": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"labels": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGoogleStorageBucketsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return err
+	}
+	prefix := d.Get("prefix").(string)
+	labels := convertStringMap(d.Get("labels").(map[string]interface{}))
+
+	// The API filters buckets by prefix, but not by labels.
+	buckets := make([]map[string]interface{}, 0)
+	call := config.NewStorageClient(userAgent).Buckets.List(project).Prefix(prefix)
+	err = call.Pages(config.context, func(res *storage.Buckets) error {
+		for _, b := range res.Items {
+			if !storageBucketHasLabels(b, labels) {
+				continue
+			}
+			buckets = append(buckets, map[string]interface{}{
+				"name":          b.Name,
+				"self_link":     b.SelfLink,
+				"url":           fmt.Sprintf("gs://%s", b.Name),
+				"location":      b.Location,
+				"storage_class": b.StorageClass,
+				"labels":        b.Labels,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Error listing buckets of project %s: %s", project, err)
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error setting project: %s", err)
+	}
+	if err := d.Set("buckets", buckets); err != nil {
+		return fmt.Errorf("Error setting buckets: %s", err)
+	}
+	d.SetId(fmt.Sprintf("projects/%s/buckets/%s", project, prefix))
+
+	return nil
+}
+
+// storageBucketHasLabels returns whether bucket has all of labels.
+func storageBucketHasLabels(bucket *storage.Bucket, labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := bucket.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
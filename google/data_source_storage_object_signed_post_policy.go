@@ -0,0 +1,296 @@
+package google
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceGoogleSignedPostPolicy() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGoogleSignedPostPolicyRead,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"path": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"credentials": {
+				Type:      schema.TypeString,
+				Sensitive: true,
+				Optional:  true,
+			},
+			"service_account_email": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"duration": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "1h",
+			},
+			"fields": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"starts_with": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"prefix": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"content_length_range": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"min": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"max": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+					},
+				},
+			},
+			"url_style": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      signedUrlPathStyle,
+				ValidateFunc: validation.StringInSlice(signedUrlStyles, false),
+			},
+			"bucket_bound_hostname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"form_fields": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceGoogleSignedPostPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(d.Get("duration").(string))
+	if err != nil {
+		return errwrap.Wrapf("could not parse duration: {{err}}", err)
+	}
+	if duration > signedUrlV4MaxExpires {
+		return fmt.Errorf("duration must be at most %s, got %s", signedUrlV4MaxExpires, duration)
+	}
+
+	signer, err := loadSignedUrlSigner(d, config, userAgent)
+	if err != nil {
+		return err
+	}
+
+	policy := &PostPolicyV4{
+		Signer:              signer,
+		Bucket:              d.Get("bucket").(string),
+		Object:              d.Get("path").(string),
+		Time:                time.Now(),
+		Expires:             duration,
+		Fields:              convertStringMap(d.Get("fields").(map[string]interface{})),
+		UrlStyle:            d.Get("url_style").(string),
+		BucketBoundHostname: d.Get("bucket_bound_hostname").(string),
+	}
+	for _, raw := range d.Get("starts_with").([]interface{}) {
+		v := raw.(map[string]interface{})
+		policy.StartsWith = append(policy.StartsWith, [2]string{v["field"].(string), v["prefix"].(string)})
+	}
+	if v, ok := d.GetOk("content_length_range"); ok {
+		r := v.([]interface{})[0].(map[string]interface{})
+		if r["min"].(int) > r["max"].(int) {
+			return fmt.Errorf("content_length_range.0.min must not be greater than content_length_range.0.max")
+		}
+		policy.ContentLengthRange = &[2]int{r["min"].(int), r["max"].(int)}
+	}
+
+	postUrl, fields, err := policy.Sign()
+	if err != nil {
+		return err
+	}
+	if err := d.Set("url", postUrl); err != nil {
+		return fmt.Errorf("Error setting url: %s", err)
+	}
+	if err := d.Set("form_fields", fields); err != nil {
+		return fmt.Errorf("Error setting form_fields: %s", err)
+	}
+	d.SetId(fields["x-goog-signature"])
+
+	return nil
+}
+
+// PostPolicyV4 stores the values required to create a signed V4 POST policy
+// document, which lets browsers upload an object with an HTML form:
+// see https://cloud.google.com/storage/docs/xml-api/post-object-forms
+type PostPolicyV4 struct {
+	Signer *signedUrlSigner
+	Bucket string
+	Object string
+	// Time is when the policy is signed, and Expires how long it's valid for.
+	Time    time.Time
+	Expires time.Duration
+	// Fields are added to the form, and the form must send them with the
+	// same values.
+	Fields map[string]string
+	// StartsWith requires form fields to start with a prefix, as pairs of
+	// field name and prefix.
+	StartsWith [][2]string
+	// ContentLengthRange, if set, is the minimum and maximum size in bytes of
+	// the uploaded object.
+	ContentLengthRange *[2]int
+	// Scheme defaults to https, and UrlStyle to PATH_STYLE.
+	Scheme              string
+	UrlStyle            string
+	BucketBoundHostname string
+}
+
+func (p *PostPolicyV4) credential() string {
+	return fmt.Sprintf("%s/%s/auto/storage/goog4_request", p.Signer.Email, p.Time.UTC().Format("20060102"))
+}
+
+// Document creates the JSON policy document. Conditions are listed in the
+// order of the V4 conformance tests: range conditions, then the form fields
+// sorted by name, then the fields every policy has.
+func (p *PostPolicyV4) Document() ([]byte, error) {
+	var conditions []interface{}
+	for _, sw := range p.StartsWith {
+		field := sw[0]
+		if !strings.HasPrefix(field, "$") {
+			field = "$" + field
+		}
+		conditions = append(conditions, []interface{}{"starts-with", field, sw[1]})
+	}
+	if p.ContentLengthRange != nil {
+		conditions = append(conditions, []interface{}{"content-length-range", p.ContentLengthRange[0], p.ContentLengthRange[1]})
+	}
+
+	var names []string
+	for k := range p.Fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		conditions = append(conditions, map[string]string{k: p.Fields[k]})
+	}
+
+	conditions = append(conditions,
+		map[string]string{"bucket": p.Bucket},
+		map[string]string{"key": p.Object},
+		map[string]string{"x-goog-date": p.Time.UTC().Format("20060102T150405Z")},
+		map[string]string{"x-goog-credential": p.credential()},
+		map[string]string{"x-goog-algorithm": signedUrlV4Algorithm},
+	)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(map[string]interface{}{
+		"conditions": conditions,
+		"expiration": p.Time.Add(p.Expires).UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error encoding POST policy: {{err}}", err)
+	}
+	return escapePolicyNonAscii(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// escapePolicyNonAscii escapes the non-ASCII characters of a JSON document,
+// as policy documents must be ASCII.
+func escapePolicyNonAscii(b []byte) []byte {
+	var buf bytes.Buffer
+	for _, r := range string(b) {
+		if r < 0x80 {
+			buf.WriteRune(r)
+			continue
+		}
+		if r > 0xffff {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&buf, "\\u%04x", r)
+	}
+	return buf.Bytes()
+}
+
+// Sign signs the policy document, and returns the URL to post the form to
+// along with the fields the form must include.
+func (p *PostPolicyV4) Sign() (string, map[string]string, error) {
+	document, err := p.Document()
+	if err != nil {
+		return "", nil, err
+	}
+	policy := base64.StdEncoding.EncodeToString(document)
+
+	signature, err := p.Signer.SignBytes([]byte(policy))
+	if err != nil {
+		return "", nil, err
+	}
+
+	fields := map[string]string{
+		"key":               p.Object,
+		"x-goog-algorithm":  signedUrlV4Algorithm,
+		"x-goog-credential": p.credential(),
+		"x-goog-date":       p.Time.UTC().Format("20060102T150405Z"),
+		"x-goog-signature":  hex.EncodeToString(signature),
+		"policy":            policy,
+	}
+	for k, v := range p.Fields {
+		fields[k] = v
+	}
+
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host, path := signedUrlHostAndPath(p.UrlStyle, p.BucketBoundHostname, p.Bucket, "")
+	postUrl := fmt.Sprintf("%s://%s/", scheme, host)
+	if path != "" {
+		postUrl += signedUrlV4EscapePath(path) + "/"
+	}
+	return postUrl, fields, nil
+}
@@ -0,0 +1,61 @@
+package google
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPostPolicyV4_conformance(t *testing.T) {
+	tests, signer := loadSignedUrlV4ConformanceTests(t)
+
+	for _, tc := range tests.PostPolicyV4Tests {
+		in := tc.PolicyInput
+		policy := &PostPolicyV4{
+			Signer:              signer,
+			Bucket:              in.Bucket,
+			Object:              in.Object,
+			Time:                in.Timestamp,
+			Expires:             time.Duration(in.Expiration) * time.Second,
+			Fields:              in.Fields,
+			Scheme:              in.Scheme,
+			UrlStyle:            in.UrlStyle,
+			BucketBoundHostname: in.BucketBoundHostname,
+		}
+		if sw := in.Conditions.StartsWith; len(sw) == 2 {
+			policy.StartsWith = [][2]string{{sw[0], sw[1]}}
+		}
+		if r := in.Conditions.ContentLengthRange; len(r) == 2 {
+			policy.ContentLengthRange = &[2]int{r[0], r[1]}
+		}
+
+		document, err := policy.Document()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		// The expected policy is decoded from JSON, so it has the characters
+		// the document escapes, and the documents are compared as JSON.
+		var got, expected interface{}
+		if err := json.Unmarshal(document, &got); err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		if err := json.Unmarshal([]byte(tc.PolicyOutput.ExpectedDecodedPolicy), &expected); err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: policy documents do not match:\n%s\n%s", tc.Description, tc.PolicyOutput.ExpectedDecodedPolicy, document)
+		}
+
+		postUrl, fields, err := policy.Sign()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		if postUrl != tc.PolicyOutput.Url {
+			t.Errorf("%s: URL does not match expected value:\n%s\n%s", tc.Description, tc.PolicyOutput.Url, postUrl)
+		}
+		if !reflect.DeepEqual(fields, tc.PolicyOutput.Fields) {
+			t.Errorf("%s: fields do not match:\n%v\n%v", tc.Description, tc.PolicyOutput.Fields, fields)
+		}
+	}
+}
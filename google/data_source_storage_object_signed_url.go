@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -23,8 +24,11 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
 )
 
 const gcsBaseUrl = "https://storage.googleapis.com"
@@ -54,6 +58,31 @@ func dataSourceGoogleSignedUrl() *schema.Resource {
 				Sensitive: true,
 				Optional:  true,
 			},
+			"service_account_email": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"signing_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "v2",
+				ValidateFunc: validation.StringInSlice([]string{"v2", "v4"}, false),
+			},
+			"query_parameters": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"url_style": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      signedUrlPathStyle,
+				ValidateFunc: validation.StringInSlice(signedUrlStyles, false),
+			},
+			"bucket_bound_hostname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"duration": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -69,7 +98,7 @@ func dataSourceGoogleSignedUrl() *schema.Resource {
 				Type:         schema.TypeString,
 				Optional:     true,
 				Default:      "GET",
-				ValidateFunc: validation.StringInSlice([]string{"GET", "HEAD", "PUT", "DELETE"}, true),
+				ValidateFunc: validation.StringInSlice([]string{"GET", "HEAD", "PUT", "DELETE", "POST"}, true),
 			},
 			"path": {
 				Type:     schema.TypeString,
@@ -96,13 +125,9 @@ func validateExtensionHeaders(v interface{}, k string) (ws []string, errors []er
 
 func dataSourceGoogleSignedUrlRead(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(*Config)
-
-	// Build UrlData object from data source attributes
-	urlData := &UrlData{}
-
-	// HTTP Method
-	if method, ok := d.GetOk("http_method"); ok {
-		urlData.HttpMethod = method.(string)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
 	}
 
 	// convert duration to an expiration datetime (unix time in seconds)
@@ -114,6 +139,33 @@ func dataSourceGoogleSignedUrlRead(d *schema.ResourceData, meta interface{}) err
 	if err != nil {
 		return errwrap.Wrapf("could not parse duration: {{err}}", err)
 	}
+
+	signer, err := loadSignedUrlSigner(d, config, userAgent)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("signing_version").(string) == "v4" {
+		return dataSourceGoogleSignedUrlReadV4(d, signer, duration)
+	}
+
+	if len(d.Get("query_parameters").(map[string]interface{})) > 0 {
+		return errors.New("query_parameters are only supported with signing_version v4")
+	}
+	if d.Get("url_style").(string) != signedUrlPathStyle {
+		return errors.New("url_style is only supported with signing_version v4")
+	}
+
+	// Build UrlData object from data source attributes
+	urlData := &UrlData{
+		Signer: signer,
+	}
+
+	// HTTP Method
+	if method, ok := d.GetOk("http_method"); ok {
+		urlData.HttpMethod = method.(string)
+	}
+
 	expires := time.Now().Unix() + int64(duration.Seconds())
 	urlData.Expires = int(expires)
 
@@ -141,29 +193,52 @@ func dataSourceGoogleSignedUrlRead(d *schema.ResourceData, meta interface{}) err
 
 	urlData.Path = fmt.Sprintf("/%s/%s", d.Get("bucket").(string), d.Get("path").(string))
 
-	// Load JWT Config from Google Credentials
-	jwtConfig, err := loadJwtConfig(d, config)
+	encodedSig, err := urlData.EncodedSignature()
 	if err != nil {
 		return err
 	}
-	urlData.JwtConfig = jwtConfig
 
 	// Construct URL
-	signedUrl, err := urlData.SignedUrl()
-	if err != nil {
-		return err
+	if err := d.Set("signed_url", urlData.signedUrlWithSignature(encodedSig)); err != nil {
+		return fmt.Errorf("Error setting signed_url: %s", err)
 	}
+	d.SetId(encodedSig)
 
-	// Success
-	if err := d.Set("signed_url", signedUrl); err != nil {
-		return fmt.Errorf("Error setting signed_url: %s", err)
+	return nil
+}
+
+func dataSourceGoogleSignedUrlReadV4(d *schema.ResourceData, signer *signedUrlSigner, duration time.Duration) error {
+	if duration > signedUrlV4MaxExpires {
+		return fmt.Errorf("duration must be at most %s for signing_version v4, got %s", signedUrlV4MaxExpires, duration)
 	}
 
-	encodedSig, err := urlData.EncodedSignature()
+	urlData := &UrlDataV4{
+		Signer:              signer,
+		HttpMethod:          strings.ToUpper(d.Get("http_method").(string)),
+		Bucket:              d.Get("bucket").(string),
+		Object:              d.Get("path").(string),
+		Time:                time.Now(),
+		Expires:             duration,
+		HttpHeaders:         convertStringMap(d.Get("extension_headers").(map[string]interface{})),
+		QueryParameters:     convertStringMap(d.Get("query_parameters").(map[string]interface{})),
+		UrlStyle:            d.Get("url_style").(string),
+		BucketBoundHostname: d.Get("bucket_bound_hostname").(string),
+	}
+	if v, ok := d.GetOk("content_md5"); ok {
+		urlData.HttpHeaders["content-md5"] = v.(string)
+	}
+	if v, ok := d.GetOk("content_type"); ok {
+		urlData.HttpHeaders["content-type"] = v.(string)
+	}
+
+	signedUrl, signature, err := urlData.SignedUrl()
 	if err != nil {
 		return err
 	}
-	d.SetId(encodedSig)
+	if err := d.Set("signed_url", signedUrl); err != nil {
+		return fmt.Errorf("Error setting signed_url: %s", err)
+	}
+	d.SetId(signature)
 
 	return nil
 }
@@ -235,7 +310,9 @@ func parsePrivateKey(key []byte) (*rsa.PrivateKey, error) {
 
 // UrlData stores the values required to create a Signed Url
 type UrlData struct {
-	JwtConfig   *jwt.Config
+	JwtConfig *jwt.Config
+	// Signer, if set, signs the url instead of the private key of JwtConfig.
+	Signer      *signedUrlSigner
 	ContentMd5  string
 	ContentType string
 	HttpMethod  string
@@ -292,6 +369,10 @@ func (u *UrlData) SigningString() []byte {
 }
 
 func (u *UrlData) Signature() ([]byte, error) {
+	if u.Signer != nil {
+		return u.Signer.SignBytes(u.SigningString())
+	}
+
 	// Sign url data
 	signature, err := SignString(u.SigningString(), u.JwtConfig)
 	if err != nil {
@@ -325,19 +406,30 @@ func (u *UrlData) SignedUrl() (string, error) {
 		return "", err
 	}
 
+	return u.signedUrlWithSignature(encodedSig), nil
+}
+
+func (u *UrlData) signedUrlWithSignature(encodedSig string) string {
+	googleAccessId := ""
+	if u.Signer != nil {
+		googleAccessId = u.Signer.Email
+	} else {
+		googleAccessId = u.JwtConfig.Email
+	}
+
 	// build url
 	// https://cloud.google.com/storage/docs/access-control/create-signed-urls-program
 	var urlBuffer bytes.Buffer
 	urlBuffer.WriteString(gcsBaseUrl)
 	urlBuffer.WriteString(u.Path)
 	urlBuffer.WriteString("?GoogleAccessId=")
-	urlBuffer.WriteString(u.JwtConfig.Email)
+	urlBuffer.WriteString(googleAccessId)
 	urlBuffer.WriteString("&Expires=")
 	urlBuffer.WriteString(strconv.Itoa(u.Expires))
 	urlBuffer.WriteString("&Signature=")
 	urlBuffer.WriteString(encodedSig)
 
-	return urlBuffer.String(), nil
+	return urlBuffer.String()
 }
 
 // SignString calculates the SHA256 signature of the input string
@@ -362,3 +454,268 @@ func SignString(toSign []byte, cfg *jwt.Config) ([]byte, error) {
 
 	return signed, nil
 }
+
+// signedUrlSigner signs the strings of signed URLs and POST policies as a
+// service account.
+type signedUrlSigner struct {
+	Email string
+	// SignBytes returns the RSA SHA256 signature of b.
+	SignBytes func(b []byte) ([]byte, error)
+}
+
+func jwtConfigSigner(cfg *jwt.Config) *signedUrlSigner {
+	return &signedUrlSigner{
+		Email: cfg.Email,
+		SignBytes: func(b []byte) ([]byte, error) {
+			return SignString(b, cfg)
+		},
+	}
+}
+
+// signBlobSigner signs through the IAM Credentials signBlob API, so that no
+// private key is needed. The caller needs the iam.serviceAccounts.signBlob
+// permission on the service account, or on each of the delegates in turn.
+func signBlobSigner(service *iamcredentials.Service, email string, delegates []string) *signedUrlSigner {
+	return &signedUrlSigner{
+		Email: email,
+		SignBytes: func(b []byte) ([]byte, error) {
+			req := &iamcredentials.SignBlobRequest{
+				Payload: base64.StdEncoding.EncodeToString(b),
+			}
+			for _, delegate := range delegates {
+				if !strings.HasPrefix(delegate, "projects/") {
+					delegate = fmt.Sprintf("projects/-/serviceAccounts/%s", delegate)
+				}
+				req.Delegates = append(req.Delegates, delegate)
+			}
+
+			name := fmt.Sprintf("projects/-/serviceAccounts/%s", email)
+			resp, err := service.Projects.ServiceAccounts.SignBlob(name, req).Do()
+			if err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("Error signing as %s: {{err}}", email), err)
+			}
+			return base64.StdEncoding.DecodeString(resp.SignedBlob)
+		},
+	}
+}
+
+// loadSignedUrlSigner picks the identity to sign as, in order of preference:
+//   1. `service_account_email` attribute of the datasource, signing through
+//      the IAM Credentials API with the provider's credentials.
+//   2. `credentials` attribute of the datasource.
+//   3. The service account the provider impersonates, signing through the
+//      IAM Credentials API with the provider's original credentials.
+//   4. A service account key found by loadJwtConfig.
+//   5. The identity of the provider's credentials, signing through the IAM
+//      Credentials API, e.g. the service account of a VM.
+func loadSignedUrlSigner(d *schema.ResourceData, config *Config, userAgent string) (*signedUrlSigner, error) {
+	if v, ok := d.GetOk("service_account_email"); ok {
+		log.Printf("[DEBUG] using signBlob as %s to sign URL", v.(string))
+		return signBlobSigner(config.NewIamCredentialsClient(userAgent), v.(string), nil), nil
+	}
+
+	if _, ok := d.GetOk("credentials"); !ok && config.ImpersonateServiceAccount != "" {
+		log.Printf("[DEBUG] using signBlob as impersonated service account %s to sign URL", config.ImpersonateServiceAccount)
+		tokenSource, err := config.getTokenSource(config.Scopes, true)
+		if err != nil {
+			return nil, err
+		}
+		service, err := iamcredentials.NewService(config.context, option.WithHTTPClient(oauth2.NewClient(config.context, tokenSource)))
+		if err != nil {
+			return nil, errwrap.Wrapf("Error creating IAM Credentials client: {{err}}", err)
+		}
+		service.UserAgent = userAgent
+		service.BasePath = removeBasePathVersion(config.IamCredentialsBasePath)
+		return signBlobSigner(service, config.ImpersonateServiceAccount, config.ImpersonateServiceAccountDelegates), nil
+	}
+
+	jwtConfig, err := loadJwtConfig(d, config)
+	if err == nil {
+		return jwtConfigSigner(jwtConfig), nil
+	}
+	if _, ok := d.GetOk("credentials"); ok {
+		return nil, err
+	}
+
+	email, emailErr := GetCurrentUserEmail(config, userAgent)
+	if emailErr != nil {
+		return nil, fmt.Errorf("%s Set service_account_email to sign as a service account without a key. Finding the identity of the provider credentials failed: %s", err, emailErr)
+	}
+	log.Printf("[DEBUG] no service account key found, using signBlob as %s to sign URL", email)
+	return signBlobSigner(config.NewIamCredentialsClient(userAgent), email, nil), nil
+}
+
+const signedUrlV4Algorithm = "GOOG4-RSA-SHA256"
+
+// signedUrlV4MaxExpires is the longest a V4 signed URL or POST policy can be
+// valid for.
+const signedUrlV4MaxExpires = 7 * 24 * time.Hour
+
+const (
+	signedUrlPathStyle           = "PATH_STYLE"
+	signedUrlVirtualHostedStyle  = "VIRTUAL_HOSTED_STYLE"
+	signedUrlBucketBoundHostname = "BUCKET_BOUND_HOSTNAME"
+)
+
+var signedUrlStyles = []string{signedUrlPathStyle, signedUrlVirtualHostedStyle, signedUrlBucketBoundHostname}
+
+// signedUrlHostAndPath returns the host and unescaped path addressing the
+// object in the given URL style. The object may be empty to address the
+// bucket.
+func signedUrlHostAndPath(style, bucketBoundHostname, bucket, object string) (string, string) {
+	switch style {
+	case signedUrlVirtualHostedStyle:
+		return bucket + ".storage.googleapis.com", object
+	case signedUrlBucketBoundHostname:
+		return bucketBoundHostname, object
+	}
+	if object == "" {
+		return "storage.googleapis.com", bucket
+	}
+	return "storage.googleapis.com", bucket + "/" + object
+}
+
+// signedUrlV4EscapePath escapes each segment of a path, keeping the slashes
+// between them.
+func signedUrlV4EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+	}
+	return strings.Join(segments, "/")
+}
+
+// UrlDataV4 stores the values required to create a V4 Signed Url:
+// see https://cloud.google.com/storage/docs/access-control/signing-urls-manually
+type UrlDataV4 struct {
+	Signer     *signedUrlSigner
+	HttpMethod string
+	Bucket     string
+	// Object may be empty, for a URL addressing the bucket.
+	Object string
+	// Time is when the URL is signed, and Expires how long it's valid for.
+	Time    time.Time
+	Expires time.Duration
+	// HttpHeaders are signed, so requests using the URL must send them with
+	// the same values. The host header is always signed.
+	HttpHeaders     map[string]string
+	QueryParameters map[string]string
+	// Scheme defaults to https, and UrlStyle to PATH_STYLE.
+	Scheme              string
+	UrlStyle            string
+	BucketBoundHostname string
+}
+
+func (u *UrlDataV4) hostAndPath() (string, string) {
+	return signedUrlHostAndPath(u.UrlStyle, u.BucketBoundHostname, u.Bucket, u.Object)
+}
+
+func (u *UrlDataV4) credentialScope() string {
+	return fmt.Sprintf("%s/auto/storage/goog4_request", u.Time.UTC().Format("20060102"))
+}
+
+// canonicalHeaders returns the headers to sign, by lowercase name, with the
+// whitespace in their values collapsed.
+func (u *UrlDataV4) canonicalHeaders() map[string]string {
+	host, _ := u.hostAndPath()
+	headers := map[string]string{"host": host}
+	for k, v := range u.HttpHeaders {
+		headers[strings.ToLower(strings.TrimSpace(k))] = strings.Join(strings.Fields(v), " ")
+	}
+	return headers
+}
+
+func (u *UrlDataV4) signedHeaders() []string {
+	var names []string
+	for k := range u.canonicalHeaders() {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonicalQuery returns the query string of the URL, without its signature.
+func (u *UrlDataV4) canonicalQuery() string {
+	query := url.Values{
+		"X-Goog-Algorithm":     {signedUrlV4Algorithm},
+		"X-Goog-Credential":    {fmt.Sprintf("%s/%s", u.Signer.Email, u.credentialScope())},
+		"X-Goog-Date":          {u.Time.UTC().Format("20060102T150405Z")},
+		"X-Goog-Expires":       {strconv.Itoa(int(u.Expires.Seconds()))},
+		"X-Goog-SignedHeaders": {strings.Join(u.signedHeaders(), ";")},
+	}
+	for k, v := range u.QueryParameters {
+		query.Add(k, v)
+	}
+	// Encode sorts parameters by name, but escapes spaces as "+".
+	return strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+// CanonicalRequest creates the canonical form of the request the URL is for.
+// Example output:
+// -------------------
+// GET
+// /bucket/objectname
+// X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Credential=...&X-Goog-SignedHeaders=host
+// host:storage.googleapis.com
+//
+// host
+// UNSIGNED-PAYLOAD
+// -------------------
+func (u *UrlDataV4) CanonicalRequest() string {
+	var buf bytes.Buffer
+
+	_, path := u.hostAndPath()
+	buf.WriteString(u.HttpMethod)
+	buf.WriteString("\n/")
+	buf.WriteString(signedUrlV4EscapePath(path))
+	buf.WriteString("\n")
+	buf.WriteString(u.canonicalQuery())
+	buf.WriteString("\n")
+
+	headers := u.canonicalHeaders()
+	names := u.signedHeaders()
+	for _, k := range names {
+		buf.WriteString(fmt.Sprintf("%s:%s\n", k, headers[k]))
+	}
+	buf.WriteString("\n")
+	buf.WriteString(strings.Join(names, ";"))
+	buf.WriteString("\n")
+
+	// The payload is only signed if the client sends its hash.
+	if v, ok := headers["x-goog-content-sha256"]; ok {
+		buf.WriteString(v)
+	} else {
+		buf.WriteString("UNSIGNED-PAYLOAD")
+	}
+
+	return buf.String()
+}
+
+// StringToSign creates the string the signature of the URL is computed over.
+func (u *UrlDataV4) StringToSign() string {
+	hash := sha256.Sum256([]byte(u.CanonicalRequest()))
+	return strings.Join([]string{
+		signedUrlV4Algorithm,
+		u.Time.UTC().Format("20060102T150405Z"),
+		u.credentialScope(),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+// SignedUrl constructs the final signed URL, and returns it along with its
+// hex encoded signature.
+func (u *UrlDataV4) SignedUrl() (string, string, error) {
+	signature, err := u.Signer.SignBytes([]byte(u.StringToSign()))
+	if err != nil {
+		return "", "", err
+	}
+	encodedSig := hex.EncodeToString(signature)
+
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host, path := u.hostAndPath()
+	signedUrl := fmt.Sprintf("%s://%s/%s?%s&X-Goog-Signature=%s", scheme, host, signedUrlV4EscapePath(path), u.canonicalQuery(), encodedSig)
+	return signedUrl, encodedSig, nil
+}
@@ -1,13 +1,18 @@
 package google
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 
 	"github.com/hashicorp/go-cleanhttp"
@@ -99,6 +104,189 @@ func TestUrlData_SignedUrl(t *testing.T) {
 	}
 }
 
+// The V4 conformance tests are copied from
+// https://github.com/googleapis/conformance-tests/tree/master/storage/v1, and
+// are signed with a dummy service account key.
+const testSignedUrlV4ConformanceFile = "./test-fixtures/storage_signed_url/v4_signatures.json"
+const testSignedUrlV4ServiceAccountFile = "./test-fixtures/storage_signed_url/service_account.json"
+
+type signedUrlV4ConformanceTests struct {
+	SigningV4Tests []struct {
+		Description              string
+		Bucket                   string
+		Object                   string
+		Method                   string
+		Expiration               json.Number
+		Timestamp                time.Time
+		Headers                  map[string]string
+		QueryParameters          map[string]string
+		Scheme                   string
+		UrlStyle                 string
+		BucketBoundHostname      string
+		ExpectedUrl              string
+		ExpectedCanonicalRequest string
+		ExpectedStringToSign     string
+	}
+	PostPolicyV4Tests []struct {
+		Description string
+		PolicyInput struct {
+			Scheme              string
+			UrlStyle            string
+			BucketBoundHostname string
+			Bucket              string
+			Object              string
+			Expiration          int
+			Timestamp           time.Time
+			Conditions          struct {
+				StartsWith         []string
+				ContentLengthRange []int
+			}
+			Fields map[string]string
+		}
+		PolicyOutput struct {
+			Url                   string
+			Fields                map[string]string
+			ExpectedDecodedPolicy string
+		}
+	}
+}
+
+func loadSignedUrlV4ConformanceTests(t *testing.T) (*signedUrlV4ConformanceTests, *signedUrlSigner) {
+	contents, err := ioutil.ReadFile(testSignedUrlV4ConformanceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests signedUrlV4ConformanceTests
+	if err := json.Unmarshal(contents, &tests); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ioutil.ReadFile(testSignedUrlV4ServiceAccountFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := google.JWTConfigFromJSON(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tests, jwtConfigSigner(cfg)
+}
+
+func TestUrlDataV4_conformance(t *testing.T) {
+	tests, signer := loadSignedUrlV4ConformanceTests(t)
+
+	for _, tc := range tests.SigningV4Tests {
+		expires, err := tc.Expiration.Int64()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		urlData := &UrlDataV4{
+			Signer:              signer,
+			HttpMethod:          tc.Method,
+			Bucket:              tc.Bucket,
+			Object:              tc.Object,
+			Time:                tc.Timestamp,
+			Expires:             time.Duration(expires) * time.Second,
+			HttpHeaders:         tc.Headers,
+			QueryParameters:     tc.QueryParameters,
+			Scheme:              tc.Scheme,
+			UrlStyle:            tc.UrlStyle,
+			BucketBoundHostname: tc.BucketBoundHostname,
+		}
+
+		if got := urlData.CanonicalRequest(); got != tc.ExpectedCanonicalRequest {
+			t.Errorf("%s: canonical requests do not match:\n%s\n%s", tc.Description, tc.ExpectedCanonicalRequest, got)
+		}
+		if got := urlData.StringToSign(); got != tc.ExpectedStringToSign {
+			t.Errorf("%s: strings to sign do not match:\n%s\n%s", tc.Description, tc.ExpectedStringToSign, got)
+		}
+		signedUrl, _, err := urlData.SignedUrl()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Description, err)
+		}
+		if signedUrl != tc.ExpectedUrl {
+			t.Errorf("%s: URL does not match expected value:\n%s\n%s", tc.Description, tc.ExpectedUrl, signedUrl)
+		}
+	}
+}
+
+func TestSignBlobSigner(t *testing.T) {
+	key, err := ioutil.ReadFile(testSignedUrlV4ServiceAccountFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := google.JWTConfigFromJSON(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Payload   string
+			Delegates []string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, fmt.Sprintf("%s %s %v", r.Method, r.URL.Path, req.Delegates))
+
+		payload, err := base64.StdEncoding.DecodeString(req.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := SignString(payload, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"keyId": "ffff", "signedBlob": %q}`, base64.StdEncoding.EncodeToString(signature))
+	}))
+	defer server.Close()
+
+	config := &Config{
+		AccessToken:                        "foo",
+		ImpersonateServiceAccount:          cfg.Email,
+		ImpersonateServiceAccountDelegates: []string{"delegate@gcp-project.iam.gserviceaccount.com"},
+		IamCredentialsBasePath:             server.URL + "/v1/",
+	}
+	if err := config.LoadAndValidate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	d := dataSourceGoogleSignedUrl().TestResourceData()
+	signer, err := loadSignedUrlSigner(d, config, config.userAgent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Email != cfg.Email {
+		t.Errorf("expected to sign as the impersonated service account %s, got %s", cfg.Email, signer.Email)
+	}
+
+	tests, _ := loadSignedUrlV4ConformanceTests(t)
+	tc := tests.SigningV4Tests[0]
+	urlData := &UrlDataV4{
+		Signer:     signer,
+		HttpMethod: tc.Method,
+		Bucket:     tc.Bucket,
+		Object:     tc.Object,
+		Time:       tc.Timestamp,
+		Expires:    10 * time.Second,
+	}
+	signedUrl, _, err := urlData.SignedUrl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedUrl != tc.ExpectedUrl {
+		t.Errorf("URL does not match expected value:\n%s\n%s", tc.ExpectedUrl, signedUrl)
+	}
+
+	expected := []string{fmt.Sprintf("POST /v1/projects/-/serviceAccounts/%s:signBlob [projects/-/serviceAccounts/delegate@gcp-project.iam.gserviceaccount.com]", cfg.Email)}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
+
 func TestAccStorageSignedUrl_basic(t *testing.T) {
 	t.Parallel()
 
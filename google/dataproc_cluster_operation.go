@@ -26,5 +26,5 @@ func dataprocClusterOperationWait(config *Config, op *dataproc.Operation, activi
 	if err := w.SetOp(op); err != nil {
 		return err
 	}
-	return OperationWait(w, activity, timeout, config.PollInterval)
+	return OperationWaitWithCtx(contextWithUserAgentSpan(config.context, userAgent), w, activity, timeout, config.PollInterval)
 }
@@ -0,0 +1,110 @@
+package google
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const deletionProtectionKey = "deletion_protection"
+
+// deletionProtectionResources are the resources that opt into the shared
+// deletion_protection field. Resources that define their own
+// deletion_protection field are in ownDeletionProtectionResources instead.
+var deletionProtectionResources = map[string]bool{
+	"google_container_cluster":   true,
+	"google_dns_managed_zone":    true,
+	"google_filestore_instance":  true,
+	"google_pubsub_subscription": true,
+	"google_pubsub_topic":        true,
+	"google_redis_instance":      true,
+	"google_storage_bucket":      true,
+}
+
+// ownDeletionProtectionResources are the resources that define their own
+// deletion_protection field without a default. They keep their own checks,
+// and also can't be deleted while the field isn't set and the provider's
+// default_deletion_protection is enabled. Resources whose own field defaults
+// to true, such as google_sql_database_instance, don't need the default.
+var ownDeletionProtectionResources = map[string]bool{
+	"google_compute_instance": true,
+}
+
+// addDeletionProtectionSupport adds a deletion_protection field to the
+// resources in deletionProtectionResources, and makes their Delete fail while
+// it's enabled.
+//
+// The field has no default, so that it's only stored in state when it's set on
+// the resource. When it isn't, the provider's default_deletion_protection is
+// read at the time the resource is deleted, so turning the default on
+// protects every existing resource without changing its plan.
+func addDeletionProtectionSupport(resources map[string]*schema.Resource) {
+	for name, r := range resources {
+		if ownDeletionProtectionResources[name] && r.Delete != nil {
+			r.Delete = wrapDeleteWithDefaultDeletionProtection(name, r.Delete)
+			continue
+		}
+		if !deletionProtectionResources[name] {
+			continue
+		}
+		if _, ok := r.Schema[deletionProtectionKey]; ok {
+			continue
+		}
+
+		r.Schema[deletionProtectionKey] = &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: `Whether or not to allow Terraform to destroy the resource. Unless this field is set to false in Terraform state, a terraform destroy or terraform apply that would delete the resource will fail. Defaults to the provider's default_deletion_protection.`,
+		}
+
+		if r.Update != nil {
+			r.Update = wrapUpdateWithDeletionProtection(r.Update)
+		}
+		if r.Delete != nil {
+			r.Delete = wrapDeleteWithDeletionProtection(name, r.Delete)
+		}
+	}
+}
+
+// deletionProtectionEnabled reports whether d may not be deleted, using the
+// provider's default if deletion_protection isn't set on the resource.
+func deletionProtectionEnabled(d *schema.ResourceData, meta interface{}) bool {
+	if v, ok := d.GetOkExists(deletionProtectionKey); ok {
+		return v.(bool)
+	}
+	config, ok := meta.(*Config)
+	return ok && config.DefaultDeletionProtection
+}
+
+// wrapUpdateWithDeletionProtection skips calling the resource's Update when
+// deletion_protection is the only field that changed, as it's only stored in
+// state.
+func wrapUpdateWithDeletionProtection(f schema.UpdateFunc) schema.UpdateFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		if !d.HasChangesExcept(deletionProtectionKey) {
+			return nil
+		}
+		return f(d, meta)
+	}
+}
+
+func wrapDeleteWithDeletionProtection(name string, f schema.DeleteFunc) schema.DeleteFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		if deletionProtectionEnabled(d, meta) {
+			return fmt.Errorf("cannot destroy %s %q without setting deletion_protection=false and running `terraform apply`", name, d.Id())
+		}
+		return f(d, meta)
+	}
+}
+
+// wrapDeleteWithDefaultDeletionProtection applies the provider's default to a
+// resource with its own deletion_protection field, leaving the resource to
+// check the field when it's set.
+func wrapDeleteWithDefaultDeletionProtection(name string, f schema.DeleteFunc) schema.DeleteFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		if _, ok := d.GetOkExists(deletionProtectionKey); !ok && deletionProtectionEnabled(d, meta) {
+			return fmt.Errorf("cannot destroy %s %q without setting deletion_protection=false and running `terraform apply`", name, d.Id())
+		}
+		return f(d, meta)
+	}
+}
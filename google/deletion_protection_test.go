@@ -0,0 +1,223 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDeletionProtectionResources(t *testing.T) {
+	resources := Provider().ResourcesMap
+	for name := range deletionProtectionResources {
+		r, ok := resources[name]
+		if !ok {
+			t.Errorf("%s isn't a resource of the provider", name)
+			continue
+		}
+		if s, ok := r.Schema[deletionProtectionKey]; !ok || s.Type != schema.TypeBool || !s.Optional {
+			t.Errorf("expected %s to have an optional %s field", name, deletionProtectionKey)
+		}
+	}
+	for name := range ownDeletionProtectionResources {
+		if deletionProtectionResources[name] {
+			t.Errorf("%s can't both have its own and the shared %s field", name, deletionProtectionKey)
+		}
+		if r, ok := resources[name]; !ok {
+			t.Errorf("%s isn't a resource of the provider", name)
+		} else if s, ok := r.Schema[deletionProtectionKey]; !ok {
+			t.Errorf("expected %s to have its own %s field", name, deletionProtectionKey)
+		} else if s.Default != nil {
+			t.Errorf("expected %s's own %s field not to have a default, as the provider's default would never apply", name, deletionProtectionKey)
+		}
+	}
+}
+
+func TestAddDeletionProtectionSupport_ownField(t *testing.T) {
+	// The instance's own Delete checks deletion_protection before deleting
+	// it through the API.
+	var deleted bool
+	r := &schema.Resource{
+		Schema: resourceComputeInstance().Schema,
+		Delete: func(d *schema.ResourceData, meta interface{}) error {
+			if d.Get(deletionProtectionKey).(bool) {
+				return errors.New("protected by the resource")
+			}
+			deleted = true
+			return nil
+		},
+	}
+	addDeletionProtectionSupport(map[string]*schema.Resource{"google_compute_instance": r})
+
+	cases := map[string]struct {
+		Raw               map[string]interface{}
+		DefaultProtection bool
+		ExpectErr         string
+	}{
+		"unset": {
+			Raw: map[string]interface{}{},
+		},
+		"unset with provider default": {
+			Raw:               map[string]interface{}{},
+			DefaultProtection: true,
+			ExpectErr:         "deletion_protection=false",
+		},
+		"enabled": {
+			Raw:       map[string]interface{}{deletionProtectionKey: true},
+			ExpectErr: "protected by the resource",
+		},
+		"disabled with provider default": {
+			Raw:               map[string]interface{}{deletionProtectionKey: false},
+			DefaultProtection: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		deleted = false
+		d := schema.TestResourceDataRaw(t, r.Schema, tc.Raw)
+		d.SetId("foo")
+		err := r.Delete(d, &Config{DefaultDeletionProtection: tc.DefaultProtection})
+		if tc.ExpectErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.ExpectErr) {
+				t.Errorf("%s: expected deletion to fail with %q, got %v", tn, tc.ExpectErr, err)
+			}
+			continue
+		}
+		if err != nil || !deleted {
+			t.Errorf("%s: expected the resource to be deleted, got %v", tn, err)
+		}
+	}
+
+	// Instances created while deletion_protection defaulted to false keep it
+	// in state, and aren't protected by the provider's default.
+	deleted = false
+	d := r.Data(&terraform.InstanceState{ID: "foo", Attributes: map[string]string{"id": "foo", deletionProtectionKey: "false"}})
+	if err := r.Delete(d, &Config{DefaultDeletionProtection: true}); err != nil || !deleted {
+		t.Errorf("expected an instance with deletion_protection false in state to be deleted, got %v", err)
+	}
+}
+
+func TestAddDeletionProtectionSupport(t *testing.T) {
+	var updated, deleted bool
+	newResource := func() *schema.Resource {
+		return &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"description": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+			Create: func(d *schema.ResourceData, meta interface{}) error {
+				d.SetId(d.Get("name").(string))
+				return nil
+			},
+			Read: func(d *schema.ResourceData, meta interface{}) error {
+				return nil
+			},
+			Update: func(d *schema.ResourceData, meta interface{}) error {
+				updated = true
+				return nil
+			},
+			Delete: func(d *schema.ResourceData, meta interface{}) error {
+				deleted = true
+				return nil
+			},
+		}
+	}
+
+	r := newResource()
+	addDeletionProtectionSupport(map[string]*schema.Resource{"google_test": r})
+	if _, ok := r.Schema[deletionProtectionKey]; ok {
+		t.Fatalf("expected %s not to be added to a resource that didn't opt in", deletionProtectionKey)
+	}
+
+	deletionProtectionResources["google_test"] = true
+	defer delete(deletionProtectionResources, "google_test")
+	r = newResource()
+	addDeletionProtectionSupport(map[string]*schema.Resource{"google_test": r})
+	if err := r.InternalValidate(nil, true); err != nil {
+		t.Fatalf("expected the resource to be valid, got %s", err)
+	}
+
+	cases := map[string]struct {
+		Raw               map[string]interface{}
+		DefaultProtection bool
+		ExpectProtected   bool
+	}{
+		"unset": {
+			Raw: map[string]interface{}{"name": "foo"},
+		},
+		"unset with provider default": {
+			Raw:               map[string]interface{}{"name": "foo"},
+			DefaultProtection: true,
+			ExpectProtected:   true,
+		},
+		"enabled": {
+			Raw:             map[string]interface{}{"name": "foo", deletionProtectionKey: true},
+			ExpectProtected: true,
+		},
+		"disabled with provider default": {
+			Raw:               map[string]interface{}{"name": "foo", deletionProtectionKey: false},
+			DefaultProtection: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		deleted = false
+		d := schema.TestResourceDataRaw(t, r.Schema, tc.Raw)
+		d.SetId("foo")
+		err := r.Delete(d, &Config{DefaultDeletionProtection: tc.DefaultProtection})
+		if tc.ExpectProtected {
+			if err == nil || !strings.Contains(err.Error(), "deletion_protection=false") {
+				t.Errorf("%s: expected deletion to fail, got %v", tn, err)
+			}
+			if deleted {
+				t.Errorf("%s: expected the resource's Delete not to be called", tn)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("%s: expected deletion to succeed, got %s", tn, err)
+			}
+			if !deleted {
+				t.Errorf("%s: expected the resource's Delete to be called", tn)
+			}
+		}
+	}
+
+	state := &terraform.InstanceState{ID: "foo", Attributes: map[string]string{"id": "foo", "name": "foo", "description": "a"}}
+	for tn, tc := range map[string]struct {
+		Raw           map[string]interface{}
+		ExpectUpdated bool
+	}{
+		"only deletion_protection changed": {
+			Raw: map[string]interface{}{"name": "foo", "description": "a", deletionProtectionKey: true},
+		},
+		"other fields changed": {
+			Raw:           map[string]interface{}{"name": "foo", "description": "b", deletionProtectionKey: true},
+			ExpectUpdated: true,
+		},
+	} {
+		updated = false
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(tc.Raw), &Config{})
+		if err != nil {
+			t.Fatalf("%s: %s", tn, err)
+		}
+		newState, diags := r.Apply(context.Background(), state, diff, &Config{})
+		if diags.HasError() {
+			t.Fatalf("%s: %v", tn, diags)
+		}
+		if newState.Attributes[deletionProtectionKey] != "true" {
+			t.Errorf("%s: expected %s to be stored in state, got %v", tn, deletionProtectionKey, newState.Attributes)
+		}
+		if updated != tc.ExpectUpdated {
+			t.Errorf("%s: expected the resource's Update to be called: %t, got %t", tn, tc.ExpectUpdated, updated)
+		}
+	}
+}
@@ -113,6 +113,67 @@ func isCommonRetryableErrorCode(err error) (bool, string) {
 	return false, ""
 }
 
+// Retry on googleapi errors with any of the given HTTP status codes. Used for
+// the user-configured retry_status_codes in the provider's retry block.
+func isRetryableErrorCodeFunc(codes []int) RetryErrorPredicateFunc {
+	return func(err error) (bool, string) {
+		gerr, ok := err.(*googleapi.Error)
+		if !ok {
+			return false, ""
+		}
+
+		for _, code := range codes {
+			if gerr.Code == code {
+				return true, fmt.Sprintf("Retryable error code %d (configured on provider)", code)
+			}
+		}
+		return false, ""
+	}
+}
+
+// Retry on googleapi errors carrying any of the given error reasons, either as
+// a legacy error item reason (e.g. "rateLimitExceeded") or as the reason of a
+// google.rpc.ErrorInfo detail (e.g. "RATE_LIMIT_EXCEEDED"). Used for the
+// user-configured retry_error_reasons in the provider's retry block.
+func isRetryableErrorReasonFunc(reasons []string) RetryErrorPredicateFunc {
+	return func(err error) (bool, string) {
+		gerr, ok := err.(*googleapi.Error)
+		if !ok {
+			return false, ""
+		}
+
+		for _, reason := range googleapiErrorReasons(gerr) {
+			for _, r := range reasons {
+				if reason == r {
+					return true, fmt.Sprintf("Retryable error reason %q (configured on provider)", reason)
+				}
+			}
+		}
+		return false, ""
+	}
+}
+
+// googleapiErrorReasons returns the reasons found in a googleapi error's
+// error items and ErrorInfo details.
+func googleapiErrorReasons(gerr *googleapi.Error) []string {
+	var reasons []string
+	for _, item := range gerr.Errors {
+		if item.Reason != "" {
+			reasons = append(reasons, item.Reason)
+		}
+	}
+	for _, detail := range gerr.Details {
+		m, ok := detail.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if reason, ok := m["reason"].(string); ok && reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
 // We've encountered a few common fingerprint-related strings; if this is one of
 // them, we're confident this is an error due to fingerprints.
 var FINGERPRINT_FAIL_ERRORS = []string{"Invalid fingerprint.", "Supplied fingerprint does not match current metadata fingerprint."}
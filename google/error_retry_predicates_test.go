@@ -109,3 +109,46 @@ func TestGRPCNotRetryable(t *testing.T) {
 		t.Errorf("Error incorrectly detected as retryable")
 	}
 }
+
+func TestIsRetryableErrorCodeFunc(t *testing.T) {
+	pred := isRetryableErrorCodeFunc([]int{409, 504})
+	for code, expected := range map[int]bool{409: true, 504: true, 400: false} {
+		isRetryable, _ := pred(&googleapi.Error{Code: code})
+		if isRetryable != expected {
+			t.Errorf("expected code %d retryable to be %t", code, expected)
+		}
+	}
+}
+
+func TestIsRetryableErrorReasonFunc(t *testing.T) {
+	pred := isRetryableErrorReasonFunc([]string{"rateLimitExceeded", "RATE_LIMIT_EXCEEDED"})
+
+	err := googleapi.Error{
+		Code:   403,
+		Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}},
+	}
+	if isRetryable, _ := pred(&err); !isRetryable {
+		t.Errorf("Error not detected as retryable")
+	}
+
+	err = googleapi.Error{
+		Code: 429,
+		Details: []interface{}{
+			map[string]interface{}{
+				"@type":  "type.googleapis.com/google.rpc.ErrorInfo",
+				"reason": "RATE_LIMIT_EXCEEDED",
+			},
+		},
+	}
+	if isRetryable, _ := pred(&err); !isRetryable {
+		t.Errorf("Error not detected as retryable")
+	}
+
+	err = googleapi.Error{
+		Code:   403,
+		Errors: []googleapi.ErrorItem{{Reason: "forbidden"}},
+	}
+	if isRetryable, _ := pred(&err); isRetryable {
+		t.Errorf("Error incorrectly detected as retryable")
+	}
+}
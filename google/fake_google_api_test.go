@@ -0,0 +1,321 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testFakeApiApply plans and applies raw as the config of a resource of type
+// name, and checks planning it again after a refresh shows no changes.
+func testFakeApiApply(t *testing.T, config *Config, name string, state *terraform.InstanceState, raw map[string]interface{}) *terraform.InstanceState {
+	t.Helper()
+	ctx := context.Background()
+	r := Provider().ResourcesMap[name]
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("%s: error planning: %s", name, err)
+	}
+	state, diags := r.Apply(ctx, state, diff, config)
+	if diags.HasError() {
+		t.Fatalf("%s: error applying: %v", name, diags)
+	}
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, config)
+	if diags.HasError() {
+		t.Fatalf("%s: error refreshing: %v", name, diags)
+	}
+	if state == nil {
+		t.Fatalf("%s: not found after applying", name)
+	}
+
+	diff, err = r.Diff(ctx, state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("%s: error planning: %s", name, err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("%s: expected an empty plan after applying, got %v", name, diff)
+	}
+	return state
+}
+
+func testFakeApiDestroy(t *testing.T, config *Config, name string, state *terraform.InstanceState) {
+	t.Helper()
+	r := Provider().ResourcesMap[name]
+	if _, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, config); diags.HasError() {
+		t.Fatalf("%s: error destroying: %v", name, diags)
+	}
+}
+
+// testFakeApiReadData reads the data source name, and returns its data.
+func testFakeApiReadData(t *testing.T, config *Config, name string, raw map[string]interface{}) (*schema.ResourceData, error) {
+	t.Helper()
+	r := Provider().DataSourcesMap[name]
+	d := schema.TestResourceDataRaw(t, r.Schema, raw)
+	return d, r.Read(d, config)
+}
+
+func TestFakeGoogleApi_computeNetwork(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+
+	network := testFakeApiApply(t, config, "google_compute_network", nil, map[string]interface{}{
+		"name":                    "my-network",
+		"auto_create_subnetworks": false,
+	})
+	subnetworkConfig := map[string]interface{}{
+		"name":          "my-subnetwork",
+		"region":        "us-central1",
+		"network":       network.Attributes["self_link"],
+		"ip_cidr_range": "10.2.0.0/16",
+	}
+	subnetwork := testFakeApiApply(t, config, "google_compute_subnetwork", nil, subnetworkConfig)
+	if got := subnetwork.Attributes["gateway_address"]; got != "10.2.0.1" {
+		t.Errorf("expected gateway_address 10.2.0.1, got %q", got)
+	}
+
+	// Updated with a custom method
+	subnetworkConfig["private_ip_google_access"] = true
+	subnetwork = testFakeApiApply(t, config, "google_compute_subnetwork", subnetwork, subnetworkConfig)
+	obj, _ := api.get("compute/v1/projects/fake-project/regions/us-central1/subnetworks/my-subnetwork")
+	if obj["privateIpGoogleAccess"] != true {
+		t.Errorf("expected privateIpGoogleAccess to be updated, got %v", obj)
+	}
+
+	testFakeApiDestroy(t, config, "google_compute_subnetwork", subnetwork)
+	testFakeApiDestroy(t, config, "google_compute_network", network)
+	if _, ok := api.get("compute/v1/projects/fake-project/global/networks/my-network"); ok {
+		t.Errorf("expected network to be deleted")
+	}
+}
+
+func TestFakeGoogleApi_storageBucket(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+
+	bucketConfig := map[string]interface{}{
+		"name":     "my-bucket",
+		"location": "eu",
+		"labels":   map[string]interface{}{"env": "test"},
+	}
+	bucket := testFakeApiApply(t, config, "google_storage_bucket", nil, bucketConfig)
+	if got := bucket.Attributes["url"]; got != "gs://my-bucket" {
+		t.Errorf("expected url gs://my-bucket, got %q", got)
+	}
+
+	bucketConfig["labels"] = map[string]interface{}{"env": "prod"}
+	bucket = testFakeApiApply(t, config, "google_storage_bucket", bucket, bucketConfig)
+	obj, _ := api.get("storage/v1/b/my-bucket")
+	if obj["labels"].(map[string]interface{})["env"] != "prod" || obj["metageneration"] != "2" {
+		t.Errorf("expected bucket to be updated, got %v", obj)
+	}
+
+	member := testFakeApiApply(t, config, "google_storage_bucket_iam_member", nil, map[string]interface{}{
+		"bucket": "my-bucket",
+		"role":   "roles/storage.objectViewer",
+		"member": "allUsers",
+	})
+	testFakeApiDestroy(t, config, "google_storage_bucket_iam_member", member)
+
+	testFakeApiDestroy(t, config, "google_storage_bucket", bucket)
+	if _, ok := api.get("storage/v1/b/my-bucket"); ok {
+		t.Errorf("expected bucket to be deleted")
+	}
+}
+
+func TestFakeGoogleApi_pubsub(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+
+	topicConfig := map[string]interface{}{
+		"name": "my-topic",
+	}
+	topic := testFakeApiApply(t, config, "google_pubsub_topic", nil, topicConfig)
+	topicConfig["labels"] = map[string]interface{}{"env": "test"}
+	topic = testFakeApiApply(t, config, "google_pubsub_topic", topic, topicConfig)
+
+	subscription := testFakeApiApply(t, config, "google_pubsub_subscription", nil, map[string]interface{}{
+		"name":                 "my-subscription",
+		"topic":                topic.ID,
+		"ack_deadline_seconds": 20,
+	})
+	obj, _ := api.get("pubsub/v1/projects/fake-project/subscriptions/my-subscription")
+	if obj["topic"] != "projects/fake-project/topics/my-topic" {
+		t.Errorf("expected subscription to the topic, got %v", obj)
+	}
+
+	// Subscriptions to missing topics fail
+	r := Provider().ResourcesMap["google_pubsub_subscription"]
+	raw := terraform.NewResourceConfigRaw(map[string]interface{}{"name": "other", "topic": "missing"})
+	diff, err := r.Diff(context.Background(), nil, raw, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := r.Apply(context.Background(), nil, diff, config); !diags.HasError() || !strings.Contains(diags[0].Summary, "Resource not found") {
+		t.Errorf("expected creating a subscription to a missing topic to fail, got %v", diags)
+	}
+
+	testFakeApiDestroy(t, config, "google_pubsub_subscription", subscription)
+	testFakeApiDestroy(t, config, "google_pubsub_topic", topic)
+}
+
+func TestFakeGoogleApi_iam(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+
+	saConfig := map[string]interface{}{
+		"account_id":   "my-account",
+		"display_name": "My account",
+	}
+	sa := testFakeApiApply(t, config, "google_service_account", nil, saConfig)
+	email := "my-account@fake-project.iam.gserviceaccount.com"
+	if got := sa.Attributes["email"]; got != email {
+		t.Errorf("expected email %q, got %q", email, got)
+	}
+	saConfig["display_name"] = "Renamed"
+	sa = testFakeApiApply(t, config, "google_service_account", sa, saConfig)
+
+	member := testFakeApiApply(t, config, "google_project_iam_member", nil, map[string]interface{}{
+		"project": fakeGoogleApiProject,
+		"role":    "roles/viewer",
+		"member":  "serviceAccount:" + email,
+	})
+	saMember := testFakeApiApply(t, config, "google_service_account_iam_member", nil, map[string]interface{}{
+		"service_account_id": sa.ID,
+		"role":               "roles/iam.serviceAccountUser",
+		"member":             "user:jane@example.com",
+	})
+
+	testFakeApiDestroy(t, config, "google_service_account_iam_member", saMember)
+	testFakeApiDestroy(t, config, "google_project_iam_member", member)
+	testFakeApiDestroy(t, config, "google_service_account", sa)
+
+	api.mu.Lock()
+	bindings := api.policies["cloudresourcemanager/v1/projects/fake-project"]["bindings"]
+	api.mu.Unlock()
+	if bindings != nil {
+		t.Errorf("expected project bindings to be removed, got %v", bindings)
+	}
+}
+
+func TestFakeGoogleApi_requests(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+
+	cases := []struct {
+		method       string
+		path         string
+		body         map[string]interface{}
+		expectedCode int
+		expected     map[string]interface{}
+	}{
+		{"GET", "sqladmin/v1beta4/projects/fake-project/instances", nil, http.StatusNotImplemented, nil},
+		{"GET", "compute/v1/projects/fake-project/global/networks", nil, 0, map[string]interface{}{}},
+		{"POST", "compute/v1/projects/fake-project/global/networks", map[string]interface{}{"name": "a"}, 0, map[string]interface{}{"status": "RUNNING"}},
+		{"POST", "compute/v1/projects/fake-project/global/networks", map[string]interface{}{"name": "a"}, http.StatusConflict, nil},
+		{"POST", "compute/v1/projects/fake-project/global/networks", map[string]interface{}{}, http.StatusBadRequest, nil},
+		{"GET", "compute/v1/projects/fake-project/global/networks/b", nil, http.StatusNotFound, nil},
+		{"PUT", "pubsub/v1/projects/fake-project/topics/t", map[string]interface{}{"labels": map[string]interface{}{"a": "b"}}, 0, map[string]interface{}{"name": "projects/fake-project/topics/t"}},
+		{"PATCH", "pubsub/v1/projects/fake-project/topics/t?updateMask=labels", map[string]interface{}{"topic": map[string]interface{}{}}, 0, map[string]interface{}{"name": "projects/fake-project/topics/t"}},
+		{"POST", "pubsub/v1/projects/fake-project/topics/t:setIamPolicy", map[string]interface{}{"policy": map[string]interface{}{"etag": "stale"}}, http.StatusConflict, nil},
+		{"POST", "pubsub/v1/projects/fake-project/topics/t:testIamPermissions", map[string]interface{}{"permissions": []interface{}{"pubsub.topics.get"}}, 0, map[string]interface{}{"permissions": []interface{}{"pubsub.topics.get"}}},
+	}
+	for _, tc := range cases {
+		res, err := sendRequest(config, tc.method, "", api.URL+"/"+tc.path, "", tc.body)
+		if tc.expectedCode != 0 {
+			if !isGoogleApiErrorWithCode(err, tc.expectedCode) {
+				t.Errorf("%s %s: expected a %d error, got %v", tc.method, tc.path, tc.expectedCode, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %s", tc.method, tc.path, err)
+			continue
+		}
+		for k, v := range tc.expected {
+			if fmt.Sprint(res[k]) != fmt.Sprint(v) {
+				t.Errorf("%s %s: expected %s to be %v, got %v", tc.method, tc.path, k, v, res)
+			}
+		}
+	}
+
+	obj, _ := api.get("pubsub/v1/projects/fake-project/topics/t")
+	if _, ok := obj["labels"]; ok {
+		t.Errorf("expected labels to be cleared by an update with them in the mask, got %v", obj)
+	}
+}
+
+func TestAccFakeGoogleApi_basic(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	resource.Test(t, resource.TestCase{
+		Providers: api.providers(),
+		CheckDestroy: func(s *terraform.State) error {
+			for _, path := range []string{
+				"compute/v1/projects/fake-project/global/networks/my-network",
+				"pubsub/v1/projects/fake-project/topics/my-topic",
+			} {
+				if _, ok := api.get(path); ok {
+					return fmt.Errorf("%s still exists", path)
+				}
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFakeGoogleApi_basic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("google_compute_subnetwork.subnetwork", "gateway_address", "10.2.0.1"),
+					resource.TestCheckResourceAttr("google_pubsub_subscription.subscription", "topic", "projects/fake-project/topics/my-topic"),
+				),
+			},
+			{
+				ResourceName:      "google_compute_network.network",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccFakeGoogleApi_basic() string {
+	return `
+resource "google_compute_network" "network" {
+  name                    = "my-network"
+  auto_create_subnetworks = false
+}
+
+resource "google_compute_subnetwork" "subnetwork" {
+  name          = "my-subnetwork"
+  region        = "us-central1"
+  network       = google_compute_network.network.self_link
+  ip_cidr_range = "10.2.0.0/16"
+}
+
+resource "google_pubsub_topic" "topic" {
+  name = "my-topic"
+}
+
+resource "google_pubsub_subscription" "subscription" {
+  name  = "my-subscription"
+  topic = google_pubsub_topic.topic.id
+}
+`
+}
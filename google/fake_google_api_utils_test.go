@@ -0,0 +1,1162 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// The project the fake API starts with, and providers using it default to.
+const fakeGoogleApiProject = "fake-project"
+
+/**
+* fakeGoogleApi is an in-process fake of the parts of the Google APIs the
+* provider's core resources use, so they can be tested without credentials or
+* network access. It keeps the resources it's sent in memory, and supports:
+*
+*   - Compute networks, subnetworks, firewalls and routes, with operations that
+*     are pending until they're polled
+*   - Storage buckets and their IAM policies, and objects uploaded to them
+*   - Pub/Sub topics and subscriptions and their IAM policies
+*   - IAM service accounts and their IAM policies
+*   - Resource Manager projects' IAM policies. Projects can't be created, as the
+*     provider polls project operations on the real endpoint, but the fake
+*     starts with fakeGoogleApiProject.
+*
+* Requests for anything else fail with a 501 error naming the unsupported
+* path. The provider reaches the fake through its *_custom_endpoint fields, so
+* a test can use it with resource.Test, in place of vcrTest:
+*
+*   api := newFakeGoogleApi(t)
+*   resource.Test(t, resource.TestCase{
+*     Providers:    api.providers(),
+*     CheckDestroy: ...,
+*     Steps:        ...,
+*   })
+*
+* or call resources' CRUD functions directly with api.config(t).
+**/
+type fakeGoogleApi struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// resources by their path on the server, e.g. "pubsub/v1/projects/p/topics/t"
+	resources map[string]map[string]interface{}
+	// IAM policies by the path of the resource they're set on
+	policies map[string]map[string]interface{}
+	// operations by their path on the server
+	operations map[string]*fakeApiOperation
+	// the media of storage objects by their path on the server
+	media map[string][]byte
+	// resumable uploads by their ID
+	uploads map[string]*fakeApiUpload
+	// The number of resumable upload requests that fail after storing half
+	// their data, to test uploads resume.
+	uploadFailures int
+	ids            int
+}
+
+type fakeApiUpload struct {
+	bucketPath string
+	obj        map[string]interface{}
+	mediaType  string
+	data       []byte
+}
+
+type fakeApiOperation struct {
+	op map[string]interface{}
+	// The number of times the operation is polled before it's done.
+	polls int
+}
+
+// fakeApiService is a service the fake API supports, e.g. Compute.
+type fakeApiService struct {
+	// The provider field the service's base path is set with.
+	endpoint string
+	// The service's base path on the server, e.g. "compute/v1/".
+	prefix string
+	// Whether mutating methods return Compute operations.
+	computeOperations bool
+	collections       []*fakeApiCollection
+}
+
+// fakeApiCollection is a collection of resources of a service, e.g. networks.
+type fakeApiCollection struct {
+	// Matches the path of the collection relative to the service's base path.
+	pattern *regexp.Regexp
+	// The field of list responses holding the collection's resources.
+	listField string
+	// Returns whether a list request returns the resource, if lists can be
+	// filtered.
+	listFilter func(api *fakeGoogleApi, r *http.Request, obj map[string]interface{}) bool
+	// Returns the ID and body of the resource a POST to the collection
+	// creates, if resources can be created with a POST.
+	create func(r *http.Request, collection string, body map[string]interface{}) (string, map[string]interface{}, error)
+	// Fills in the output only fields of a new resource.
+	init func(api *fakeGoogleApi, path string, obj map[string]interface{})
+	// The field of update requests wrapping the resource, if any.
+	updateField string
+	// Whether resources are created with a PUT to their path, rather than a
+	// POST to the collection. Otherwise, a PUT updates the resource.
+	putCreates bool
+}
+
+var fakeApiServices = []*fakeApiService{
+	{
+		endpoint:          "compute_custom_endpoint",
+		prefix:            "compute/v1/",
+		computeOperations: true,
+		collections: []*fakeApiCollection{
+			{
+				pattern:   regexp.MustCompile(`^projects/[^/]+/global/networks$`),
+				listField: "items",
+				create:    fakeApiCreateFromField("name"),
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					api.initComputeResource("compute#network", path, obj)
+					setDefault(obj, "routingConfig", map[string]interface{}{"routingMode": "REGIONAL"})
+				},
+			},
+			{
+				pattern:   regexp.MustCompile(`^projects/[^/]+/regions/[^/]+/subnetworks$`),
+				listField: "items",
+				create:    fakeApiCreateFromField("name"),
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					api.initComputeResource("compute#subnetwork", path, obj)
+					if cidr, ok := obj["ipCidrRange"].(string); ok {
+						if ip, _, err := net.ParseCIDR(cidr); err == nil && ip.To4() != nil {
+							ip = ip.To4()
+							obj["gatewayAddress"] = net.IPv4(ip[0], ip[1], ip[2], ip[3]+1).String()
+						}
+					}
+					setDefault(obj, "privateIpGoogleAccess", false)
+				},
+			},
+			{
+				pattern:   regexp.MustCompile(`^projects/[^/]+/global/firewalls$`),
+				listField: "items",
+				create:    fakeApiCreateFromField("name"),
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					api.initComputeResource("compute#firewall", path, obj)
+					setDefault(obj, "direction", "INGRESS")
+					setDefault(obj, "priority", 1000)
+				},
+			},
+			{
+				pattern:   regexp.MustCompile(`^projects/[^/]+/global/routes$`),
+				listField: "items",
+				create:    fakeApiCreateFromField("name"),
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					api.initComputeResource("compute#route", path, obj)
+					setDefault(obj, "priority", 1000)
+				},
+			},
+		},
+	},
+	{
+		endpoint: "storage_custom_endpoint",
+		prefix:   "storage/v1/",
+		collections: []*fakeApiCollection{
+			{
+				pattern:   regexp.MustCompile(`^b$`),
+				listField: "items",
+				listFilter: func(api *fakeGoogleApi, r *http.Request, obj map[string]interface{}) bool {
+					q := r.URL.Query()
+					return obj["projectNumber"] == api.projectNumber(q.Get("project")) && strings.HasPrefix(obj["name"].(string), q.Get("prefix"))
+				},
+				create: fakeApiCreateFromField("name"),
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					name := obj["name"].(string)
+					now := time.Now().UTC().Format(time.RFC3339)
+					obj["kind"] = "storage#bucket"
+					obj["id"] = name
+					obj["selfLink"] = "https://www.googleapis.com/storage/v1/b/" + name
+					obj["projectNumber"] = api.projectNumber(fakeGoogleApiProject)
+					obj["metageneration"] = "1"
+					obj["timeCreated"] = now
+					obj["updated"] = now
+					obj["etag"] = "CAE="
+					setDefault(obj, "location", "US")
+					obj["location"] = strings.ToUpper(obj["location"].(string))
+					setDefault(obj, "storageClass", "STANDARD")
+					// Both fields of the IAM configuration are always set, to the same value.
+					iamConfig, _ := obj["iamConfiguration"].(map[string]interface{})
+					if iamConfig == nil {
+						iamConfig = make(map[string]interface{})
+						obj["iamConfiguration"] = iamConfig
+					}
+					ubla, _ := iamConfig["uniformBucketLevelAccess"].(map[string]interface{})
+					if ubla == nil {
+						ubla, _ = iamConfig["bucketPolicyOnly"].(map[string]interface{})
+					}
+					if ubla == nil {
+						ubla = map[string]interface{}{"enabled": false}
+					}
+					iamConfig["uniformBucketLevelAccess"] = ubla
+					iamConfig["bucketPolicyOnly"] = ubla
+				},
+			},
+		},
+	},
+	{
+		endpoint: "pubsub_custom_endpoint",
+		prefix:   "pubsub/v1/",
+		collections: []*fakeApiCollection{
+			{
+				pattern:     regexp.MustCompile(`^projects/[^/]+/topics$`),
+				listField:   "topics",
+				updateField: "topic",
+				putCreates:  true,
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					obj["name"] = strings.TrimPrefix(path, "pubsub/v1/")
+				},
+			},
+			{
+				pattern:     regexp.MustCompile(`^projects/[^/]+/subscriptions$`),
+				listField:   "subscriptions",
+				updateField: "subscription",
+				putCreates:  true,
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					obj["name"] = strings.TrimPrefix(path, "pubsub/v1/")
+					setDefault(obj, "ackDeadlineSeconds", 10)
+					setDefault(obj, "messageRetentionDuration", "604800s")
+					setDefault(obj, "expirationPolicy", map[string]interface{}{"ttl": "2678400s"})
+					setDefault(obj, "pushConfig", map[string]interface{}{})
+				},
+			},
+		},
+	},
+	{
+		endpoint: IAMCustomEndpointEntryKey,
+		prefix:   "iam/v1/",
+		collections: []*fakeApiCollection{
+			{
+				pattern:     regexp.MustCompile(`^projects/[^/]+/serviceAccounts$`),
+				listField:   "accounts",
+				updateField: "serviceAccount",
+				create: func(r *http.Request, collection string, body map[string]interface{}) (string, map[string]interface{}, error) {
+					accountId, _ := body["accountId"].(string)
+					if accountId == "" {
+						return "", nil, fmt.Errorf("accountId is required")
+					}
+					project := strings.Split(collection, "/")[1]
+					sa, _ := body["serviceAccount"].(map[string]interface{})
+					if sa == nil {
+						sa = make(map[string]interface{})
+					}
+					return fmt.Sprintf("%s@%s.iam.gserviceaccount.com", accountId, project), sa, nil
+				},
+				init: func(api *fakeGoogleApi, path string, obj map[string]interface{}) {
+					parts := strings.Split(path, "/")
+					obj["name"] = strings.TrimPrefix(path, "iam/v1/")
+					obj["projectId"] = parts[3]
+					obj["email"] = parts[5]
+					obj["uniqueId"] = api.nextId()
+					obj["oauth2ClientId"] = obj["uniqueId"]
+					obj["etag"] = "MDEwMjE5MjA="
+				},
+			},
+		},
+	},
+	{
+		endpoint: "resource_manager_custom_endpoint",
+		prefix:   "cloudresourcemanager/v1/",
+		collections: []*fakeApiCollection{
+			{
+				pattern:   regexp.MustCompile(`^projects$`),
+				listField: "projects",
+			},
+		},
+	},
+}
+
+// fakeApiCreateFromField creates resources with the ID in the given field of
+// the request body.
+func fakeApiCreateFromField(field string) func(*http.Request, string, map[string]interface{}) (string, map[string]interface{}, error) {
+	return func(r *http.Request, collection string, body map[string]interface{}) (string, map[string]interface{}, error) {
+		id, _ := body[field].(string)
+		if id == "" {
+			return "", nil, fmt.Errorf("%s is required", field)
+		}
+		return id, body, nil
+	}
+}
+
+func setDefault(obj map[string]interface{}, k string, v interface{}) {
+	if _, ok := obj[k]; !ok {
+		obj[k] = v
+	}
+}
+
+// newFakeGoogleApi starts a fake API server, which is stopped when the test
+// finishes.
+func newFakeGoogleApi(t *testing.T) *fakeGoogleApi {
+	api := &fakeGoogleApi{
+		resources:  make(map[string]map[string]interface{}),
+		policies:   make(map[string]map[string]interface{}),
+		operations: make(map[string]*fakeApiOperation),
+		media:      make(map[string][]byte),
+		uploads:    make(map[string]*fakeApiUpload),
+	}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.Close)
+
+	api.put("cloudresourcemanager/v1/projects/"+fakeGoogleApiProject, map[string]interface{}{
+		"projectId":      fakeGoogleApiProject,
+		"projectNumber":  "123456789012",
+		"name":           fakeGoogleApiProject,
+		"lifecycleState": "ACTIVE",
+	})
+	return api
+}
+
+// providers returns providers sending requests for the services the fake
+// supports to it, authenticated with a fake access token, and using
+// fakeGoogleApiProject unless they're configured with another project.
+func (api *fakeGoogleApi) providers() map[string]*schema.Provider {
+	prov := Provider()
+	configure := prov.ConfigureContextFunc
+	prov.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		for _, s := range fakeApiServices {
+			if err := d.Set(s.endpoint, api.URL+"/"+s.prefix); err != nil {
+				return nil, diag.FromErr(err)
+			}
+		}
+		if err := d.Set("access_token", "fake-access-token"); err != nil {
+			return nil, diag.FromErr(err)
+		}
+		if d.Get("project").(string) == "" {
+			if err := d.Set("project", fakeGoogleApiProject); err != nil {
+				return nil, diag.FromErr(err)
+			}
+		}
+
+		meta, diags := configure(ctx, d)
+		if diags.HasError() {
+			return nil, diags
+		}
+		// Operations are done the first time they're polled.
+		meta.(*Config).PollInterval = 10 * time.Millisecond
+		return meta, diags
+	}
+	return map[string]*schema.Provider{
+		"google":      prov,
+		"google-beta": prov,
+	}
+}
+
+// config returns the config of a provider using the fake, for calling
+// resources' functions directly.
+func (api *fakeGoogleApi) config(t *testing.T) *Config {
+	prov := api.providers()["google"]
+	if diags := prov.Configure(context.Background(), terraform.NewResourceConfigRaw(nil)); diags.HasError() {
+		t.Fatalf("error configuring provider: %v", diags)
+	}
+	return prov.Meta().(*Config)
+}
+
+// get returns a copy of the resource at path, e.g.
+// "compute/v1/projects/fake-project/global/networks/my-network".
+func (api *fakeGoogleApi) get(path string) (map[string]interface{}, bool) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	obj, ok := api.resources[path]
+	if !ok {
+		return nil, false
+	}
+	return fakeApiCopy(obj), true
+}
+
+// put adds or replaces the resource at path, e.g. to set up resources a test
+// depends on.
+func (api *fakeGoogleApi) put(path string, obj map[string]interface{}) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.resources[path] = fakeApiCopy(obj)
+}
+
+func (api *fakeGoogleApi) nextId() string {
+	api.ids++
+	return fmt.Sprintf("%d", 1000000000000000000+api.ids)
+}
+
+func (api *fakeGoogleApi) projectNumber(project string) string {
+	if p, ok := api.resources["cloudresourcemanager/v1/projects/"+project]; ok {
+		return p["projectNumber"].(string)
+	}
+	return "0"
+}
+
+func (api *fakeGoogleApi) initComputeResource(kind, path string, obj map[string]interface{}) {
+	obj["kind"] = kind
+	obj["id"] = api.nextId()
+	obj["creationTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+	obj["selfLink"] = "https://www.googleapis.com/" + path
+	if m := regexp.MustCompile(`^compute/v1/projects/[^/]+/regions/[^/]+`).FindString(path); m != "" {
+		obj["region"] = "https://www.googleapis.com/" + m
+	}
+	obj["fingerprint"] = base64.StdEncoding.EncodeToString([]byte(obj["id"].(string)))
+}
+
+type fakeApiError struct {
+	code    int
+	status  string
+	message string
+}
+
+func (e *fakeApiError) Error() string {
+	return e.message
+}
+
+func fakeApiNotFound(path string) *fakeApiError {
+	return &fakeApiError{http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("The resource '%s' was not found", path)}
+}
+
+func fakeApiBadRequest(format string, a ...interface{}) *fakeApiError {
+	return &fakeApiError{http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf(format, a...)}
+}
+
+func (api *fakeGoogleApi) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+
+	// Object names may contain escaped slashes, and uploads aren't JSON.
+	if m := fakeApiStorageObjectPattern.FindStringSubmatch(r.URL.EscapedPath()); m != nil {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		if q := r.URL.Query(); m[1] != "" && (q.Get("uploadType") == "resumable" || q.Get("upload_id") != "") {
+			api.serveResumableUpload(w, r, m[2], b)
+			return
+		}
+		res, err := api.handleStorageObject(r, m[1] != "", m[2], m[4], m[5] != "", b)
+		fakeApiWriteResponse(w, res, err)
+		return
+	}
+
+	var body map[string]interface{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &body); err != nil {
+			fakeApiWriteError(w, fakeApiBadRequest("Invalid JSON payload received: %s", err))
+			return
+		}
+	}
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+
+	api.mu.Lock()
+	res, err := api.handle(r, strings.TrimPrefix(r.URL.Path, "/"), body)
+	api.mu.Unlock()
+	fakeApiWriteResponse(w, res, err)
+}
+
+func fakeApiWriteResponse(w http.ResponseWriter, res map[string]interface{}, err error) {
+	if err != nil {
+		fakeApiWriteError(w, err)
+		return
+	}
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		panic(err)
+	}
+}
+
+func fakeApiWriteError(w http.ResponseWriter, err error) {
+	e, ok := err.(*fakeApiError)
+	if !ok {
+		e = fakeApiBadRequest("%s", err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(e.code)
+	b, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    e.code,
+			"message": e.message,
+			"status":  e.status,
+			"errors": []interface{}{
+				map[string]interface{}{"message": e.message, "reason": strings.ToLower(e.status)},
+			},
+		},
+	})
+	w.Write(b) // nolint: errcheck
+}
+
+func (api *fakeGoogleApi) handle(r *http.Request, path string, body map[string]interface{}) (map[string]interface{}, error) {
+	unsupported := &fakeApiError{http.StatusNotImplemented, "UNIMPLEMENTED", fmt.Sprintf("The fake Google API doesn't support %s %s", r.Method, path)}
+
+	var service *fakeApiService
+	for _, s := range fakeApiServices {
+		if strings.HasPrefix(path, s.prefix) {
+			service = s
+		}
+	}
+	if service == nil {
+		return nil, unsupported
+	}
+	rel := strings.TrimPrefix(path, service.prefix)
+
+	// Custom methods, e.g. projects/p/topics/t:getIamPolicy
+	if i := strings.LastIndex(rel, ":"); i > 0 && !strings.Contains(rel[i:], "/") {
+		return api.handleIam(r, service.prefix+rel[:i], rel[i+1:], body, unsupported)
+	}
+	if m := regexp.MustCompile(`^(b/[^/]+)/iam(/testPermissions)?$`).FindStringSubmatch(rel); m != nil {
+		method := "getIamPolicy"
+		switch {
+		case m[2] != "":
+			method = "testIamPermissions"
+			body = map[string]interface{}{"permissions": convertStringArrToInterface(r.URL.Query()["permissions"])}
+		case r.Method == "PUT":
+			method = "setIamPolicy"
+			body = map[string]interface{}{"policy": body}
+		}
+		return api.handleIam(r, service.prefix+m[1], method, body, unsupported)
+	}
+	if service.computeOperations && regexp.MustCompile(`^projects/[^/]+/(global|regions/[^/]+|zones/[^/]+)/operations/[^/]+$`).MatchString(rel) && r.Method == "GET" {
+		op, ok := api.operations[path]
+		if !ok {
+			return nil, fakeApiNotFound(rel)
+		}
+		if op.polls--; op.polls <= 0 {
+			op.op["status"] = "DONE"
+			op.op["progress"] = 100
+			op.op["endTime"] = time.Now().UTC().Format(time.RFC3339)
+		}
+		return fakeApiCopy(op.op), nil
+	}
+
+	for _, c := range service.collections {
+		switch {
+		case c.pattern.MatchString(rel):
+			switch r.Method {
+			case "GET":
+				return api.list(r, service.prefix+rel, c), nil
+			case "POST":
+				if c.create == nil {
+					return nil, unsupported
+				}
+				id, obj, err := c.create(r, rel, body)
+				if err != nil {
+					return nil, fakeApiBadRequest("%s", err)
+				}
+				return api.insert(service, c, service.prefix+rel+"/"+id, obj)
+			}
+		case c.pattern.MatchString(parentPath(rel)):
+			itemPath := api.resolve(service.prefix + rel)
+			switch r.Method {
+			case "GET":
+				obj, ok := api.resources[itemPath]
+				if !ok {
+					return nil, fakeApiNotFound(rel)
+				}
+				return fakeApiCopy(obj), nil
+			case "PUT":
+				if c.putCreates {
+					return api.insert(service, c, itemPath, body)
+				}
+				return api.update(service, itemPath, body, nil)
+			case "PATCH":
+				var mask []string
+				if m := r.URL.Query().Get("updateMask"); m != "" {
+					mask = strings.Split(m, ",")
+				} else if m, ok := body["updateMask"].(string); ok && m != "" {
+					mask = strings.Split(m, ",")
+				}
+				if c.updateField != "" {
+					body, _ = body[c.updateField].(map[string]interface{})
+				}
+				return api.update(service, itemPath, body, mask)
+			case "DELETE":
+				return api.delete(service, itemPath)
+			}
+		case service.computeOperations && c.pattern.MatchString(parentPath(parentPath(rel))) && r.Method == "POST":
+			// Compute custom methods, e.g. subnetworks/s/setPrivateIpGoogleAccess,
+			// set the fields in their request.
+			return api.update(service, service.prefix+parentPath(rel), body, nil)
+		}
+	}
+	return nil, unsupported
+}
+
+// resolve returns the path of the resource at path, where path may use "-"
+// as a wildcard project.
+func (api *fakeGoogleApi) resolve(path string) string {
+	if !strings.Contains(path, "/projects/-/") {
+		return path
+	}
+	re := regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(path), "/projects/-/", "/projects/[^/]+/", 1) + "$")
+	for k := range api.resources {
+		if re.MatchString(k) {
+			return k
+		}
+	}
+	return path
+}
+
+func fakeApiSnakeToCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func (api *fakeGoogleApi) list(r *http.Request, collection string, c *fakeApiCollection) map[string]interface{} {
+	var paths []string
+	for k, obj := range api.resources {
+		if parentPath(k) == collection && (c.listFilter == nil || c.listFilter(api, r, obj)) {
+			paths = append(paths, k)
+		}
+	}
+	sort.Strings(paths)
+
+	items := make([]interface{}, 0, len(paths))
+	for _, k := range paths {
+		items = append(items, fakeApiCopy(api.resources[k]))
+	}
+	res := make(map[string]interface{})
+	if len(items) > 0 {
+		res[c.listField] = items
+	}
+	return res
+}
+
+func (api *fakeGoogleApi) insert(service *fakeApiService, c *fakeApiCollection, path string, obj map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := api.resources[path]; ok {
+		return nil, &fakeApiError{http.StatusConflict, "ALREADY_EXISTS", fmt.Sprintf("The resource '%s' already exists", strings.TrimPrefix(path, service.prefix))}
+	}
+	if topic, ok := obj["topic"].(string); ok && service.prefix == "pubsub/v1/" {
+		if _, ok := api.resources[service.prefix+topic]; !ok {
+			return nil, &fakeApiError{http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Resource not found (resource=%s).", GetResourceNameFromSelfLink(topic))}
+		}
+	}
+
+	obj = fakeApiCopy(obj)
+	if c.init != nil {
+		c.init(api, path, obj)
+	}
+	api.resources[path] = obj
+	return api.respond(service, "insert", path, obj), nil
+}
+
+// update sets the fields of the resource at path in mask to their values in
+// obj, or merges obj into it if mask is empty.
+func (api *fakeGoogleApi) update(service *fakeApiService, path string, obj map[string]interface{}, mask []string) (map[string]interface{}, error) {
+	cur, ok := api.resources[path]
+	if !ok {
+		return nil, fakeApiNotFound(strings.TrimPrefix(path, service.prefix))
+	}
+	if obj == nil {
+		return nil, fakeApiBadRequest("missing resource in update request")
+	}
+
+	if len(mask) > 0 {
+		for _, field := range mask {
+			// Masks may use the proto field names, e.g. display_name.
+			fakeApiSetField(cur, obj, strings.Split(fakeApiSnakeToCamel(field), "."))
+		}
+	} else {
+		fakeApiMerge(cur, obj)
+	}
+
+	if mg, ok := cur["metageneration"].(string); ok {
+		var n int
+		fmt.Sscanf(mg, "%d", &n) // nolint: errcheck
+		cur["metageneration"] = fmt.Sprintf("%d", n+1)
+		cur["updated"] = time.Now().UTC().Format(time.RFC3339)
+	}
+	return api.respond(service, "patch", path, cur), nil
+}
+
+func (api *fakeGoogleApi) delete(service *fakeApiService, path string) (map[string]interface{}, error) {
+	obj, ok := api.resources[path]
+	if !ok {
+		return nil, fakeApiNotFound(strings.TrimPrefix(path, service.prefix))
+	}
+	for k := range api.resources {
+		if strings.HasPrefix(k, path+"/") {
+			return nil, &fakeApiError{http.StatusConflict, "FAILED_PRECONDITION", fmt.Sprintf("The resource '%s' is not empty", strings.TrimPrefix(path, service.prefix))}
+		}
+	}
+	delete(api.resources, path)
+	delete(api.policies, path)
+
+	if service.computeOperations {
+		return api.respond(service, "delete", path, obj), nil
+	}
+	if service.prefix == "storage/v1/" {
+		return nil, nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+// respond returns the response to a request changing the resource at path:
+// an operation for services with operations, or the resource otherwise.
+func (api *fakeGoogleApi) respond(service *fakeApiService, opType, path string, obj map[string]interface{}) map[string]interface{} {
+	if !service.computeOperations {
+		return fakeApiCopy(obj)
+	}
+
+	// Operations are in the same scope as the resource they change.
+	scope := regexp.MustCompile(`^compute/v1/projects/[^/]+/(global|regions/[^/]+|zones/[^/]+)`).FindStringSubmatch(path)
+	name := "operation-" + api.nextId()
+	opPath := fmt.Sprintf("%s/operations/%s", scope[0], name)
+	op := map[string]interface{}{
+		"kind":          "compute#operation",
+		"id":            api.nextId(),
+		"name":          name,
+		"operationType": opType,
+		"targetLink":    "https://www.googleapis.com/" + path,
+		"status":        "RUNNING",
+		"progress":      0,
+		"insertTime":    time.Now().UTC().Format(time.RFC3339),
+		"selfLink":      "https://www.googleapis.com/" + opPath,
+	}
+	switch {
+	case strings.HasPrefix(scope[1], "regions/"):
+		op["region"] = "https://www.googleapis.com/" + parentPath(parentPath(opPath))
+	case strings.HasPrefix(scope[1], "zones/"):
+		op["zone"] = "https://www.googleapis.com/" + parentPath(parentPath(opPath))
+	}
+	api.operations[opPath] = &fakeApiOperation{op: op, polls: 1}
+	return fakeApiCopy(op)
+}
+
+// handleIam handles the IAM methods of the resource at path.
+func (api *fakeGoogleApi) handleIam(r *http.Request, path, method string, body map[string]interface{}, unsupported error) (map[string]interface{}, error) {
+	path = api.resolve(path)
+	if _, ok := api.resources[path]; !ok {
+		return nil, fakeApiNotFound(path)
+	}
+	policy, ok := api.policies[path]
+	if !ok {
+		policy = map[string]interface{}{"version": 1, "etag": "ACAB"}
+	}
+
+	switch method {
+	case "getIamPolicy":
+		return fakeApiCopy(policy), nil
+	case "setIamPolicy":
+		p, ok := body["policy"].(map[string]interface{})
+		if !ok {
+			return nil, fakeApiBadRequest("policy is required")
+		}
+		if etag, ok := p["etag"].(string); ok && etag != "" && etag != policy["etag"] {
+			return nil, &fakeApiError{http.StatusConflict, "ABORTED", "There were concurrent policy changes. Please retry the whole read-modify-write with exponential backoff."}
+		}
+		p = fakeApiCopy(p)
+		p["etag"] = base64.StdEncoding.EncodeToString([]byte(api.nextId()))
+		setDefault(p, "version", 1)
+		api.policies[path] = p
+		return fakeApiCopy(p), nil
+	case "testIamPermissions":
+		// The fake's caller holds every permission.
+		res := make(map[string]interface{})
+		if ps, ok := body["permissions"].([]interface{}); ok && len(ps) > 0 {
+			res["permissions"] = ps
+		}
+		return res, nil
+	}
+	return nil, unsupported
+}
+
+// fakeApiStorageObjectPattern matches the paths of storage objects and their
+// collections, capturing whether the request is an upload, the bucket, the
+// escaped name of the object if any, and whether it's composed.
+var fakeApiStorageObjectPattern = regexp.MustCompile(`^/(upload/)?storage/v1/b/([^/]+)/o(/([^/]+)(/compose)?)?$`)
+
+// handleStorageObject handles the requests for the objects of a bucket.
+// Objects are uploaded with a multipart or media upload, or composed, and
+// listed by prefix a page at a time.
+func (api *fakeGoogleApi) handleStorageObject(r *http.Request, upload bool, bucket, escapedName string, compose bool, b []byte) (map[string]interface{}, error) {
+	unsupported := &fakeApiError{http.StatusNotImplemented, "UNIMPLEMENTED", fmt.Sprintf("The fake Google API doesn't support %s %s", r.Method, r.URL.Path)}
+	bucketPath := "storage/v1/b/" + bucket
+	if _, ok := api.resources[bucketPath]; !ok {
+		return nil, fakeApiNotFound("b/" + bucket)
+	}
+	name, err := url.PathUnescape(escapedName)
+	if err != nil {
+		return nil, fakeApiBadRequest("%s", err)
+	}
+
+	switch {
+	case upload && name == "" && r.Method == "POST":
+		return api.uploadStorageObject(r, bucketPath, b)
+	case name == "" && r.Method == "GET":
+		return api.listStorageObjects(r, bucketPath), nil
+	case name == "":
+		return nil, unsupported
+	case compose && r.Method == "POST":
+		return api.composeStorageObject(bucketPath, name, b)
+	case compose:
+		return nil, unsupported
+	}
+
+	path := bucketPath + "/o/" + name
+	obj, ok := api.resources[path]
+	if !ok {
+		return nil, fakeApiNotFound("b/" + bucket + "/o/" + name)
+	}
+	switch r.Method {
+	case "GET":
+		return fakeApiCopy(obj), nil
+	case "DELETE":
+		for field, hold := range map[string]string{"temporaryHold": "Temporary", "eventBasedHold": "Event-Based"} {
+			if obj[field] == true {
+				return nil, &fakeApiError{http.StatusForbidden, "FORBIDDEN", fmt.Sprintf("Object '%s/%s' is under active %s hold and cannot be deleted, overwritten or archived until hold is removed.", bucket, name, hold)}
+			}
+		}
+		delete(api.resources, path)
+		delete(api.media, path)
+		return nil, nil
+	}
+	return nil, unsupported
+}
+
+func (api *fakeGoogleApi) uploadStorageObject(r *http.Request, bucketPath string, b []byte) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	var media []byte
+	var mediaType string
+	switch uploadType := r.URL.Query().Get("uploadType"); uploadType {
+	case "media":
+		media, mediaType = b, r.Header.Get("Content-Type")
+	case "multipart":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return nil, fakeApiBadRequest("%s", err)
+		}
+		mr := multipart.NewReader(bytes.NewReader(b), params["boundary"])
+		for i := 0; i < 2; i++ {
+			part, err := mr.NextPart()
+			if err != nil {
+				return nil, fakeApiBadRequest("error reading multipart upload: %s", err)
+			}
+			data, err := ioutil.ReadAll(part)
+			if err != nil {
+				return nil, fakeApiBadRequest("error reading multipart upload: %s", err)
+			}
+			if i == 0 {
+				if err := json.Unmarshal(data, &obj); err != nil {
+					return nil, fakeApiBadRequest("Invalid JSON payload received: %s", err)
+				}
+			} else {
+				media, mediaType = data, part.Header.Get("Content-Type")
+			}
+		}
+	default:
+		return nil, fakeApiBadRequest("unsupported uploadType %q", uploadType)
+	}
+
+	if name := r.URL.Query().Get("name"); name != "" {
+		obj["name"] = name
+	}
+	return api.createStorageObject(bucketPath, obj, media, mediaType)
+}
+
+func (api *fakeGoogleApi) createStorageObject(bucketPath string, obj map[string]interface{}, media []byte, mediaType string) (map[string]interface{}, error) {
+	name, _ := obj["name"].(string)
+	if name == "" {
+		return nil, fakeApiBadRequest("name is required")
+	}
+	path := bucketPath + "/o/" + name
+	api.initStorageObject(path, obj, media)
+	if mediaType != "" {
+		setDefault(obj, "contentType", mediaType)
+	}
+	api.resources[path] = obj
+	api.media[path] = media
+	return fakeApiCopy(obj), nil
+}
+
+// serveResumableUpload starts a resumable upload session, or handles a
+// request sending data to it or asking for its status. Incomplete uploads
+// are answered with a 308 and the range of bytes the fake stored.
+func (api *fakeGoogleApi) serveResumableUpload(w http.ResponseWriter, r *http.Request, bucket string, b []byte) {
+	bucketPath := "storage/v1/b/" + bucket
+	if _, ok := api.resources[bucketPath]; !ok {
+		fakeApiWriteError(w, fakeApiNotFound("b/"+bucket))
+		return
+	}
+
+	if r.Method == "POST" {
+		obj := make(map[string]interface{})
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &obj); err != nil {
+				fakeApiWriteError(w, fakeApiBadRequest("Invalid JSON payload received: %s", err))
+				return
+			}
+		}
+		if name := r.URL.Query().Get("name"); name != "" {
+			obj["name"] = name
+		}
+		id := api.nextId()
+		api.uploads[id] = &fakeApiUpload{bucketPath: bucketPath, obj: obj, mediaType: r.Header.Get("X-Upload-Content-Type")}
+		w.Header().Set("Location", fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=resumable&upload_id=%s", api.URL, bucket, id))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id := r.URL.Query().Get("upload_id")
+	upload, ok := api.uploads[id]
+	if !ok || r.Method != "PUT" {
+		fakeApiWriteError(w, fakeApiNotFound("upload "+id))
+		return
+	}
+	// Content-Range is "bytes first-last/total", where the range is "*" when
+	// there's no data, and the total is "*" until the last chunk.
+	m := regexp.MustCompile(`^bytes (\*|(\d+)-(\d+))/(\*|\d+)$`).FindStringSubmatch(r.Header.Get("Content-Range"))
+	if m == nil {
+		fakeApiWriteError(w, fakeApiBadRequest("invalid Content-Range %q", r.Header.Get("Content-Range")))
+		return
+	}
+	if m[1] != "*" {
+		first, _ := strconv.Atoi(m[2])
+		if first > len(upload.data) {
+			fakeApiWriteError(w, fakeApiBadRequest("invalid Content-Range %q: expected the data from byte %d", m[0], len(upload.data)))
+			return
+		}
+		// Data the fake already stored is ignored.
+		b = b[len(upload.data)-first:]
+		if api.uploadFailures > 0 {
+			api.uploadFailures--
+			upload.data = append(upload.data, b[:len(b)/2]...)
+			fakeApiWriteError(w, &fakeApiError{http.StatusServiceUnavailable, "UNAVAILABLE", "Backend Error"})
+			return
+		}
+		upload.data = append(upload.data, b...)
+	}
+
+	if total, err := strconv.Atoi(m[4]); err == nil && total == len(upload.data) {
+		delete(api.uploads, id)
+		res, err := api.createStorageObject(upload.bucketPath, upload.obj, upload.data, upload.mediaType)
+		fakeApiWriteResponse(w, res, err)
+		return
+	}
+	if len(upload.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(upload.data)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+// composeStorageObject creates the object name from the media of the source
+// objects in a compose request.
+func (api *fakeGoogleApi) composeStorageObject(bucketPath, name string, b []byte) (map[string]interface{}, error) {
+	var req struct {
+		Destination   map[string]interface{} `json:"destination"`
+		SourceObjects []struct {
+			Name       string `json:"name"`
+			Generation string `json:"generation"`
+		} `json:"sourceObjects"`
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, fakeApiBadRequest("Invalid JSON payload received: %s", err)
+	}
+	if len(req.SourceObjects) == 0 || len(req.SourceObjects) > 32 {
+		return nil, fakeApiBadRequest("The number of source components provided (%d) must be between 1 and 32", len(req.SourceObjects))
+	}
+
+	var media []byte
+	components := 0
+	for _, source := range req.SourceObjects {
+		path := bucketPath + "/o/" + source.Name
+		obj, ok := api.resources[path]
+		if !ok {
+			return nil, fakeApiNotFound(strings.TrimPrefix(path, "storage/v1/"))
+		}
+		if source.Generation != "" && source.Generation != obj["generation"] {
+			return nil, &fakeApiError{http.StatusPreconditionFailed, "FAILED_PRECONDITION", "Precondition Failed"}
+		}
+		media = append(media, api.media[path]...)
+		if n, ok := obj["componentCount"].(float64); ok {
+			components += int(n)
+		} else {
+			components++
+		}
+	}
+
+	obj := req.Destination
+	if obj == nil {
+		obj = make(map[string]interface{})
+	}
+	obj["name"] = name
+	res, err := api.createStorageObject(bucketPath, obj, media, "")
+	if err != nil {
+		return nil, err
+	}
+	// Composite objects have no MD5 hash.
+	path := bucketPath + "/o/" + name
+	delete(api.resources[path], "md5Hash")
+	api.resources[path]["componentCount"] = float64(components)
+	delete(res, "md5Hash")
+	res["componentCount"] = float64(components)
+	return res, nil
+}
+
+// initStorageObject fills in the output only fields of the object at path.
+func (api *fakeGoogleApi) initStorageObject(path string, obj map[string]interface{}, media []byte) {
+	parts := strings.SplitN(path, "/", 6)
+	bucket, name := parts[3], parts[5]
+	generation := api.nextId()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	md5Sum := md5.Sum(media)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.Checksum(media, crc32.MakeTable(crc32.Castagnoli)))
+
+	obj["kind"] = "storage#object"
+	obj["id"] = fmt.Sprintf("%s/%s/%s", bucket, name, generation)
+	obj["bucket"] = bucket
+	obj["name"] = name
+	obj["selfLink"] = "https://www.googleapis.com/storage/v1/b/" + bucket + "/o/" + url.PathEscape(name)
+	obj["mediaLink"] = "https://www.googleapis.com/download/storage/v1/b/" + bucket + "/o/" + url.PathEscape(name) + "?generation=" + generation + "&alt=media"
+	obj["generation"] = generation
+	obj["metageneration"] = "1"
+	obj["size"] = fmt.Sprintf("%d", len(media))
+	obj["md5Hash"] = base64.StdEncoding.EncodeToString(md5Sum[:])
+	obj["crc32c"] = base64.StdEncoding.EncodeToString(crc)
+	obj["timeCreated"] = now
+	obj["updated"] = now
+	obj["etag"] = base64.StdEncoding.EncodeToString([]byte(generation))
+	setDefault(obj, "storageClass", api.resources["storage/v1/b/"+bucket]["storageClass"])
+}
+
+func (api *fakeGoogleApi) listStorageObjects(r *http.Request, bucketPath string) map[string]interface{} {
+	q := r.URL.Query()
+	prefix := bucketPath + "/o/" + q.Get("prefix")
+	maxResults := 1000
+	if v, err := strconv.Atoi(q.Get("maxResults")); err == nil && v > 0 {
+		maxResults = v
+	}
+
+	var paths []string
+	for k := range api.resources {
+		if strings.HasPrefix(k, prefix) {
+			paths = append(paths, k)
+		}
+	}
+	sort.Strings(paths)
+
+	// Page tokens are the path of the first object of the page.
+	res := map[string]interface{}{"kind": "storage#objects"}
+	var items []interface{}
+	for _, k := range paths {
+		if k < q.Get("pageToken") {
+			continue
+		}
+		if len(items) == maxResults {
+			res["nextPageToken"] = k
+			break
+		}
+		items = append(items, fakeApiCopy(api.resources[k]))
+	}
+	if len(items) > 0 {
+		res["items"] = items
+	}
+	return res
+}
+
+// putStorageObject adds the object name to bucket, with the given fields
+// and media, e.g. to set up objects a test depends on.
+func (api *fakeGoogleApi) putStorageObject(bucket, name string, obj map[string]interface{}, media []byte) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	obj = fakeApiCopy(obj)
+	obj["name"] = name
+	if _, err := api.createStorageObject("storage/v1/b/"+bucket, obj, media, "application/octet-stream"); err != nil {
+		panic(err)
+	}
+}
+
+// storageObjectMedia returns the media of the object name in bucket.
+func (api *fakeGoogleApi) storageObjectMedia(bucket, name string) ([]byte, bool) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	media, ok := api.media["storage/v1/b/"+bucket+"/o/"+name]
+	return media, ok
+}
+
+// fakeApiMerge merges src into dst as a JSON merge patch: nulls delete
+// fields, objects are merged recursively, and other values replace dst's.
+func fakeApiMerge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		switch v := v.(type) {
+		case nil:
+			delete(dst, k)
+		case map[string]interface{}:
+			d, ok := dst[k].(map[string]interface{})
+			if !ok {
+				d = make(map[string]interface{})
+				dst[k] = d
+			}
+			fakeApiMerge(d, v)
+		default:
+			dst[k] = v
+		}
+	}
+}
+
+// fakeApiSetField sets the field at path in dst to its value in src, or
+// deletes it if it's not set in src.
+func fakeApiSetField(dst, src map[string]interface{}, path []string) {
+	v, ok := src[path[0]]
+	if len(path) == 1 {
+		if ok && v != nil {
+			dst[path[0]] = v
+		} else {
+			delete(dst, path[0])
+		}
+		return
+	}
+	s, _ := v.(map[string]interface{})
+	d, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		d = make(map[string]interface{})
+		dst[path[0]] = d
+	}
+	fakeApiSetField(d, s, path[1:])
+}
+
+func fakeApiCopy(obj map[string]interface{}) map[string]interface{} {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	var c map[string]interface{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		panic(err)
+	}
+	return c
+}
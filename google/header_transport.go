@@ -25,6 +25,15 @@ func newTransportWithHeaders(baseTransit http.RoundTripper) headerTransportLayer
 }
 
 func (h headerTransportLayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The user agent may be tagged with the span of the CRUD function making
+	// the request, which isn't sent to the API.
+	if userAgent, span := splitUserAgentSpan(req.Header.Get("User-Agent")); userAgent != req.Header.Get("User-Agent") {
+		if spanFromContext(req.Context()) == nil {
+			req = req.WithContext(contextWithSpan(req.Context(), span))
+		}
+		req.Header = req.Header.Clone()
+		req.Header.Set("User-Agent", userAgent)
+	}
 	for key, value := range h.Header {
 		// only set headers that are not previously defined
 		if _, ok := req.Header[key]; !ok {
@@ -55,11 +55,33 @@ type (
 	resourceIdParserFunc func(d *schema.ResourceData, config *Config) error
 )
 
-// Locking wrapper around read-only operation with retries.
-func iamPolicyReadWithRetry(updater ResourceIamUpdater) (*cloudresourcemanager.Policy, error) {
+// Backoffs used by iamPolicyReadModifyWrite. Variables so tests can shorten them.
+var (
+	iamPolicyConflictInitialBackoff    = time.Second
+	iamPolicyPropagationInitialBackoff = time.Second
+)
+
+// Number of times iamPolicyReadModifyWrite re-reads a policy and re-applies its
+// changes after the policy was changed concurrently, before giving up.
+const maxIamPolicyConflictRetries = 10
+
+// lockIamPolicy takes the in-process lock on the policy updated by updater and
+// returns a func releasing it. If the lock is disabled with the provider's
+// iam_policy_mutex field, concurrent changes to the policy are only guarded
+// by its etag.
+func lockIamPolicy(config *Config, updater ResourceIamUpdater) func() {
+	if config != nil && config.IamPolicyMutexDisabled {
+		return func() {}
+	}
 	mutexKey := updater.GetMutexKey()
 	mutexKV.Lock(mutexKey)
-	defer mutexKV.Unlock(mutexKey)
+	return func() { mutexKV.Unlock(mutexKey) }
+}
+
+// Locking wrapper around read-only operation with retries.
+func iamPolicyReadWithRetry(config *Config, updater ResourceIamUpdater) (*cloudresourcemanager.Policy, error) {
+	unlock := lockIamPolicy(config, updater)
+	defer unlock()
 
 	log.Printf("[DEBUG] Retrieving policy for %s\n", updater.DescribeResource())
 	var policy *cloudresourcemanager.Policy
@@ -74,13 +96,27 @@ func iamPolicyReadWithRetry(updater ResourceIamUpdater) (*cloudresourcemanager.P
 	return policy, nil
 }
 
-// Locking wrapper around read-modify-write cycle for IAM policy.
-func iamPolicyReadModifyWrite(updater ResourceIamUpdater, modify iamPolicyModifyFunc) error {
-	mutexKey := updater.GetMutexKey()
-	mutexKV.Lock(mutexKey)
-	defer mutexKV.Unlock(mutexKey)
+// isIamPolicyEtagConflict returns whether an error setting a policy means the
+// etag it was sent with is stale, i.e. the policy was changed since it was
+// read.
+func isIamPolicyEtagConflict(err error) bool {
+	return isConflictError(err)
+}
+
+// Read-modify-write cycle for IAM policy. The policy is written with the etag
+// it was read with, so the write fails if the policy was changed concurrently,
+// in which case it's read again and modify is re-applied. Unless disabled,
+// the cycle also holds the in-process lock on the policy.
+func iamPolicyReadModifyWrite(config *Config, updater ResourceIamUpdater, modify iamPolicyModifyFunc) error {
+	unlock := lockIamPolicy(config, updater)
+	defer unlock()
 
-	backoff := time.Second
+	if config != nil {
+		modify = withIamGuardrails(config.IamGuardrails, modify)
+	}
+
+	backoff := iamPolicyConflictInitialBackoff
+	conflicts := 0
 	for {
 		log.Printf("[DEBUG]: Retrieving policy for %s\n", updater.DescribeResource())
 		p, err := updater.GetResourceIamPolicy()
@@ -91,92 +127,109 @@ func iamPolicyReadModifyWrite(updater ResourceIamUpdater, modify iamPolicyModify
 		} else if err != nil {
 			return err
 		}
-		log.Printf("[DEBUG]: Retrieved policy for %s: %+v\n", updater.DescribeResource(), p)
+		log.Printf("[DEBUG]: Retrieved policy for %s with etag %q: %+v\n", updater.DescribeResource(), p.Etag, p)
+		readEtag := p.Etag
+		if readEtag == "" {
+			log.Printf("[WARN] Policy for %s has no etag, so concurrent changes to it can't be detected", updater.DescribeResource())
+		}
 
 		err = modify(p)
 		if err != nil {
 			return err
 		}
 
-		log.Printf("[DEBUG]: Setting policy for %s to %+v\n", updater.DescribeResource(), p)
+		log.Printf("[DEBUG]: Setting policy for %s with etag %q to %+v\n", updater.DescribeResource(), readEtag, p)
 		err = updater.SetResourceIamPolicy(p)
 		if err == nil {
-			fetchBackoff := 1 * time.Second
-			for successfulFetches := 0; successfulFetches < 3; {
-				if fetchBackoff > maxBackoffSeconds*time.Second {
-					return fmt.Errorf("Error applying IAM policy to %s: Waited too long for propagation.\n", updater.DescribeResource())
-				}
-				time.Sleep(fetchBackoff)
-				log.Printf("[DEBUG]: Retrieving policy for %s\n", updater.DescribeResource())
-				new_p, err := updater.GetResourceIamPolicy()
-				if err != nil {
-					// Quota for Read is pretty limited, so watch out for running out of quota.
-					if isGoogleApiErrorWithCode(err, 429) {
-						fetchBackoff = fetchBackoff * 2
-					} else {
-						return err
-					}
-				}
-				log.Printf("[DEBUG]: Retrieved policy for %s: %+v\n", updater.DescribeResource(), p)
-				if new_p == nil {
-					// https://github.com/hashicorp/terraform-provider-google/issues/2625
-					fetchBackoff = fetchBackoff * 2
-					continue
-				}
-				modified_p := new_p
-				// This relies on the fact that `modify` is idempotent: since other changes might have
-				// happened between the call to set the policy and now, we just need to make sure that
-				// our change has been made.  'modify(p) == p' is our check for whether this has been
-				// correctly applied.
-				err = modify(modified_p)
-				if err != nil {
-					return err
-				}
-				if modified_p == new_p {
-					successfulFetches += 1
-				} else {
-					fetchBackoff = fetchBackoff * 2
-				}
+			if err := iamPolicyWaitForPropagation(updater, modify); err != nil {
+				return err
 			}
 			break
 		}
-		if isConflictError(err) {
-			log.Printf("[DEBUG]: Concurrent policy changes, restarting read-modify-write after %s\n", backoff)
-			time.Sleep(backoff)
-			backoff = backoff * 2
-			if backoff > 30*time.Second {
-				return errwrap.Wrapf(fmt.Sprintf("Error applying IAM policy to %s: Too many conflicts.  Latest error: {{err}}", updater.DescribeResource()), err)
+
+		conflict := isIamPolicyEtagConflict(err)
+		if !conflict {
+			// A service account in the policy not being found can be caused by
+			// a concurrent change deleting it, in which case the policy's etag
+			// has changed since it was read. Otherwise, the error is
+			// returned as is.
+			if isServiceAccountNotFoundError, _ := iamServiceAccountNotFound(err); isServiceAccountNotFoundError {
+				currentPolicy, rerr := updater.GetResourceIamPolicy()
+				if rerr != nil {
+					log.Printf("[DEBUG]: error checking etag for policy %s. error: %v", updater.DescribeResource(), rerr)
+				} else if currentPolicy.Etag != readEtag {
+					log.Printf("[DEBUG]: etag for policy %s changed from %q to %q", updater.DescribeResource(), readEtag, currentPolicy.Etag)
+					conflict = true
+				} else {
+					log.Printf("[DEBUG]: etag for policy %s is unchanged, not retrying", updater.DescribeResource())
+				}
 			}
-			continue
+		}
+		if !conflict {
+			log.Printf("[DEBUG]: not retrying IAM policy for %s. error: %v", updater.DescribeResource(), err)
+			return errwrap.Wrapf(fmt.Sprintf("Error applying IAM policy for %s: {{err}}", updater.DescribeResource()), err)
 		}
 
-		// retry in the case that a service account is not found. This can happen when a service account is deleted
-		// out of band.
-		if isServiceAccountNotFoundError, _ := iamServiceAccountNotFound(err); isServiceAccountNotFoundError {
-			// calling a retryable function within a retry loop is not
-			// strictly the _best_ idea, but this error only happens in
-			// high-traffic projects anyways
-			currentPolicy, rerr := iamPolicyReadWithRetry(updater)
-			if rerr != nil {
-				if p.Etag != currentPolicy.Etag {
-					// not matching indicates that there is a new state to attempt to apply
-					log.Printf("current and old etag did not match for %s, retrying", updater.DescribeResource())
-					time.Sleep(backoff)
-					backoff = backoff * 2
-					continue
-				}
+		conflicts++
+		metricIamPolicyConflicts.inc()
+		if conflicts > maxIamPolicyConflictRetries {
+			return errwrap.Wrapf(fmt.Sprintf("Error applying IAM policy to %s: the policy was changed concurrently %d times in a row, giving up. Latest error: {{err}}", updater.DescribeResource(), conflicts), err)
+		}
+		log.Printf("[WARN] Policy for %s was changed concurrently since it was read with etag %q (conflict %d of at most %d): %v. Re-reading the policy and re-applying changes in %s", updater.DescribeResource(), readEtag, conflicts, maxIamPolicyConflictRetries, err, backoff)
+		time.Sleep(backoff)
+		backoff = backoff * 2
+		if backoff > maxBackoffSeconds*time.Second {
+			backoff = maxBackoffSeconds * time.Second
+		}
+	}
+	if conflicts > 0 {
+		log.Printf("[DEBUG]: Set policy for %s after %d conflicts", updater.DescribeResource(), conflicts)
+	} else {
+		log.Printf("[DEBUG]: Set policy for %s", updater.DescribeResource())
+	}
+	return nil
+}
 
-				log.Printf("current and old etag matched for %s, not retrying", updater.DescribeResource())
+// iamPolicyWaitForPropagation waits until modify is reflected in the policy
+// read back from the API on 3 successive reads.
+func iamPolicyWaitForPropagation(updater ResourceIamUpdater, modify iamPolicyModifyFunc) error {
+	fetchBackoff := iamPolicyPropagationInitialBackoff
+	for successfulFetches := 0; successfulFetches < 3; {
+		if fetchBackoff > maxBackoffSeconds*time.Second {
+			return fmt.Errorf("Error applying IAM policy to %s: Waited too long for propagation.\n", updater.DescribeResource())
+		}
+		time.Sleep(fetchBackoff)
+		log.Printf("[DEBUG]: Retrieving policy for %s\n", updater.DescribeResource())
+		new_p, err := updater.GetResourceIamPolicy()
+		if err != nil {
+			// Quota for Read is pretty limited, so watch out for running out of quota.
+			if isGoogleApiErrorWithCode(err, 429) {
+				fetchBackoff = fetchBackoff * 2
 			} else {
-				// if the error is non-nil, just fall through and return the base error
-				log.Printf("[DEBUG]: error checking etag for policy %s. error: %v", updater.DescribeResource(), rerr)
+				return err
 			}
 		}
-
-		log.Printf("[DEBUG]: not retrying IAM policy for %s. error: %v", updater.DescribeResource(), err)
-		return errwrap.Wrapf(fmt.Sprintf("Error applying IAM policy for %s: {{err}}", updater.DescribeResource()), err)
+		log.Printf("[DEBUG]: Retrieved policy for %s: %+v\n", updater.DescribeResource(), new_p)
+		if new_p == nil {
+			// https://github.com/hashicorp/terraform-provider-google/issues/2625
+			fetchBackoff = fetchBackoff * 2
+			continue
+		}
+		modified_p := new_p
+		// This relies on the fact that `modify` is idempotent: since other changes might have
+		// happened between the call to set the policy and now, we just need to make sure that
+		// our change has been made.  'modify(p) == p' is our check for whether this has been
+		// correctly applied.
+		err = modify(modified_p)
+		if err != nil {
+			return err
+		}
+		if modified_p == new_p {
+			successfulFetches += 1
+		} else {
+			fetchBackoff = fetchBackoff * 2
+		}
 	}
-	log.Printf("[DEBUG]: Set policy for %s", updater.DescribeResource())
 	return nil
 }
 
@@ -2,8 +2,11 @@ package google
 
 import (
 	"fmt"
-	"google.golang.org/api/cloudresourcemanager/v1"
 	"time"
+
+	"github.com/hashicorp/errwrap"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
 )
 
 const (
@@ -17,11 +20,12 @@ func BatchRequestModifyIamPolicy(updater ResourceIamUpdater, modify iamPolicyMod
 	batchKey := fmt.Sprintf(batchKeyTmplModifyIamPolicy, updater.GetMutexKey())
 
 	request := &BatchRequest{
-		ResourceName: updater.GetResourceId(),
-		Body:         []iamPolicyModifyFunc{modify},
-		CombineF:     combineBatchIamPolicyModifiers,
-		SendF:        sendBatchModifyIamPolicy(updater),
-		DebugId:      reqDesc,
+		ResourceName:  updater.GetResourceId(),
+		Body:          []iamPolicyModifyFunc{modify},
+		CombineF:      combineBatchIamPolicyModifiers,
+		SendF:         sendBatchModifyIamPolicy(config, updater),
+		IsolateErrorF: isIamBatchErrorIsolatable,
+		DebugId:       reqDesc,
 	}
 
 	_, err := config.requestBatcherIam.SendRequestWithTimeout(batchKey, request, time.Minute*30)
@@ -42,13 +46,13 @@ func combineBatchIamPolicyModifiers(currV interface{}, toAddV interface{}) (inte
 	return append(currModifiers, newModifiers...), nil
 }
 
-func sendBatchModifyIamPolicy(updater ResourceIamUpdater) BatcherSendFunc {
+func sendBatchModifyIamPolicy(config *Config, updater ResourceIamUpdater) BatcherSendFunc {
 	return func(resourceName string, body interface{}) (interface{}, error) {
 		modifiers, ok := body.([]iamPolicyModifyFunc)
 		if !ok {
 			return nil, fmt.Errorf("provider error: expected data to be type []iamPolicyModifyFunc, got %v with type %T", body, body)
 		}
-		return nil, iamPolicyReadModifyWrite(updater, func(policy *cloudresourcemanager.Policy) error {
+		return nil, iamPolicyReadModifyWrite(config, updater, func(policy *cloudresourcemanager.Policy) error {
 			for _, modifyF := range modifiers {
 				if err := modifyF(policy); err != nil {
 					return err
@@ -58,3 +62,19 @@ func sendBatchModifyIamPolicy(updater ResourceIamUpdater) BatcherSendFunc {
 		})
 	}
 }
+
+// isIamBatchErrorIsolatable returns whether an error modifying a policy may be
+// caused by only some of the modifiers in a batch, e.g. a binding for a deleted
+// member. Errors reading or writing the policy itself, such as missing
+// permissions on the resource, fail every modifier the same way.
+func isIamBatchErrorIsolatable(err error) bool {
+	gerr, ok := errwrap.GetType(err, &googleapi.Error{}).(*googleapi.Error)
+	if !ok {
+		return true
+	}
+	switch gerr.Code {
+	case 401, 403, 404:
+		return false
+	}
+	return true
+}
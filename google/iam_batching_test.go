@@ -0,0 +1,39 @@
+package google
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"google.golang.org/api/googleapi"
+)
+
+func TestIsIamBatchErrorIsolatable(t *testing.T) {
+	cases := map[string]struct {
+		Err      error
+		Expected bool
+	}{
+		"invalid member": {
+			Err:      &googleapi.Error{Code: 400, Message: "User user:deleted@example.com does not exist."},
+			Expected: true,
+		},
+		"modifier error": {
+			Err:      errors.New("invalid condition"),
+			Expected: true,
+		},
+		"permission denied": {
+			Err:      errwrap.Wrapf("Error retrieving IAM policy: {{err}}", &googleapi.Error{Code: 403}),
+			Expected: false,
+		},
+		"resource not found": {
+			Err:      &googleapi.Error{Code: 404},
+			Expected: false,
+		},
+	}
+
+	for tn, tc := range cases {
+		if got := isIamBatchErrorIsolatable(tc.Err); got != tc.Expected {
+			t.Errorf("%s: expected %t, got %t", tn, tc.Expected, got)
+		}
+	}
+}
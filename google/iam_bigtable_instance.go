@@ -108,6 +108,26 @@ func (u *BigtableInstanceIamUpdater) SetResourceIamPolicy(policy *cloudresourcem
 	return nil
 }
 
+func (u *BigtableInstanceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewBigTableProjectsInstancesClient(userAgent).TestIamPermissions(u.GetResourceId(),
+			&bigtableadmin.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *BigtableInstanceIamUpdater) GetResourceId() string {
 	return fmt.Sprintf("projects/%s/instances/%s", u.project, u.instance)
 }
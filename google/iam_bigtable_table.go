@@ -129,6 +129,26 @@ func (u *BigtableTableIamUpdater) SetResourceIamPolicy(policy *cloudresourcemana
 	return nil
 }
 
+func (u *BigtableTableIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewBigTableProjectsInstancesTablesClient(userAgent).TestIamPermissions(u.GetResourceId(),
+			&bigtableadmin.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *BigtableTableIamUpdater) GetResourceId() string {
 	return fmt.Sprintf("projects/%s/instances/%s/tables/%s", u.project, u.instance, u.table)
 }
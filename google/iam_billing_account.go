@@ -68,6 +68,26 @@ func (u *BillingAccountIamUpdater) SetResourceIamPolicy(policy *cloudresourceman
 	return nil
 }
 
+func (u *BillingAccountIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewBillingClient(userAgent).BillingAccounts.TestIamPermissions("billingAccounts/"+u.billingAccountId,
+			&cloudbilling.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *BillingAccountIamUpdater) GetResourceId() string {
 	return u.billingAccountId
 }
@@ -0,0 +1,567 @@
+package google
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file checks the syntax of IAM condition expressions, which are written
+// in the Common Expression Language (CEL), so mistakes are reported at plan
+// time instead of by the API at apply time. It only checks syntax: unknown
+// functions and attributes, and type errors, are still reported by the API.
+// See https://github.com/google/cel-spec/blob/master/doc/langdef.md#syntax.
+
+type celTokenKind int
+
+const (
+	celEOF celTokenKind = iota
+	celIdent
+	celNumber
+	celString
+	// Operators, delimiters and the "in" keyword.
+	celPunct
+)
+
+type celToken struct {
+	kind celTokenKind
+	text string
+	// pos is the byte offset of the token in the expression.
+	pos int
+}
+
+// celSyntaxError is a syntax error at a byte offset in an expression.
+type celSyntaxError struct {
+	pos int
+	msg string
+}
+
+func (e *celSyntaxError) Error() string {
+	return e.msg
+}
+
+// Identifiers CEL reserves for future use, which can't be used as names.
+var celReservedWords = map[string]struct{}{
+	"as": {}, "break": {}, "const": {}, "continue": {}, "else": {}, "for": {},
+	"function": {}, "if": {}, "import": {}, "let": {}, "loop": {}, "package": {},
+	"namespace": {}, "return": {}, "var": {}, "void": {}, "while": {},
+}
+
+// Longer operators come first so they're matched before their prefixes.
+var celPunctuation = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "?", ":", "+", "-", "*", "/", "%", ".", ",", "(", ")", "[", "]", "{", "}",
+}
+
+// Binary operators, from lowest to highest precedence.
+var celBinaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"<", "<=", ">", ">=", "==", "!=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func isCelIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isCelIdentPart(c byte) bool {
+	return isCelIdentStart(c) || isCelDigit(c)
+}
+
+func isCelDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isCelHexDigit(c byte) bool {
+	return isCelDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func lexCel(expr string) ([]celToken, error) {
+	var tokens []celToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(expr[i:], "//"):
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case isCelIdentStart(c):
+			start := i
+			for i < len(expr) && isCelIdentPart(expr[i]) {
+				i++
+			}
+			word := expr[start:i]
+
+			// String literals may be prefixed with r for raw strings and b for bytes.
+			if i < len(expr) && (expr[i] == '"' || expr[i] == '\'') {
+				switch strings.ToLower(word) {
+				case "r", "b", "rb", "br":
+					end, err := lexCelString(expr, start, i, strings.ContainsAny(word, "rR"))
+					if err != nil {
+						return nil, err
+					}
+					tokens = append(tokens, celToken{kind: celString, text: expr[start:end], pos: start})
+					i = end
+					continue
+				}
+			}
+
+			if _, ok := celReservedWords[word]; ok {
+				return nil, &celSyntaxError{start, fmt.Sprintf("%q is a reserved identifier", word)}
+			}
+			kind := celIdent
+			if word == "in" {
+				kind = celPunct
+			}
+			tokens = append(tokens, celToken{kind: kind, text: word, pos: start})
+		case isCelDigit(c) || (c == '.' && i+1 < len(expr) && isCelDigit(expr[i+1])):
+			end, err := lexCelNumber(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, celToken{kind: celNumber, text: expr[i:end], pos: i})
+			i = end
+		case c == '"' || c == '\'':
+			end, err := lexCelString(expr, i, i, false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, celToken{kind: celString, text: expr[i:end], pos: i})
+			i = end
+		default:
+			matched := false
+			for _, p := range celPunctuation {
+				if strings.HasPrefix(expr[i:], p) {
+					tokens = append(tokens, celToken{kind: celPunct, text: p, pos: i})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				r, _ := utf8.DecodeRuneInString(expr[i:])
+				return nil, &celSyntaxError{i, fmt.Sprintf("unexpected character %q", r)}
+			}
+		}
+	}
+	return append(tokens, celToken{kind: celEOF, pos: len(expr)}), nil
+}
+
+// lexCelNumber returns the end of the int, uint or double literal at start.
+func lexCelNumber(expr string, start int) (int, error) {
+	i := start
+	if strings.HasPrefix(expr[i:], "0x") || strings.HasPrefix(expr[i:], "0X") {
+		i += 2
+		digits := i
+		for i < len(expr) && isCelHexDigit(expr[i]) {
+			i++
+		}
+		if i == digits {
+			return 0, &celSyntaxError{start, "invalid hexadecimal literal"}
+		}
+	} else {
+		isDouble := false
+		for i < len(expr) && isCelDigit(expr[i]) {
+			i++
+		}
+		if i+1 < len(expr) && expr[i] == '.' && isCelDigit(expr[i+1]) {
+			isDouble = true
+			i++
+			for i < len(expr) && isCelDigit(expr[i]) {
+				i++
+			}
+		}
+		if i < len(expr) && (expr[i] == 'e' || expr[i] == 'E') {
+			j := i + 1
+			if j < len(expr) && (expr[j] == '+' || expr[j] == '-') {
+				j++
+			}
+			if j >= len(expr) || !isCelDigit(expr[j]) {
+				return 0, &celSyntaxError{i, "invalid exponent in number literal"}
+			}
+			isDouble = true
+			i = j
+			for i < len(expr) && isCelDigit(expr[i]) {
+				i++
+			}
+		}
+		if isDouble {
+			return lexCelNumberEnd(expr, start, i)
+		}
+	}
+
+	// Integers may have a u suffix to make them unsigned.
+	if i < len(expr) && (expr[i] == 'u' || expr[i] == 'U') {
+		i++
+	}
+	return lexCelNumberEnd(expr, start, i)
+}
+
+// lexCelNumberEnd checks the number literal from start to end isn't directly
+// followed by an identifier, as in 12abc.
+func lexCelNumberEnd(expr string, start, end int) (int, error) {
+	if end < len(expr) && isCelIdentPart(expr[end]) {
+		return 0, &celSyntaxError{start, fmt.Sprintf("invalid number literal %q", expr[start:end+1])}
+	}
+	return end, nil
+}
+
+// lexCelString returns the end of the string literal whose opening quote is
+// at quote. start is the start of its prefix, if any.
+func lexCelString(expr string, start, quote int, raw bool) (int, error) {
+	q := expr[quote : quote+1]
+	if strings.HasPrefix(expr[quote:], strings.Repeat(q, 3)) {
+		q = strings.Repeat(q, 3)
+	}
+
+	for i := quote + len(q); i < len(expr); {
+		switch {
+		case strings.HasPrefix(expr[i:], q):
+			return i + len(q), nil
+		case expr[i] == '\\' && !raw:
+			n, err := celEscapeLength(expr, i)
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		case (expr[i] == '\n' || expr[i] == '\r') && len(q) == 1:
+			return 0, &celSyntaxError{start, "unterminated string literal, use triple quotes for strings spanning multiple lines"}
+		default:
+			i++
+		}
+	}
+	return 0, &celSyntaxError{start, "unterminated string literal"}
+}
+
+// celEscapeLength returns the length of the escape sequence at i.
+func celEscapeLength(expr string, i int) (int, error) {
+	if i+1 < len(expr) {
+		c := expr[i+1]
+		switch {
+		case strings.IndexByte("abfnrtv\\'\"`?", c) >= 0:
+			return 2, nil
+		case c == 'x' || c == 'X':
+			if celHexDigits(expr, i+2, 2) {
+				return 4, nil
+			}
+		case c == 'u':
+			if celHexDigits(expr, i+2, 4) {
+				return 6, nil
+			}
+		case c == 'U':
+			if celHexDigits(expr, i+2, 8) {
+				return 10, nil
+			}
+		case c >= '0' && c <= '3':
+			if i+3 < len(expr) && expr[i+2] >= '0' && expr[i+2] <= '7' && expr[i+3] >= '0' && expr[i+3] <= '7' {
+				return 4, nil
+			}
+		}
+	}
+
+	end := i + 2
+	if end > len(expr) {
+		end = len(expr)
+	}
+	return 0, &celSyntaxError{i, fmt.Sprintf("invalid escape sequence %q", expr[i:end])}
+}
+
+func celHexDigits(expr string, start, n int) bool {
+	if start+n > len(expr) {
+		return false
+	}
+	for i := start; i < start+n; i++ {
+		if !isCelHexDigit(expr[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// celExprKind is what the macros need to know about their arguments.
+type celExprKind int
+
+const (
+	celOtherExpr celExprKind = iota
+	// A simple name, e.g. a.
+	celIdentExpr
+	// A field selection, e.g. a.b.
+	celSelectExpr
+)
+
+// celParser is a recursive descent parser following the CEL grammar.
+type celParser struct {
+	tokens []celToken
+	next   int
+}
+
+func (p *celParser) peek() celToken {
+	return p.tokens[p.next]
+}
+
+func (p *celParser) accept(punct string) bool {
+	if t := p.peek(); t.kind == celPunct && t.text == punct {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *celParser) expect(punct string) error {
+	if !p.accept(punct) {
+		return p.unexpected(fmt.Sprintf("%q", punct))
+	}
+	return nil
+}
+
+func (p *celParser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == celEOF {
+		return &celSyntaxError{t.pos, fmt.Sprintf("unexpected end of expression, expected %s", expected)}
+	}
+	return &celSyntaxError{t.pos, fmt.Sprintf("unexpected %q, expected %s", t.text, expected)}
+}
+
+// expr = binary ["?" binary ":" expr]
+func (p *celParser) expr() (celExprKind, error) {
+	k, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return k, err
+	}
+	if _, err := p.binary(0); err != nil {
+		return celOtherExpr, err
+	}
+	if err := p.expect(":"); err != nil {
+		return celOtherExpr, err
+	}
+	_, err = p.expr()
+	return celOtherExpr, err
+}
+
+func (p *celParser) binary(level int) (celExprKind, error) {
+	if level == len(celBinaryOperators) {
+		return p.unary()
+	}
+
+	k, err := p.binary(level + 1)
+	for err == nil && p.acceptBinaryOperator(level) {
+		_, err = p.binary(level + 1)
+		k = celOtherExpr
+	}
+	return k, err
+}
+
+func (p *celParser) acceptBinaryOperator(level int) bool {
+	for _, op := range celBinaryOperators[level] {
+		if p.accept(op) {
+			return true
+		}
+	}
+	return false
+}
+
+// unary = member | "!" unary | "-" unary
+func (p *celParser) unary() (celExprKind, error) {
+	if p.accept("!") || p.accept("-") {
+		_, err := p.unary()
+		return celOtherExpr, err
+	}
+	return p.member()
+}
+
+// member = primary {"." IDENT ["(" args ")" | "{" fields "}"] | "[" expr "]"}
+func (p *celParser) member() (celExprKind, error) {
+	k, err := p.primary()
+	for err == nil {
+		switch {
+		case p.accept("."):
+			name := p.peek()
+			if name.kind != celIdent {
+				return celOtherExpr, p.unexpected("a field name")
+			}
+			p.next++
+			k = celSelectExpr
+			if p.accept("(") {
+				err = p.call(name, true)
+				k = celOtherExpr
+			} else if p.accept("{") {
+				err = p.fields()
+				k = celOtherExpr
+			}
+		case p.accept("["):
+			if _, err = p.expr(); err == nil {
+				err = p.expect("]")
+			}
+			k = celOtherExpr
+		default:
+			return k, nil
+		}
+	}
+	return celOtherExpr, err
+}
+
+func (p *celParser) primary() (celExprKind, error) {
+	t := p.peek()
+	switch {
+	case t.kind == celIdent:
+		p.next++
+		if p.accept("(") {
+			return celOtherExpr, p.call(t, false)
+		}
+		if p.accept("{") {
+			return celOtherExpr, p.fields()
+		}
+		return celIdentExpr, nil
+	case t.kind == celPunct && t.text == ".":
+		// A name qualified from the root namespace, e.g. .google.type.Expr.
+		p.next++
+		if p.peek().kind != celIdent {
+			return celOtherExpr, p.unexpected("a name")
+		}
+		return p.primary()
+	case t.kind == celNumber || t.kind == celString:
+		p.next++
+		return celOtherExpr, nil
+	case p.accept("("):
+		if _, err := p.expr(); err != nil {
+			return celOtherExpr, err
+		}
+		return celOtherExpr, p.expect(")")
+	case p.accept("["):
+		return celOtherExpr, p.list("]", func() error {
+			_, err := p.expr()
+			return err
+		})
+	case p.accept("{"):
+		return celOtherExpr, p.list("}", func() error {
+			if _, err := p.expr(); err != nil {
+				return err
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			_, err := p.expr()
+			return err
+		})
+	}
+	return celOtherExpr, p.unexpected("an expression")
+}
+
+// fields parses the field initializers of a message, e.g. {a: 1, b: 2}.
+func (p *celParser) fields() error {
+	return p.list("}", func() error {
+		if p.peek().kind != celIdent {
+			return p.unexpected("a field name")
+		}
+		p.next++
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		_, err := p.expr()
+		return err
+	})
+}
+
+// list parses elements separated by commas, with an optional trailing comma,
+// up to the closing delimiter.
+func (p *celParser) list(closing string, element func() error) error {
+	for !p.accept(closing) {
+		if err := element(); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			return p.expect(closing)
+		}
+	}
+	return nil
+}
+
+// call parses the arguments of a call to name, and checks the arguments of
+// the macros CEL expands at parse time.
+func (p *celParser) call(name celToken, receiver bool) error {
+	var args []celExprKind
+	var positions []int
+	if !p.accept(")") {
+		for {
+			positions = append(positions, p.peek().pos)
+			k, err := p.expr()
+			if err != nil {
+				return err
+			}
+			args = append(args, k)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return p.unexpected(`"," or ")"`)
+			}
+		}
+	}
+
+	switch {
+	case !receiver && name.text == "has":
+		if len(args) != 1 || args[0] != celSelectExpr {
+			return &celSyntaxError{name.pos, "invalid argument to has() macro, expected a field selection such as has(a.b)"}
+		}
+	case receiver && len(args) == 2 && (name.text == "all" || name.text == "exists" || name.text == "exists_one" || name.text == "filter" || name.text == "map"),
+		receiver && len(args) == 3 && name.text == "map":
+		if args[0] != celIdentExpr {
+			return &celSyntaxError{positions[0], fmt.Sprintf("the first argument to %s() must be a simple name", name.text)}
+		}
+	}
+	return nil
+}
+
+// parseCelExpression checks expr is a syntactically valid CEL expression.
+func parseCelExpression(expr string) error {
+	tokens, err := lexCel(expr)
+	if err != nil {
+		return err
+	}
+	p := &celParser{tokens: tokens}
+	if _, err := p.expr(); err != nil {
+		return err
+	}
+	if p.peek().kind != celEOF {
+		return p.unexpected("end of expression")
+	}
+	return nil
+}
+
+// formatCelSyntaxError describes a syntax error in expr with its line and
+// column, followed by the line with a caret under the error, e.g.
+//
+//	line 1, column 12: unexpected end of expression, expected ")"
+//	 | has(a.b) && (
+//	 | ...........^
+func formatCelSyntaxError(expr string, err error) string {
+	serr, ok := err.(*celSyntaxError)
+	if !ok {
+		return err.Error()
+	}
+
+	lineStart := strings.LastIndex(expr[:serr.pos], "\n") + 1
+	lineEnd := strings.Index(expr[lineStart:], "\n")
+	if lineEnd < 0 {
+		lineEnd = len(expr)
+	} else {
+		lineEnd += lineStart
+	}
+	line := strings.Count(expr[:lineStart], "\n") + 1
+	column := utf8.RuneCountInString(expr[lineStart:serr.pos]) + 1
+
+	// Tabs are kept so the caret lines up with the error.
+	caret := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return '.'
+	}, expr[lineStart:serr.pos])
+
+	return fmt.Sprintf("line %d, column %d: %s\n | %s\n | %s^", line, column, serr.msg,
+		strings.TrimRight(expr[lineStart:lineEnd], "\r"), caret)
+}
@@ -0,0 +1,72 @@
+package google
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCelExpression(t *testing.T) {
+	valid := []string{
+		`request.time < timestamp("2020-01-01T00:00:00Z")`,
+		`resource.name.startsWith("projects/_/buckets/my-bucket/objects/logs/")`,
+		`resource.type == "storage.googleapis.com/Bucket" || resource.name.endsWith(".txt")`,
+		`request.time.getHours("America/Los_Angeles") >= 9 && request.time.getHours("America/Los_Angeles") <= 17`,
+		`!(resource.name in ["a", "b",]) && has(request.auth.claims.email)`,
+		`resource.matchTag('123456789012/env', 'prod') ? true : false`,
+		`api.getAttribute("iam.googleapis.com/modifiedGrantsByRole", []).hasOnly(["roles/viewer"])`,
+		`{"a": 1, 'b': -2.5e3}["a"] == 0x1F && 3u > 1u`,
+		`[1, 2, 3].exists(x, x > 2) && [1].map(x, x * 2).all(y, y % 2 == 0)`,
+		"r\"\\d+\" != b'\\x00' + '''multi\nline''' // a comment",
+		`.google.type.Expr{expression: "a"}.expression == "a"`,
+		"request.time <\n  timestamp(\"2020-01-01T00:00:00Z\")",
+	}
+	for _, expr := range valid {
+		if err := parseCelExpression(expr); err != nil {
+			t.Errorf("expected %q to be valid, got %s", expr, formatCelSyntaxError(expr, err))
+		}
+	}
+
+	invalid := map[string]struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		"empty":                 {``, 0, "unexpected end of expression, expected an expression"},
+		"unbalanced":            {`has(a.b) && (a.c`, 16, `unexpected end of expression, expected ")"`},
+		"single equals":         {`resource.name = "a"`, 14, `unexpected character '='`},
+		"single ampersand":      {`a & b`, 2, `unexpected character '&'`},
+		"trailing operator":     {`a ||`, 4, "unexpected end of expression"},
+		"missing argument":      {`a.startsWith("b",)`, 17, `unexpected ")", expected an expression`},
+		"unterminated string":   {`resource.name == "abc`, 17, "unterminated string literal"},
+		"newline in string":     {"a == \"b\nc\"", 5, "use triple quotes"},
+		"invalid escape":        {`a == "\q"`, 6, `invalid escape sequence "\\q"`},
+		"reserved word":         {`a == if`, 5, `"if" is a reserved identifier`},
+		"number and identifier": {`a == 12abc`, 5, `invalid number literal "12a"`},
+		"extra token":           {`a == b c`, 7, `unexpected "c", expected end of expression`},
+		"has without select":    {`has(a)`, 0, "invalid argument to has() macro"},
+		"macro variable":        {`[1].exists(a.b, true)`, 11, "the first argument to exists() must be a simple name"},
+		"missing ternary else":  {`a ? b`, 5, `expected ":"`},
+		"field name":            {`a.1`, 1, `unexpected ".1", expected end of expression`},
+	}
+	for tn, tc := range invalid {
+		err := parseCelExpression(tc.expr)
+		serr, ok := err.(*celSyntaxError)
+		if !ok {
+			t.Errorf("%s: expected a syntax error, got %v", tn, err)
+			continue
+		}
+		if serr.pos != tc.pos || !strings.Contains(serr.msg, tc.msg) {
+			t.Errorf("%s: expected error containing %q at %d, got %q at %d", tn, tc.msg, tc.pos, serr.msg, serr.pos)
+		}
+	}
+}
+
+func TestFormatCelSyntaxError(t *testing.T) {
+	expr := "request.time < timestamp(\"2020-01-01T00:00:00Z\") &&\n\tresource.name == \"a\" ||"
+	expected := "line 2, column 25: unexpected end of expression, expected an expression\n" +
+		" | \tresource.name == \"a\" ||\n" +
+		" | \t.......................^"
+	if got := formatCelSyntaxError(expr, parseCelExpression(expr)); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
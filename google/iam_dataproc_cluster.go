@@ -126,6 +126,26 @@ func (u *DataprocClusterIamUpdater) SetResourceIamPolicy(policy *cloudresourcema
 	return nil
 }
 
+func (u *DataprocClusterIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewDataprocClient(userAgent).Projects.Regions.Clusters.TestIamPermissions(u.GetResourceId(),
+			&dataproc.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *DataprocClusterIamUpdater) GetResourceId() string {
 	return fmt.Sprintf("projects/%s/regions/%s/clusters/%s", u.project, u.region, u.cluster)
 }
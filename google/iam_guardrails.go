@@ -0,0 +1,206 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// iamGuardrails restricts the roles and members IAM resources may grant, as
+// configured in the provider's iam_guardrails block.
+type iamGuardrails struct {
+	deniedRoles   map[string]struct{}
+	deniedMembers map[string]struct{}
+	// allowedDomains, if set, are the only domains user, group, service
+	// account and domain members may belong to, including their subdomains.
+	allowedDomains []string
+}
+
+func expandProviderIamGuardrails(v interface{}) *iamGuardrails {
+	ls, ok := v.([]interface{})
+	if !ok || len(ls) == 0 || ls[0] == nil {
+		return nil
+	}
+	raw := ls[0].(map[string]interface{})
+
+	g := &iamGuardrails{
+		deniedRoles:   make(map[string]struct{}),
+		deniedMembers: make(map[string]struct{}),
+	}
+	for _, r := range convertStringArr(raw["denied_roles"].([]interface{})) {
+		g.deniedRoles[r] = struct{}{}
+	}
+	for _, m := range convertStringArr(raw["denied_members"].([]interface{})) {
+		g.deniedMembers[normalizeIamMemberCasing(m)] = struct{}{}
+	}
+	for _, d := range convertStringArr(raw["allowed_domains"].([]interface{})) {
+		g.allowedDomains = append(g.allowedDomains, strings.ToLower(d))
+	}
+	return g
+}
+
+// iamMemberDomain returns the domain of a user, group, service account or
+// domain member, e.g. "example.com" for "user:jane@example.com".
+func iamMemberDomain(member string) (string, bool) {
+	member = strings.TrimPrefix(member, "deleted:")
+	pieces := strings.SplitN(member, ":", 2)
+	if len(pieces) != 2 {
+		return "", false
+	}
+	switch pieces[0] {
+	case "domain":
+		return strings.ToLower(pieces[1]), true
+	case "user", "group", "serviceAccount":
+		email := pieces[1]
+		// Deleted members have a "?uid=" suffix.
+		if idx := strings.Index(email, "?"); idx >= 0 {
+			email = email[:idx]
+		}
+		if idx := strings.LastIndex(email, "@"); idx >= 0 {
+			return strings.ToLower(email[idx+1:]), true
+		}
+	}
+	return "", false
+}
+
+// checkBinding returns an error naming the binding if granting role to member
+// violates the guardrails.
+func (g *iamGuardrails) checkBinding(role, member string, condition *cloudresourcemanager.Expr) error {
+	if g == nil {
+		return nil
+	}
+
+	desc := fmt.Sprintf("binding of role %q to member %q", role, member)
+	if k := conditionKeyFromCondition(condition); !k.Empty() {
+		desc = fmt.Sprintf("%s with condition %q", desc, k.Title)
+	}
+
+	if _, ok := g.deniedRoles[role]; ok {
+		return fmt.Errorf("IAM guardrails: %s is not allowed: role %q is denied by the provider's iam_guardrails", desc, role)
+	}
+	if _, ok := g.deniedMembers[normalizeIamMemberCasing(member)]; ok {
+		return fmt.Errorf("IAM guardrails: %s is not allowed: member %q is denied by the provider's iam_guardrails", desc, member)
+	}
+	if len(g.allowedDomains) > 0 {
+		if domain, ok := iamMemberDomain(member); ok && !g.domainAllowed(domain) {
+			return fmt.Errorf("IAM guardrails: %s is not allowed: domain %q is not one of the allowed_domains %v in the provider's iam_guardrails", desc, domain, g.allowedDomains)
+		}
+	}
+	return nil
+}
+
+func (g *iamGuardrails) domainAllowed(domain string) bool {
+	for _, allowed := range g.allowedDomains {
+		if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBindings checks every member of every binding against the guardrails.
+func (g *iamGuardrails) checkBindings(bindings []*cloudresourcemanager.Binding) error {
+	if g == nil {
+		return nil
+	}
+	for _, b := range bindings {
+		for _, m := range b.Members {
+			if err := g.checkBinding(b.Role, m, b.Condition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkAddedBindings checks the members granted a role in after, but not in
+// before, against the guardrails. Members already granted a role, e.g.
+// outside of Terraform, aren't checked.
+func (g *iamGuardrails) checkAddedBindings(before, after []*cloudresourcemanager.Binding) error {
+	if g == nil {
+		return nil
+	}
+	beforeMap := createIamBindingsMap(before)
+	for key, members := range createIamBindingsMap(after) {
+		for m := range members {
+			if _, ok := beforeMap[key][m]; ok {
+				continue
+			}
+			var condition *cloudresourcemanager.Expr
+			if !key.Condition.Empty() {
+				condition = &cloudresourcemanager.Expr{
+					Title:       key.Condition.Title,
+					Description: key.Condition.Description,
+					Expression:  key.Condition.Expression,
+				}
+			}
+			if err := g.checkBinding(key.Role, m, condition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withIamGuardrails wraps a policy modifier so it fails if it grants a role
+// the guardrails don't allow.
+func withIamGuardrails(g *iamGuardrails, modify iamPolicyModifyFunc) iamPolicyModifyFunc {
+	if g == nil {
+		return modify
+	}
+	return func(p *cloudresourcemanager.Policy) error {
+		before := make([]*cloudresourcemanager.Binding, len(p.Bindings))
+		for i, b := range p.Bindings {
+			copied := *b
+			copied.Members = append([]string{}, b.Members...)
+			before[i] = &copied
+		}
+		if err := modify(p); err != nil {
+			return err
+		}
+		return g.checkAddedBindings(before, p.Bindings)
+	}
+}
+
+// iamGuardrailsCustomizeDiff checks the bindings planned by an IAM member,
+// members, binding or policy resource against the guardrails, so violations
+// fail at plan time. Values not known until apply are checked then.
+func iamGuardrailsCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	config, ok := meta.(*Config)
+	if !ok || config.IamGuardrails == nil {
+		return nil
+	}
+	g := config.IamGuardrails
+
+	if _, ok := d.GetOk("policy_data"); ok && d.NewValueKnown("policy_data") {
+		policy, err := unmarshalIamPolicy(d.Get("policy_data").(string))
+		if err != nil {
+			return err
+		}
+		return g.checkBindings(policy.Bindings)
+	}
+
+	if !d.NewValueKnown("role") || !d.NewValueKnown("condition") {
+		return nil
+	}
+	role := d.Get("role").(string)
+	var condition *cloudresourcemanager.Expr
+	if v, ok := d.GetOk("condition"); ok {
+		condition = expandIamCondition(v)
+	}
+
+	if v, ok := d.GetOk("member"); ok && d.NewValueKnown("member") {
+		return g.checkBinding(role, v.(string), condition)
+	}
+	if v, ok := d.GetOk("members"); ok && d.NewValueKnown("members") {
+		for _, m := range convertStringSet(v.(*schema.Set)) {
+			if err := g.checkBinding(role, m, condition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
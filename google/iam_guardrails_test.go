@@ -0,0 +1,216 @@
+package google
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+func testIamGuardrails() *iamGuardrails {
+	return expandProviderIamGuardrails([]interface{}{
+		map[string]interface{}{
+			"denied_roles":    []interface{}{"roles/owner", "roles/editor"},
+			"denied_members":  []interface{}{"allUsers", "allAuthenticatedUsers"},
+			"allowed_domains": []interface{}{"example.com", "iam.gserviceaccount.com"},
+		},
+	})
+}
+
+func TestIamGuardrails_checkBinding(t *testing.T) {
+	cases := map[string]struct {
+		role        string
+		member      string
+		expectedErr string
+	}{
+		"allowed": {
+			role:   "roles/viewer",
+			member: "user:jane@example.com",
+		},
+		"subdomain": {
+			role:   "roles/viewer",
+			member: "group:admins@eng.example.com",
+		},
+		"service account": {
+			role:   "roles/viewer",
+			member: "serviceAccount:sa@my-project.iam.gserviceaccount.com",
+		},
+		"denied role": {
+			role:        "roles/owner",
+			member:      "user:jane@example.com",
+			expectedErr: `binding of role "roles/owner" to member "user:jane@example.com" is not allowed: role "roles/owner" is denied`,
+		},
+		"denied member": {
+			role:        "roles/storage.objectViewer",
+			member:      "allUsers",
+			expectedErr: `member "allUsers" is denied`,
+		},
+		"other domain": {
+			role:        "roles/viewer",
+			member:      "user:jane@other.com",
+			expectedErr: `domain "other.com" is not one of the allowed_domains`,
+		},
+		"suffix of other domain": {
+			role:        "roles/viewer",
+			member:      "user:jane@notexample.com",
+			expectedErr: `domain "notexample.com"`,
+		},
+		"domain member": {
+			role:        "roles/viewer",
+			member:      "domain:other.com",
+			expectedErr: `domain "other.com"`,
+		},
+		"deleted member": {
+			role:        "roles/viewer",
+			member:      "deleted:user:jane@other.com?uid=123",
+			expectedErr: `domain "other.com"`,
+		},
+		"case insensitive": {
+			role:        "roles/viewer",
+			member:      "user:Jane@Other.com",
+			expectedErr: `domain "other.com"`,
+		},
+	}
+
+	g := testIamGuardrails()
+	for tn, tc := range cases {
+		err := g.checkBinding(tc.role, tc.member, nil)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tn, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tn, tc.expectedErr, err)
+		}
+	}
+}
+
+func TestIamGuardrails_nil(t *testing.T) {
+	var g *iamGuardrails
+	if err := g.checkBinding("roles/owner", "allUsers", nil); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if expandProviderIamGuardrails([]interface{}{}) != nil {
+		t.Errorf("expected no guardrails without an iam_guardrails block")
+	}
+}
+
+func TestIamGuardrails_checkAddedBindings(t *testing.T) {
+	g := testIamGuardrails()
+	before := []*cloudresourcemanager.Binding{
+		{Role: "roles/owner", Members: []string{"user:owner@example.com"}},
+	}
+
+	// Bindings granted before, e.g. outside of Terraform, aren't checked.
+	after := []*cloudresourcemanager.Binding{
+		{Role: "roles/owner", Members: []string{"user:owner@example.com"}},
+		{Role: "roles/viewer", Members: []string{"user:jane@example.com"}},
+	}
+	if err := g.checkAddedBindings(before, after); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	after = []*cloudresourcemanager.Binding{
+		{Role: "roles/owner", Members: []string{"user:owner@example.com", "user:jane@example.com"}},
+	}
+	if err := g.checkAddedBindings(before, after); err == nil || !strings.Contains(err.Error(), `"user:jane@example.com"`) {
+		t.Errorf("expected error naming the added member, got %v", err)
+	}
+}
+
+func TestIamGuardrails_readModifyWrite(t *testing.T) {
+	testIamShortenPolicyBackoffs(t)
+
+	u := newTestIamPolicyUpdater()
+	config := &Config{IamGuardrails: testIamGuardrails()}
+
+	err := iamPolicyReadModifyWrite(config, u, testIamAddMemberModifyFunc("roles/editor", "user:jane@example.com"))
+	if err == nil || !strings.Contains(err.Error(), `role "roles/editor" is denied`) {
+		t.Fatalf("expected guardrails error, got %v", err)
+	}
+	if u.sets != 0 {
+		t.Errorf("expected the policy not to be written, got %d writes", u.sets)
+	}
+
+	if err := iamPolicyReadModifyWrite(config, u, testIamAddMemberModifyFunc("roles/viewer", "user:jane@example.com")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestIamGuardrails_customizeDiff(t *testing.T) {
+	config := &Config{IamGuardrails: testIamGuardrails()}
+
+	cases := map[string]struct {
+		resource    string
+		raw         map[string]interface{}
+		expectedErr string
+	}{
+		"member": {
+			resource: "google_project_iam_member",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/owner",
+				"member":  "user:jane@example.com",
+			},
+			expectedErr: `binding of role "roles/owner" to member "user:jane@example.com"`,
+		},
+		"members": {
+			resource: "google_project_iam_members",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/viewer",
+				"members": []interface{}{"user:jane@example.com", "allUsers"},
+			},
+			expectedErr: `binding of role "roles/viewer" to member "allUsers"`,
+		},
+		"binding with condition": {
+			resource: "google_project_iam_binding",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/viewer",
+				"members": []interface{}{"user:jane@other.com"},
+				"condition": []interface{}{
+					map[string]interface{}{
+						"title":      "expires",
+						"expression": "request.time < timestamp(\"2020-01-01T00:00:00Z\")",
+					},
+				},
+			},
+			expectedErr: `with condition "expires"`,
+		},
+		"policy": {
+			resource: "google_project_iam_policy",
+			raw: map[string]interface{}{
+				"project":     "my-project",
+				"policy_data": `{"bindings":[{"role":"roles/viewer","members":["user:jane@example.com"]},{"role":"roles/editor","members":["user:jane@example.com"]}]}`,
+			},
+			expectedErr: `binding of role "roles/editor"`,
+		},
+		"allowed": {
+			resource: "google_project_iam_member",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/viewer",
+				"member":  "user:jane@example.com",
+			},
+		},
+	}
+
+	resources := ResourceMap()
+	for tn, tc := range cases {
+		_, err := resources[tc.resource].Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.raw), config)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tn, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tn, tc.expectedErr, err)
+		}
+	}
+}
@@ -95,6 +95,26 @@ func (u *HealthcareHl7V2StoreIamUpdater) SetResourceIamPolicy(policy *cloudresou
 	return nil
 }
 
+func (u *HealthcareHl7V2StoreIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewHealthcareClient(userAgent).Projects.Locations.Datasets.Hl7V2Stores.TestIamPermissions(u.resourceId,
+			&healthcare.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *HealthcareHl7V2StoreIamUpdater) GetResourceId() string {
 	return u.resourceId
 }
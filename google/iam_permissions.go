@@ -0,0 +1,233 @@
+package google
+
+import (
+	"fmt"
+	neturl "net/url"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// Most testIamPermissions methods accept at most 100 permissions per request.
+const maxTestIamPermissionsPerRequest = 100
+
+// ResourceIamPermissionsTester is implemented by the ResourceIamUpdaters of
+// resources whose API can test which permissions the caller holds on them.
+type ResourceIamPermissionsTester interface {
+	// Returns the subset of permissions the caller holds on the resource.
+	TestIamPermissions(permissions []string) ([]string, error)
+}
+
+// testIamPermissionsInChunks calls test with permissions, split into chunks
+// small enough for a single request, and returns the granted permissions of
+// every chunk.
+func testIamPermissionsInChunks(permissions []string, test func([]string) ([]string, error)) ([]string, error) {
+	granted := make([]string, 0, len(permissions))
+	for start := 0; start < len(permissions); start += maxTestIamPermissionsPerRequest {
+		end := start + maxTestIamPermissionsPerRequest
+		if end > len(permissions) {
+			end = len(permissions)
+		}
+		g, err := test(permissions[start:end])
+		if err != nil {
+			return nil, err
+		}
+		granted = append(granted, g...)
+	}
+	return granted, nil
+}
+
+// testIamPermissionsWithUrl tests permissions with a POST to a resource's
+// testIamPermissions URL, e.g.
+// "https://pubsub.googleapis.com/v1/projects/p/topics/t:testIamPermissions".
+func testIamPermissionsWithUrl(config *Config, url, userAgent string, permissions []string) ([]string, error) {
+	return testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		obj := map[string]interface{}{
+			"permissions": chunk,
+		}
+		res, err := sendRequest(config, "POST", "", url, userAgent, obj)
+		if err != nil {
+			return nil, err
+		}
+		return flattenTestIamPermissionsResponse(res)
+	})
+}
+
+// flattenTestIamPermissionsResponse returns the granted permissions from the
+// JSON response of a testIamPermissions method.
+func flattenTestIamPermissionsResponse(res map[string]interface{}) ([]string, error) {
+	v, ok := res["permissions"]
+	if !ok || v == nil {
+		// No permissions are granted.
+		return []string{}, nil
+	}
+	ls, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for permissions in testIamPermissions response", v)
+	}
+	return convertStringArr(ls), nil
+}
+
+// testIamPermissionsOfUpdater tests permissions with a POST to the
+// testIamPermissions URL of the resource qualifyUrl builds URLs for, and is
+// shared by the updaters of generated IAM resources below.
+func testIamPermissionsOfUpdater(u ResourceIamUpdater, d TerraformResourceData, config *Config, qualifyUrl func(string) (string, error), permissions []string) ([]string, error) {
+	url, err := qualifyUrl("testIamPermissions")
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsWithUrl(config, url, userAgent, permissions)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
+// The updaters of generated IAM resources are generated without a
+// TestIamPermissions method, so it's added to them here.
+
+func (u *BigQueryTableIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTableUrl, permissions)
+}
+
+func (u *BinaryAuthorizationAttestorIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyAttestorUrl, permissions)
+}
+
+func (u *CloudRunServiceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyServiceUrl, permissions)
+}
+
+func (u *CloudFunctionsCloudFunctionIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyCloudFunctionUrl, permissions)
+}
+
+func (u *ComputeDiskIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyDiskUrl, permissions)
+}
+
+func (u *ComputeImageIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyImageUrl, permissions)
+}
+
+func (u *ComputeInstanceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyInstanceUrl, permissions)
+}
+
+func (u *ComputeRegionDiskIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyRegionDiskUrl, permissions)
+}
+
+func (u *ComputeSubnetworkIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifySubnetworkUrl, permissions)
+}
+
+func (u *DataCatalogEntryGroupIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyEntryGroupUrl, permissions)
+}
+
+func (u *DataCatalogTagTemplateIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTagTemplateUrl, permissions)
+}
+
+func (u *HealthcareConsentStoreIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyConsentStoreUrl, permissions)
+}
+
+func (u *IapAppEngineServiceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyAppEngineServiceUrl, permissions)
+}
+
+func (u *IapAppEngineVersionIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyAppEngineVersionUrl, permissions)
+}
+
+func (u *IapTunnelIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTunnelUrl, permissions)
+}
+
+func (u *IapTunnelInstanceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTunnelInstanceUrl, permissions)
+}
+
+func (u *IapWebIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyWebUrl, permissions)
+}
+
+func (u *IapWebBackendServiceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyWebBackendServiceUrl, permissions)
+}
+
+func (u *IapWebTypeAppEngineIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyWebTypeAppEngineUrl, permissions)
+}
+
+func (u *IapWebTypeComputeIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyWebTypeComputeUrl, permissions)
+}
+
+func (u *NotebooksInstanceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyInstanceUrl, permissions)
+}
+
+func (u *PrivatecaCaPoolIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyCaPoolUrl, permissions)
+}
+
+func (u *PubsubTopicIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTopicUrl, permissions)
+}
+
+func (u *RuntimeConfigConfigIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyConfigUrl, permissions)
+}
+
+func (u *SecretManagerSecretIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifySecretUrl, permissions)
+}
+
+func (u *ServiceManagementServiceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyServiceUrl, permissions)
+}
+
+func (u *SourceRepoRepositoryIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyRepositoryUrl, permissions)
+}
+
+func (u *TagsTagKeyIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTagKeyUrl, permissions)
+}
+
+func (u *TagsTagValueIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	return testIamPermissionsOfUpdater(u, u.d, u.Config, u.qualifyTagValueUrl, permissions)
+}
+
+func (u *StorageBucketIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	url, err := u.qualifyBucketUrl("iam/testPermissions")
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cloud Storage takes the permissions to test as query parameters.
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := sendRequest(u.Config, "GET", "", url+"?"+neturl.Values{"permissions": chunk}.Encode(), userAgent, nil)
+		if err != nil {
+			return nil, err
+		}
+		return flattenTestIamPermissionsResponse(res)
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
@@ -108,6 +108,30 @@ func (u *SpannerDatabaseIamUpdater) SetResourceIamPolicy(policy *cloudresourcema
 	return nil
 }
 
+func (u *SpannerDatabaseIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewSpannerClient(userAgent).Projects.Instances.Databases.TestIamPermissions(spannerDatabaseId{
+			Project:  u.project,
+			Database: u.database,
+			Instance: u.instance,
+		}.databaseUri(),
+			&spanner.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *SpannerDatabaseIamUpdater) GetResourceId() string {
 	return spannerDatabaseId{
 		Project:  u.project,
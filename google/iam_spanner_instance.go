@@ -114,6 +114,29 @@ func (u *SpannerInstanceIamUpdater) SetResourceIamPolicy(policy *cloudresourcema
 	return nil
 }
 
+func (u *SpannerInstanceIamUpdater) TestIamPermissions(permissions []string) ([]string, error) {
+	userAgent, err := generateUserAgentString(u.d, u.Config.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := testIamPermissionsInChunks(permissions, func(chunk []string) ([]string, error) {
+		res, err := u.Config.NewSpannerClient(userAgent).Projects.Instances.TestIamPermissions(spannerInstanceId{
+			Project:  u.project,
+			Instance: u.instance,
+		}.instanceUri(),
+			&spanner.TestIamPermissionsRequest{Permissions: chunk}).Do()
+		if err != nil {
+			return nil, err
+		}
+		return res.Permissions, nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error testing IAM permissions for %s: {{err}}", u.DescribeResource()), err)
+	}
+	return granted, nil
+}
+
 func (u *SpannerInstanceIamUpdater) GetResourceId() string {
 	return spannerInstanceId{
 		Project:  u.project,
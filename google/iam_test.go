@@ -2,11 +2,16 @@ package google
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
 )
 
 func TestIamMergeBindings(t *testing.T) {
@@ -1117,3 +1122,242 @@ func debugPrintBindings(bs []*cloudresourcemanager.Binding) string {
 	v, _ := json.MarshalIndent(bs, "", "\t")
 	return string(v)
 }
+
+// testIamPolicyUpdater is a ResourceIamUpdater storing a policy in memory and
+// rejecting writes with a stale etag, like the IAM APIs.
+type testIamPolicyUpdater struct {
+	mu     sync.Mutex
+	policy *cloudresourcemanager.Policy
+	etag   int
+	sets   int
+	// beforeSet, if set, is called before each write, e.g. to simulate a
+	// concurrent change.
+	beforeSet func(u *testIamPolicyUpdater)
+	// setErr, if set, is returned by every write.
+	setErr error
+}
+
+func newTestIamPolicyUpdater() *testIamPolicyUpdater {
+	return &testIamPolicyUpdater{policy: &cloudresourcemanager.Policy{Etag: "0"}}
+}
+
+// addMember adds a member to a role in the stored policy, changing its etag.
+// Must be called with the updater's lock held.
+func (u *testIamPolicyUpdater) addMember(role, member string) {
+	u.policy.Bindings = mergeBindings(append(u.policy.Bindings, &cloudresourcemanager.Binding{
+		Role:    role,
+		Members: []string{member},
+	}))
+	u.etag++
+	u.policy.Etag = fmt.Sprintf("%d", u.etag)
+}
+
+func (u *testIamPolicyUpdater) GetResourceIamPolicy() (*cloudresourcemanager.Policy, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	b, err := json.Marshal(u.policy)
+	if err != nil {
+		return nil, err
+	}
+	p := &cloudresourcemanager.Policy{}
+	return p, json.Unmarshal(b, p)
+}
+
+func (u *testIamPolicyUpdater) SetResourceIamPolicy(policy *cloudresourcemanager.Policy) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.sets++
+	if u.beforeSet != nil {
+		u.beforeSet(u)
+	}
+	if u.setErr != nil {
+		return u.setErr
+	}
+	if policy.Etag != u.policy.Etag {
+		return &googleapi.Error{Code: 409, Message: "There were concurrent policy changes."}
+	}
+	u.etag++
+	u.policy = policy
+	u.policy.Etag = fmt.Sprintf("%d", u.etag)
+	return nil
+}
+
+func (u *testIamPolicyUpdater) GetMutexKey() string      { return "iam-test" }
+func (u *testIamPolicyUpdater) GetResourceId() string    { return "test" }
+func (u *testIamPolicyUpdater) DescribeResource() string { return "test resource" }
+
+func testIamAddMemberModifyFunc(role, member string) iamPolicyModifyFunc {
+	return func(p *cloudresourcemanager.Policy) error {
+		p.Bindings = mergeBindings(append(p.Bindings, &cloudresourcemanager.Binding{
+			Role:    role,
+			Members: []string{member},
+		}))
+		return nil
+	}
+}
+
+func testIamShortenPolicyBackoffs(t *testing.T) {
+	conflict, propagation := iamPolicyConflictInitialBackoff, iamPolicyPropagationInitialBackoff
+	iamPolicyConflictInitialBackoff, iamPolicyPropagationInitialBackoff = time.Millisecond, time.Millisecond
+	t.Cleanup(func() {
+		iamPolicyConflictInitialBackoff, iamPolicyPropagationInitialBackoff = conflict, propagation
+	})
+}
+
+func testIamPolicyMembers(p *cloudresourcemanager.Policy, role string) []string {
+	for _, b := range p.Bindings {
+		if b.Role == role {
+			return b.Members
+		}
+	}
+	return nil
+}
+
+func TestIamPolicyReadModifyWrite_retriesEtagConflicts(t *testing.T) {
+	testIamShortenPolicyBackoffs(t)
+
+	u := newTestIamPolicyUpdater()
+	concurrent := 0
+	u.beforeSet = func(u *testIamPolicyUpdater) {
+		if concurrent < 2 {
+			concurrent++
+			u.addMember("roles/viewer", fmt.Sprintf("user:concurrent%d@example.com", concurrent))
+		}
+	}
+
+	err := iamPolicyReadModifyWrite(&Config{}, u, testIamAddMemberModifyFunc("roles/viewer", "user:test@example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if u.sets != 3 {
+		t.Errorf("expected 3 writes, got %d", u.sets)
+	}
+	expected := []string{"user:concurrent1@example.com", "user:concurrent2@example.com", "user:test@example.com"}
+	if got := testIamPolicyMembers(u.policy, "roles/viewer"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected members %v, got %v", expected, got)
+	}
+}
+
+func TestIamPolicyReadModifyWrite_givesUpAfterTooManyConflicts(t *testing.T) {
+	testIamShortenPolicyBackoffs(t)
+
+	u := newTestIamPolicyUpdater()
+	u.beforeSet = func(u *testIamPolicyUpdater) {
+		u.addMember("roles/viewer", "user:concurrent@example.com")
+	}
+
+	err := iamPolicyReadModifyWrite(&Config{}, u, testIamAddMemberModifyFunc("roles/viewer", "user:test@example.com"))
+	if err == nil || !strings.Contains(err.Error(), "changed concurrently") {
+		t.Fatalf("expected error about concurrent changes, got %v", err)
+	}
+	if u.sets != maxIamPolicyConflictRetries+1 {
+		t.Errorf("expected %d writes, got %d", maxIamPolicyConflictRetries+1, u.sets)
+	}
+}
+
+func TestIamPolicyReadModifyWrite_doesNotRetryOtherErrors(t *testing.T) {
+	testIamShortenPolicyBackoffs(t)
+
+	u := newTestIamPolicyUpdater()
+	u.setErr = &googleapi.Error{Code: 400, Message: "Invalid argument"}
+
+	err := iamPolicyReadModifyWrite(&Config{}, u, testIamAddMemberModifyFunc("roles/viewer", "user:test@example.com"))
+	if err == nil || !strings.Contains(err.Error(), "Invalid argument") {
+		t.Fatalf("expected error from writing policy, got %v", err)
+	}
+	if u.sets != 1 {
+		t.Errorf("expected 1 write, got %d", u.sets)
+	}
+}
+
+func TestIamPolicyReadModifyWrite_withoutMutex(t *testing.T) {
+	testIamShortenPolicyBackoffs(t)
+
+	u := newTestIamPolicyUpdater()
+	config := &Config{IamPolicyMutexDisabled: true}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	var expected []string
+	for i := 0; i < 5; i++ {
+		member := fmt.Sprintf("user:test%d@example.com", i)
+		expected = append(expected, member)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- iamPolicyReadModifyWrite(config, u, testIamAddMemberModifyFunc("roles/viewer", member))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+	if got := testIamPolicyMembers(u.policy, "roles/viewer"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected members %v, got %v", expected, got)
+	}
+}
+
+func TestIamModifyIamMembers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []*cloudresourcemanager.Binding
+		add      []string
+		remove   []string
+		expected []*cloudresourcemanager.Binding
+	}{
+		{
+			name:   "add to missing binding",
+			input:  nil,
+			add:    []string{"user:a@example.com"},
+			remove: nil,
+			expected: []*cloudresourcemanager.Binding{
+				{Role: "role-1", Members: []string{"user:a@example.com"}},
+			},
+		},
+		{
+			name: "keeps other members",
+			input: []*cloudresourcemanager.Binding{
+				{Role: "role-1", Members: []string{"user:other@example.com", "user:old@example.com"}},
+				{Role: "role-2", Members: []string{"user:old@example.com"}},
+			},
+			add:    []string{"user:a@example.com"},
+			remove: []string{"user:old@example.com"},
+			expected: []*cloudresourcemanager.Binding{
+				{Role: "role-1", Members: []string{"user:a@example.com", "user:other@example.com"}},
+				{Role: "role-2", Members: []string{"user:old@example.com"}},
+			},
+		},
+		{
+			name: "remove last member",
+			input: []*cloudresourcemanager.Binding{
+				{Role: "role-1", Members: []string{"user:A@example.com"}},
+			},
+			add:      nil,
+			remove:   []string{"user:a@example.com"},
+			expected: []*cloudresourcemanager.Binding{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &cloudresourcemanager.Policy{Bindings: tc.input}
+			add := &cloudresourcemanager.Binding{Role: "role-1", Members: tc.add}
+			remove := &cloudresourcemanager.Binding{Role: "role-1", Members: tc.remove}
+			if err := modifyIamMembers(add, remove)(p); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !compareBindings(p.Bindings, tc.expected) {
+				t.Errorf("expected bindings %s, got %s", debugPrintBindings(tc.expected), debugPrintBindings(p.Bindings))
+			}
+			if p.Version != iamPolicyVersion {
+				t.Errorf("expected policy version %d, got %d", iamPolicyVersion, p.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package google
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed iam_predefined_roles.txt
+var iamPredefinedRolesList string
+
+// iamPredefinedRoles are the names of the predefined roles, sorted.
+var iamPredefinedRoles = parseIamPredefinedRoles(iamPredefinedRolesList)
+
+var (
+	iamPredefinedRoleRegexp = regexp.MustCompile(`^roles/[a-zA-Z0-9_.]+$`)
+	iamCustomRoleRegexp     = regexp.MustCompile(`^(projects|organizations)/[^/]+/roles/[a-zA-Z0-9_.]{3,64}$`)
+)
+
+// A role this close to a predefined role, but not one, is likely a typo.
+const maxIamRoleTypoDistance = 2
+
+func parseIamPredefinedRoles(list string) []string {
+	var roles []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		roles = append(roles, line)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func isIamPredefinedRole(role string) bool {
+	i := sort.SearchStrings(iamPredefinedRoles, role)
+	return i < len(iamPredefinedRoles) && iamPredefinedRoles[i] == role
+}
+
+// validateIamRole checks a role is a predefined role, or a custom role of a
+// project or organization. Predefined roles that aren't in the catalogue are
+// only a warning, as the catalogue may not include recently added roles; the
+// warning suggests the closest role in the catalogue, if the role is likely a
+// typo of it.
+func validateIamRole(v interface{}, k string) (warnings []string, errors []error) {
+	role := v.(string)
+	if iamCustomRoleRegexp.MatchString(role) {
+		return
+	}
+	if !iamPredefinedRoleRegexp.MatchString(role) {
+		errors = append(errors, fmt.Errorf("%q (%q) isn't a valid role, expected roles/{role} for a predefined role, or "+
+			"projects/{project}/roles/{role} or organizations/{org_id}/roles/{role} for a custom role", k, role))
+		return
+	}
+	if isIamPredefinedRole(role) {
+		return
+	}
+
+	if suggestion := closestIamPredefinedRole(role); suggestion != "" {
+		warnings = append(warnings, fmt.Sprintf("%q (%q) isn't a predefined role known to the provider, did you mean %q?", k, role, suggestion))
+		return
+	}
+	warnings = append(warnings, fmt.Sprintf("%q (%q) isn't a predefined role known to the provider, check it's spelled correctly", k, role))
+	return
+}
+
+// closestIamPredefinedRole returns the predefined role closest to role, if
+// any is close enough for role to likely be a typo of it.
+func closestIamPredefinedRole(role string) string {
+	closest, closestDistance := "", maxIamRoleTypoDistance+1
+	for _, r := range iamPredefinedRoles {
+		if d := len(r) - len(role); d > maxIamRoleTypoDistance || -d > maxIamRoleTypoDistance {
+			continue
+		}
+		if d := levenshteinDistance(role, r); d < closestDistance {
+			closest, closestDistance = r, d
+		}
+	}
+	return closest
+}
+
+// levenshteinDistance returns the number of single byte insertions, deletions
+// and substitutions needed to turn a into b.
+func levenshteinDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cur[j] = prev[j-1]
+			if a[i-1] != b[j-1] {
+				cur[j]++
+			}
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// validateIamConditionExpression checks the syntax of an IAM condition
+// expression, and reports errors with their position in the expression.
+func validateIamConditionExpression(v interface{}, k string) (warnings []string, errors []error) {
+	expr := v.(string)
+	if err := parseCelExpression(expr); err != nil {
+		errors = append(errors, fmt.Errorf("%q isn't a valid CEL expression: %s", k, formatCelSyntaxError(expr, err)))
+	}
+	return
+}
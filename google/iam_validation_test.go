@@ -0,0 +1,172 @@
+package google
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidateIamRole(t *testing.T) {
+	cases := map[string]struct {
+		role            string
+		expectedError   string
+		expectedWarning string
+	}{
+		"basic role": {
+			role: "roles/owner",
+		},
+		"predefined role": {
+			role: "roles/storage.objectViewer",
+		},
+		"project custom role": {
+			role: "projects/my-project/roles/myCustomRole",
+		},
+		"organization custom role": {
+			role: "organizations/123456789/roles/my_custom.role",
+		},
+		"typo": {
+			role:            "roles/storage.objectViwer",
+			expectedWarning: `isn't a predefined role known to the provider, did you mean "roles/storage.objectViewer"?`,
+		},
+		"wrong case": {
+			role:            "roles/Owner",
+			expectedWarning: `did you mean "roles/owner"?`,
+		},
+		"role close to another missing from the catalogue": {
+			role:            "roles/ids.admin",
+			expectedWarning: `did you mean "roles/dns.admin"?`,
+		},
+		"unknown role": {
+			role:            "roles/someNewService.someNewRole",
+			expectedWarning: "isn't a predefined role known to the provider",
+		},
+		"missing prefix": {
+			role:          "storage.objectViewer",
+			expectedError: "isn't a valid role",
+		},
+		"invalid characters": {
+			role:          "roles/storage-objectViewer",
+			expectedError: "isn't a valid role",
+		},
+		"short custom role": {
+			role:          "projects/my-project/roles/ab",
+			expectedError: "isn't a valid role",
+		},
+	}
+
+	for tn, tc := range cases {
+		ws, es := validateIamRole(tc.role, "role")
+		if tc.expectedError == "" && len(es) > 0 {
+			t.Errorf("%s: unexpected errors: %v", tn, es)
+		}
+		if tc.expectedError != "" && (len(es) != 1 || !strings.Contains(es[0].Error(), tc.expectedError)) {
+			t.Errorf("%s: expected an error containing %q, got %v", tn, tc.expectedError, es)
+		}
+		if tc.expectedWarning == "" && len(ws) > 0 {
+			t.Errorf("%s: unexpected warnings: %v", tn, ws)
+		}
+		if tc.expectedWarning != "" && (len(ws) != 1 || !strings.Contains(ws[0], tc.expectedWarning)) {
+			t.Errorf("%s: expected a warning containing %q, got %v", tn, tc.expectedWarning, ws)
+		}
+	}
+}
+
+func TestIamPredefinedRoles(t *testing.T) {
+	if len(iamPredefinedRoles) == 0 {
+		t.Fatalf("expected predefined roles to be embedded")
+	}
+	for i, r := range iamPredefinedRoles {
+		if !iamPredefinedRoleRegexp.MatchString(r) {
+			t.Errorf("predefined role %q isn't a valid role", r)
+		}
+		if i > 0 && iamPredefinedRoles[i-1] == r {
+			t.Errorf("predefined role %q is listed more than once", r)
+		}
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"objectViewer", "objectViwer", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tc := range cases {
+		if got := levenshteinDistance(tc.a, tc.b); got != tc.expected {
+			t.Errorf("expected distance between %q and %q to be %d, got %d", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
+
+func TestIamValidation_plan(t *testing.T) {
+	cases := map[string]struct {
+		resource    string
+		raw         map[string]interface{}
+		expectedErr string
+	}{
+		"member role typo": {
+			resource: "google_project_iam_member",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/storage.objectViwer",
+				"member":  "user:jane@example.com",
+			},
+			expectedErr: `did you mean "roles/storage.objectViewer"?`,
+		},
+		"binding condition syntax error": {
+			resource: "google_storage_bucket_iam_binding",
+			raw: map[string]interface{}{
+				"bucket":  "my-bucket",
+				"role":    "roles/storage.objectViewer",
+				"members": []interface{}{"user:jane@example.com"},
+				"condition": []interface{}{
+					map[string]interface{}{
+						"title":      "expires",
+						"expression": `request.time < timestamp("2020-01-01T00:00:00Z"`,
+					},
+				},
+			},
+			expectedErr: `line 1, column 48: unexpected end of expression, expected "," or ")"`,
+		},
+		"valid": {
+			resource: "google_project_iam_member",
+			raw: map[string]interface{}{
+				"project": "my-project",
+				"role":    "roles/viewer",
+				"member":  "user:jane@example.com",
+				"condition": []interface{}{
+					map[string]interface{}{
+						"title":      "expires",
+						"expression": `request.time < timestamp("2020-01-01T00:00:00Z")`,
+					},
+				},
+			},
+		},
+	}
+
+	provider := Provider()
+	for tn, tc := range cases {
+		diags := provider.ResourcesMap[tc.resource].Validate(terraform.NewResourceConfigRaw(tc.raw))
+		if tc.expectedErr == "" {
+			if diags.HasError() {
+				t.Errorf("%s: unexpected errors: %v", tn, diags)
+			}
+			continue
+		}
+
+		found := false
+		for _, d := range diags {
+			if strings.Contains(d.Summary, tc.expectedErr) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected an error containing %q, got %v", tn, tc.expectedErr, diags)
+		}
+	}
+}
@@ -70,7 +70,18 @@ func parseImportId(idRegexes []string, d TerraformResourceData, config *Config)
 			return nil
 		}
 	}
-	return fmt.Errorf("Import id %q doesn't match any of the accepted formats: %v", d.Id(), idRegexes)
+	return &importIdFormatError{id: d.Id(), idRegexes: idRegexes}
+}
+
+// importIdFormatError is returned by parseImportId for an import id matching
+// none of the accepted formats.
+type importIdFormatError struct {
+	id        string
+	idRegexes []string
+}
+
+func (e *importIdFormatError) Error() string {
+	return fmt.Sprintf("Import id %q doesn't match any of the accepted formats: %v", e.id, e.idRegexes)
 }
 
 func setDefaultValues(idRegex string, d TerraformResourceData, config *Config) error {
@@ -0,0 +1,197 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// terraformLabelsKey is the computed field holding the labels Terraform manages
+// on a resource: the provider's default_labels merged with the resource's
+// own labels.
+const terraformLabelsKey = "terraform_labels"
+
+// hasConfigurableLabels reports whether a resource has a top-level, user-set
+// "labels" map that default_labels can be merged into.
+func hasConfigurableLabels(r *schema.Resource) bool {
+	s, ok := r.Schema["labels"]
+	if !ok {
+		return false
+	}
+	return s.Type == schema.TypeMap && s.Optional && !s.Computed
+}
+
+// addDefaultLabelsSupport wires the provider-level default_labels into every
+// resource with a configurable "labels" map. The resource's own CRUD functions
+// keep reading "labels"; the wrappers below make that field hold the merged
+// labels while the resource talks to the API, and only the user-set labels
+// the rest of the time.
+func addDefaultLabelsSupport(resources map[string]*schema.Resource) {
+	for _, r := range resources {
+		if !hasConfigurableLabels(r) {
+			continue
+		}
+		if _, ok := r.Schema[terraformLabelsKey]; ok {
+			continue
+		}
+
+		// Resources that can't update their labels are replaced when the
+		// default labels change, like when their own labels change.
+		r.Schema[terraformLabelsKey] = &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			ForceNew:    r.Schema["labels"].ForceNew,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: `The combination of labels configured directly on the resource and default labels configured on the provider.`,
+		}
+
+		if r.CustomizeDiff != nil {
+			r.CustomizeDiff = customdiff.All(r.CustomizeDiff, setDefaultLabelsDiff)
+		} else {
+			r.CustomizeDiff = setDefaultLabelsDiff
+		}
+
+		if r.Create != nil {
+			r.Create = schema.CreateFunc(wrapWriteWithDefaultLabels(r.Create))
+		}
+		if r.Update != nil {
+			r.Update = schema.UpdateFunc(wrapWriteWithDefaultLabels(r.Update))
+		}
+		if r.Read != nil {
+			r.Read = wrapReadWithDefaultLabels(r.Read)
+		}
+	}
+}
+
+// labelsChanged reports whether an update needs to write the labels: either
+// the resource's own labels or the provider's default_labels merged into them
+// changed. Resources check it rather than d.HasChange("labels"), which misses
+// a change to the default labels alone.
+func labelsChanged(d TerraformResourceData) bool {
+	return d.HasChange("labels") || d.HasChange(terraformLabelsKey)
+}
+
+// mergeDefaultLabels returns the provider's default labels overlaid with the
+// resource's labels. Resource-level keys take priority.
+func mergeDefaultLabels(defaults map[string]string, labels map[string]interface{}) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(labels))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v.(string)
+	}
+	return merged
+}
+
+// splitDefaultLabels partitions the labels read from the API. userLabels
+// holds the keys configured on the resource itself.
+//
+// The returned labels drop default labels the user did not set on the
+// resource, so they don't show up as drift against the resource config.
+// Labels that are neither default nor user-set are kept so that labels added
+// outside of Terraform are still reported. terraformLabels holds every label
+// Terraform manages, i.e. the user-set and default keys.
+func splitDefaultLabels(defaults map[string]string, userLabels, apiLabels map[string]interface{}) (labels, terraformLabels map[string]interface{}) {
+	labels = make(map[string]interface{})
+	terraformLabels = make(map[string]interface{})
+	for k, v := range apiLabels {
+		_, isUser := userLabels[k]
+		_, isDefault := defaults[k]
+		if isUser || !isDefault {
+			labels[k] = v
+		}
+		if isUser || isDefault {
+			terraformLabels[k] = v
+		}
+	}
+	return labels, terraformLabels
+}
+
+// setDefaultLabelsDiff plans terraform_labels as the merge of the provider
+// default_labels and the resource's labels, so that adding, changing or
+// removing a default label shows up as a change to the resource.
+func setDefaultLabelsDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	config, ok := meta.(*Config)
+	if !ok {
+		return nil
+	}
+	// Existing resources may still have default labels that were removed
+	// from the provider.
+	if len(config.DefaultLabels) == 0 && d.Id() == "" {
+		return nil
+	}
+
+	if !d.NewValueKnown("labels") {
+		return d.SetNewComputed(terraformLabelsKey)
+	}
+
+	merged := mergeDefaultLabels(config.DefaultLabels, d.Get("labels").(map[string]interface{}))
+	if d.Id() != "" {
+		old := convertStringMap(d.Get(terraformLabelsKey).(map[string]interface{}))
+		if reflect.DeepEqual(old, merged) {
+			return nil
+		}
+	}
+
+	return d.SetNew(terraformLabelsKey, merged)
+}
+
+// setDefaultLabelsState splits the labels the resource read back from the API
+// into the user-set "labels" and the Terraform-managed "terraform_labels".
+func setDefaultLabelsState(d *schema.ResourceData, config *Config, userLabels map[string]interface{}) error {
+	labels, terraformLabels := splitDefaultLabels(config.DefaultLabels, userLabels, d.Get("labels").(map[string]interface{}))
+	if err := d.Set("labels", labels); err != nil {
+		return fmt.Errorf("Error setting labels: %s", err)
+	}
+	if err := d.Set(terraformLabelsKey, terraformLabels); err != nil {
+		return fmt.Errorf("Error setting %s: %s", terraformLabelsKey, err)
+	}
+	return nil
+}
+
+func wrapWriteWithDefaultLabels(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		config, ok := meta.(*Config)
+		if !ok || len(config.DefaultLabels) == 0 {
+			return f(d, meta)
+		}
+
+		userLabels := d.Get("labels").(map[string]interface{})
+		if err := d.Set("labels", mergeDefaultLabels(config.DefaultLabels, userLabels)); err != nil {
+			return fmt.Errorf("Error setting labels: %s", err)
+		}
+
+		err := f(d, meta)
+		if d.Id() == "" {
+			return err
+		}
+		if serr := setDefaultLabelsState(d, config, userLabels); serr != nil && err == nil {
+			return serr
+		}
+		return err
+	}
+}
+
+func wrapReadWithDefaultLabels(f schema.ReadFunc) schema.ReadFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		config, ok := meta.(*Config)
+		if !ok {
+			return f(d, meta)
+		}
+
+		// Before the read, "labels" only holds the keys set on the resource
+		// itself (or nothing, on import).
+		userLabels := d.Get("labels").(map[string]interface{})
+		if err := f(d, meta); err != nil {
+			return err
+		}
+		if d.Id() == "" {
+			return nil
+		}
+		return setDefaultLabelsState(d, config, userLabels)
+	}
+}
@@ -0,0 +1,238 @@
+package google
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestMergeDefaultLabels(t *testing.T) {
+	cases := map[string]struct {
+		Defaults map[string]string
+		Labels   map[string]interface{}
+		Expected map[string]string
+	}{
+		"no defaults": {
+			Defaults: nil,
+			Labels:   map[string]interface{}{"env": "prod"},
+			Expected: map[string]string{"env": "prod"},
+		},
+		"no labels": {
+			Defaults: map[string]string{"team": "infra"},
+			Labels:   map[string]interface{}{},
+			Expected: map[string]string{"team": "infra"},
+		},
+		"disjoint keys": {
+			Defaults: map[string]string{"team": "infra"},
+			Labels:   map[string]interface{}{"env": "prod"},
+			Expected: map[string]string{"team": "infra", "env": "prod"},
+		},
+		"resource label takes priority": {
+			Defaults: map[string]string{"team": "infra", "env": "dev"},
+			Labels:   map[string]interface{}{"env": "prod"},
+			Expected: map[string]string{"team": "infra", "env": "prod"},
+		},
+	}
+
+	for tn, tc := range cases {
+		if got := mergeDefaultLabels(tc.Defaults, tc.Labels); !reflect.DeepEqual(got, tc.Expected) {
+			t.Errorf("%s: expected %v, got %v", tn, tc.Expected, got)
+		}
+	}
+}
+
+func TestSplitDefaultLabels(t *testing.T) {
+	cases := map[string]struct {
+		Defaults        map[string]string
+		UserLabels      map[string]interface{}
+		ApiLabels       map[string]interface{}
+		Labels          map[string]interface{}
+		TerraformLabels map[string]interface{}
+	}{
+		"no defaults": {
+			UserLabels:      map[string]interface{}{"env": "prod"},
+			ApiLabels:       map[string]interface{}{"env": "prod", "other": "x"},
+			Labels:          map[string]interface{}{"env": "prod", "other": "x"},
+			TerraformLabels: map[string]interface{}{"env": "prod"},
+		},
+		"default label hidden from labels": {
+			Defaults:        map[string]string{"team": "infra"},
+			UserLabels:      map[string]interface{}{"env": "prod"},
+			ApiLabels:       map[string]interface{}{"env": "prod", "team": "infra"},
+			Labels:          map[string]interface{}{"env": "prod"},
+			TerraformLabels: map[string]interface{}{"env": "prod", "team": "infra"},
+		},
+		"default label overridden on resource": {
+			Defaults:        map[string]string{"env": "dev"},
+			UserLabels:      map[string]interface{}{"env": "prod"},
+			ApiLabels:       map[string]interface{}{"env": "prod"},
+			Labels:          map[string]interface{}{"env": "prod"},
+			TerraformLabels: map[string]interface{}{"env": "prod"},
+		},
+		"stale default label value": {
+			Defaults:        map[string]string{"team": "infra"},
+			UserLabels:      map[string]interface{}{},
+			ApiLabels:       map[string]interface{}{"team": "old"},
+			Labels:          map[string]interface{}{},
+			TerraformLabels: map[string]interface{}{"team": "old"},
+		},
+		"unmanaged label kept": {
+			Defaults:        map[string]string{"team": "infra"},
+			UserLabels:      map[string]interface{}{},
+			ApiLabels:       map[string]interface{}{"team": "infra", "added-by-hand": "yes"},
+			Labels:          map[string]interface{}{"added-by-hand": "yes"},
+			TerraformLabels: map[string]interface{}{"team": "infra"},
+		},
+	}
+
+	for tn, tc := range cases {
+		labels, terraformLabels := splitDefaultLabels(tc.Defaults, tc.UserLabels, tc.ApiLabels)
+		if !reflect.DeepEqual(labels, tc.Labels) {
+			t.Errorf("%s: expected labels %v, got %v", tn, tc.Labels, labels)
+		}
+		if !reflect.DeepEqual(terraformLabels, tc.TerraformLabels) {
+			t.Errorf("%s: expected terraform_labels %v, got %v", tn, tc.TerraformLabels, terraformLabels)
+		}
+	}
+}
+
+func TestAddDefaultLabelsSupport(t *testing.T) {
+	var sentLabels map[string]string
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"labels": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			sentLabels = expandLabels(d)
+			d.SetId(d.Get("name").(string))
+			// Simulate reading back what the API stored.
+			return d.Set("labels", sentLabels)
+		},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			return nil
+		},
+		Delete: func(d *schema.ResourceData, meta interface{}) error {
+			return nil
+		},
+	}
+	addDefaultLabelsSupport(map[string]*schema.Resource{"test": r})
+
+	if _, ok := r.Schema[terraformLabelsKey]; !ok {
+		t.Fatalf("expected %s to be added to the schema", terraformLabelsKey)
+	}
+
+	config := &Config{DefaultLabels: map[string]string{"team": "infra", "env": "dev"}}
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":   "foo",
+		"labels": map[string]interface{}{"env": "prod"},
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSent := map[string]string{"team": "infra", "env": "prod"}
+	if !reflect.DeepEqual(sentLabels, expectedSent) {
+		t.Errorf("expected the API to receive %v, got %v", expectedSent, sentLabels)
+	}
+	if got, expected := expandLabels(d), map[string]string{"env": "prod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected labels %v, got %v", expected, got)
+	}
+	if got := expandStringMap(d, terraformLabelsKey); !reflect.DeepEqual(got, expectedSent) {
+		t.Errorf("expected %s %v, got %v", terraformLabelsKey, expectedSent, got)
+	}
+}
+
+func TestFakeGoogleApi_defaultLabelsUpdate(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	config.DefaultLabels = map[string]string{"team": "infra", "env": "dev"}
+	raw := map[string]interface{}{
+		"name":     "my-bucket",
+		"location": "US",
+		"labels":   map[string]interface{}{"app": "web"},
+	}
+	state := testFakeApiApply(t, config, "google_storage_bucket", nil, raw)
+
+	// Only the provider's default labels change, so the plan only changes
+	// terraform_labels, and the labels must still be written.
+	config.DefaultLabels = map[string]string{"team": "platform"}
+	state = testFakeApiApply(t, config, "google_storage_bucket", state, raw)
+
+	expected := map[string]interface{}{"app": "web", "team": "platform"}
+	bucket, _ := api.get("storage/v1/b/my-bucket")
+	if got := bucket["labels"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the bucket's labels to be %v, got %v", expected, got)
+	}
+	if got := state.Attributes["terraform_labels.team"]; got != "platform" {
+		t.Errorf("expected terraform_labels.team to be platform, got %q", got)
+	}
+	if _, ok := state.Attributes["terraform_labels.env"]; ok {
+		t.Errorf("expected the removed default label to be removed from terraform_labels")
+	}
+
+	// Generated resources write their labels with an update mask.
+	config.DefaultLabels = map[string]string{"team": "infra", "env": "dev"}
+	topicConfig := map[string]interface{}{
+		"name":   "my-topic",
+		"labels": map[string]interface{}{"app": "web"},
+	}
+	topic := testFakeApiApply(t, config, "google_pubsub_topic", nil, topicConfig)
+	config.DefaultLabels = map[string]string{"team": "platform"}
+	testFakeApiApply(t, config, "google_pubsub_topic", topic, topicConfig)
+
+	obj, _ := api.get("pubsub/v1/projects/fake-project/topics/my-topic")
+	if got := obj["labels"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the topic's labels to be %v, got %v", expected, got)
+	}
+}
+
+func TestDefaultLabelsDiff_forceNewLabels(t *testing.T) {
+	r := Provider().ResourcesMap["google_ml_engine_model"]
+	if !r.Schema[terraformLabelsKey].ForceNew {
+		t.Errorf("expected %s to be ForceNew like labels", terraformLabelsKey)
+	}
+
+	raw := map[string]interface{}{
+		"name":   "my_model",
+		"labels": map[string]interface{}{"a": "b"},
+	}
+	d := r.Data(nil)
+	d.SetId("projects/fake-project/models/my_model")
+	for k, v := range map[string]interface{}{
+		"name":             "my_model",
+		"project":          "fake-project",
+		"labels":           raw["labels"],
+		terraformLabelsKey: raw["labels"],
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The model can't be updated, so changing the default labels replaces it.
+	config := &Config{Project: "fake-project", DefaultLabels: map[string]string{"team": "x"}}
+	diff, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || !diff.RequiresNew() {
+		t.Fatalf("expected changing the default labels to replace the model, got %v", diff)
+	}
+	if attr := diff.Attributes[terraformLabelsKey+".team"]; attr == nil || attr.New != "x" {
+		t.Errorf("expected %s.team to be planned as x, got %v", terraformLabelsKey, attr)
+	}
+}
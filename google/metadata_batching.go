@@ -0,0 +1,218 @@
+package google
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	batchKeyTmplComputeSetCommonInstanceMetadata = "compute:projects/%s/setCommonInstanceMetadata"
+	batchKeyTmplComputeSetInstanceMetadata       = "compute:projects/%s/zones/%s/instances/%s/setMetadata"
+)
+
+// metadataChange is a single change to a set of metadata. Changes are applied
+// in order, on top of the metadata read from the API just before writing, so
+// concurrent changes to different keys can be sent in one update.
+type metadataChange struct {
+	// replace, if set, replaces all existing metadata with its items. key,
+	// value and failIfPresent are ignored.
+	replace map[string]interface{}
+
+	// key is the metadata key to change. It is set to value, or deleted if
+	// value is nil.
+	key   string
+	value *string
+	// failIfPresent makes the change fail if key already has a value.
+	failIfPresent metadataPresentBehavior
+}
+
+func (c metadataChange) String() string {
+	switch {
+	case c.replace != nil:
+		return fmt.Sprintf("replace all (%d keys)", len(c.replace))
+	case c.value == nil:
+		return fmt.Sprintf("delete %q", c.key)
+	default:
+		return fmt.Sprintf("set %q", c.key)
+	}
+}
+
+// applyMetadataChanges applies changes to md in place. It returns whether md
+// was changed, or an error if a change requires a key to be absent but it is
+// present.
+func applyMetadataChanges(md map[string]interface{}, changes []metadataChange, resourceDesc string) (bool, error) {
+	before := make(map[string]interface{}, len(md))
+	for k, v := range md {
+		before[k] = v
+	}
+
+	for _, c := range changes {
+		if c.replace != nil {
+			for k := range md {
+				delete(md, k)
+			}
+			for k, v := range c.replace {
+				md[k] = v
+			}
+			continue
+		}
+
+		if _, ok := md[c.key]; ok && bool(c.failIfPresent) {
+			return false, fmt.Errorf("key %q already present in metadata for %s. Use `terraform import` to manage it with Terraform", c.key, resourceDesc)
+		}
+		if c.value == nil {
+			delete(md, c.key)
+		} else {
+			md[c.key] = *c.value
+		}
+	}
+
+	return !reflect.DeepEqual(before, md), nil
+}
+
+func combineMetadataChanges(currV interface{}, toAddV interface{}) (interface{}, error) {
+	currChanges, ok := currV.([]metadataChange)
+	if !ok {
+		return nil, fmt.Errorf("provider error in batch combiner: expected data to be type []metadataChange, got %v with type %T", currV, currV)
+	}
+
+	newChanges, ok := toAddV.([]metadataChange)
+	if !ok {
+		return nil, fmt.Errorf("provider error in batch combiner: expected data to be type []metadataChange, got %v with type %T", toAddV, toAddV)
+	}
+
+	return append(currChanges, newChanges...), nil
+}
+
+// BatchRequestProjectMetadataChange batches a change to a project's common
+// instance metadata with concurrent changes to the same project, i.e. to batch
+// several google_compute_project_metadata_item resources into one
+// fingerprinted update.
+func BatchRequestProjectMetadataChange(config *Config, projectID, userAgent string, change metadataChange, timeout time.Duration, reqDesc string) error {
+	req := &BatchRequest{
+		ResourceName: projectID,
+		Body:         []metadataChange{change},
+		CombineF:     combineMetadataChanges,
+		SendF:        sendBatchFuncSetCommonInstanceMetadata(config, userAgent, timeout),
+		DebugId:      reqDesc,
+	}
+
+	_, err := config.requestBatcherComputeMetadata.SendRequestWithTimeout(
+		fmt.Sprintf(batchKeyTmplComputeSetCommonInstanceMetadata, projectID),
+		req,
+		timeout)
+	return err
+}
+
+func sendBatchFuncSetCommonInstanceMetadata(config *Config, userAgent string, timeout time.Duration) BatcherSendFunc {
+	return func(projectID string, body interface{}) (interface{}, error) {
+		changes, ok := body.([]metadataChange)
+		if !ok {
+			return nil, fmt.Errorf("provider error: expected data to be type []metadataChange, got %v with type %T", body, body)
+		}
+
+		return nil, MetadataRetryWrapper(func() error {
+			lockName := fmt.Sprintf("projects/%s/commoninstancemetadata", projectID)
+			mutexKV.Lock(lockName)
+			defer mutexKV.Unlock(lockName)
+
+			log.Printf("[DEBUG] Loading project metadata: %s", projectID)
+			project, err := config.NewComputeClient(userAgent).Projects.Get(projectID).Do()
+			if err != nil {
+				return fmt.Errorf("Error loading project '%s': %s", projectID, err)
+			}
+
+			md := flattenMetadata(project.CommonInstanceMetadata)
+			changed, err := applyMetadataChanges(md, changes, fmt.Sprintf("project %q", projectID))
+			if err != nil {
+				return err
+			}
+			if !changed {
+				// The metadata is already as requested - we're done.
+				return nil
+			}
+
+			log.Printf("[DEBUG] Applying %d metadata changes to project %s: %v", len(changes), projectID, changes)
+			op, err := config.NewComputeClient(userAgent).Projects.SetCommonInstanceMetadata(
+				projectID,
+				&compute.Metadata{
+					Fingerprint: project.CommonInstanceMetadata.Fingerprint,
+					Items:       expandComputeMetadata(md),
+				},
+			).Do()
+			if err != nil {
+				return err
+			}
+
+			log.Printf("[DEBUG] SetCommonInstanceMetadata: %d (%s)", op.Id, op.SelfLink)
+
+			return computeOperationWaitTime(config, op, project.Name, "SetCommonInstanceMetadata", userAgent, timeout)
+		})
+	}
+}
+
+// BatchRequestInstanceMetadataChange batches a change to an instance's
+// metadata with concurrent changes to the same instance, so they are written
+// in one fingerprinted update.
+func BatchRequestInstanceMetadataChange(config *Config, project, zone, instance, userAgent string, change metadataChange, timeout time.Duration, reqDesc string) error {
+	req := &BatchRequest{
+		ResourceName: fmt.Sprintf("projects/%s/zones/%s/instances/%s", project, zone, instance),
+		Body:         []metadataChange{change},
+		CombineF:     combineMetadataChanges,
+		SendF:        sendBatchFuncSetInstanceMetadata(config, project, zone, instance, userAgent, timeout),
+		DebugId:      reqDesc,
+	}
+
+	_, err := config.requestBatcherComputeMetadata.SendRequestWithTimeout(
+		fmt.Sprintf(batchKeyTmplComputeSetInstanceMetadata, project, zone, instance),
+		req,
+		timeout)
+	return err
+}
+
+func sendBatchFuncSetInstanceMetadata(config *Config, project, zone, name, userAgent string, timeout time.Duration) BatcherSendFunc {
+	return func(resourceName string, body interface{}) (interface{}, error) {
+		changes, ok := body.([]metadataChange)
+		if !ok {
+			return nil, fmt.Errorf("provider error: expected data to be type []metadataChange, got %v with type %T", body, body)
+		}
+
+		return nil, MetadataRetryWrapper(func() error {
+			// Retrieve up-to-date metadata from the API, as instances sometimes but
+			// not always share metadata fingerprints.
+			instance, err := config.NewComputeClient(userAgent).Instances.Get(project, zone, name).Do()
+			if err != nil {
+				return fmt.Errorf("Error retrieving metadata: %s", err)
+			}
+
+			current := instance.Metadata
+			if current == nil {
+				current = &compute.Metadata{}
+			}
+			md := flattenMetadata(current)
+			changed, err := applyMetadataChanges(md, changes, resourceName)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				return nil
+			}
+
+			log.Printf("[DEBUG] Applying %d metadata changes to %s: %v", len(changes), resourceName, changes)
+			op, err := config.NewComputeClient(userAgent).Instances.SetMetadata(project, zone, name, &compute.Metadata{
+				Fingerprint: current.Fingerprint,
+				Items:       expandComputeMetadata(md),
+			}).Do()
+			if err != nil {
+				// Returned unwrapped so fingerprint mismatches are retried.
+				return err
+			}
+
+			return computeOperationWaitTime(config, op, project, "metadata to update", userAgent, timeout)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package google
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyMetadataChanges(t *testing.T) {
+	foo, bar := "foo", "bar"
+	cases := map[string]struct {
+		Metadata        map[string]interface{}
+		Changes         []metadataChange
+		Expected        map[string]interface{}
+		ExpectedChanged bool
+		ExpectError     bool
+	}{
+		"set and delete keys": {
+			Metadata: map[string]interface{}{"a": "1", "b": "2"},
+			Changes: []metadataChange{
+				{key: "a", value: &foo},
+				{key: "b"},
+				{key: "c", value: &bar, failIfPresent: failIfPresent},
+			},
+			Expected:        map[string]interface{}{"a": "foo", "c": "bar"},
+			ExpectedChanged: true,
+		},
+		"already set": {
+			Metadata: map[string]interface{}{"a": "foo"},
+			Changes: []metadataChange{
+				{key: "a", value: &foo},
+				{key: "b"},
+			},
+			Expected: map[string]interface{}{"a": "foo"},
+		},
+		"replace then set": {
+			Metadata: map[string]interface{}{"a": "1", "b": "2"},
+			Changes: []metadataChange{
+				{replace: map[string]interface{}{"b": "3"}},
+				{key: "c", value: &bar},
+			},
+			Expected:        map[string]interface{}{"b": "3", "c": "bar"},
+			ExpectedChanged: true,
+		},
+		"fail if present": {
+			Metadata: map[string]interface{}{"a": "foo"},
+			Changes: []metadataChange{
+				{key: "a", value: &foo, failIfPresent: failIfPresent},
+			},
+			ExpectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		changed, err := applyMetadataChanges(tc.Metadata, tc.Changes, "test")
+		if tc.ExpectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", tn)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tn, err)
+			continue
+		}
+		if changed != tc.ExpectedChanged {
+			t.Errorf("%s: expected changed to be %t, got %t", tn, tc.ExpectedChanged, changed)
+		}
+		if !reflect.DeepEqual(tc.Metadata, tc.Expected) {
+			t.Errorf("%s: expected %v, got %v", tn, tc.Expected, tc.Metadata)
+		}
+	}
+}
+
+func TestCombineMetadataChanges(t *testing.T) {
+	foo := "foo"
+	combined, err := combineMetadataChanges(
+		[]metadataChange{{key: "a", value: &foo}},
+		[]metadataChange{{key: "b"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := combined.([]metadataChange)
+	if len(changes) != 2 || changes[0].key != "a" || changes[1].key != "b" {
+		t.Errorf("expected changes to be combined in order, got %v", changes)
+	}
+
+	if _, err := combineMetadataChanges([]metadataChange{}, []string{"a"}); err == nil {
+		t.Errorf("expected an error combining an unexpected type")
+	}
+}
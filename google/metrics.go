@@ -0,0 +1,426 @@
+// An in-process registry of counters and histograms describing where time
+// goes during a provider run: retried errors, batched requests and polling for
+// long-running operations.
+//
+// Metrics are always collected. If the provider's metrics_file field or the
+// GOOGLE_METRICS_FILE environment variable is set, they're written to that
+// path after each resource or data source CRUD function that changed them, as
+// JSON if the path ends in ".json" and in the Prometheus text exposition
+// format otherwise. The provider process can be killed at any point once
+// Terraform is done with it, so they aren't left to be written on shutdown.
+
+package google
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const metricsNamespace = "terraform_provider_google"
+
+var (
+	metricsDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+	metricsSizeBuckets     = []float64{1, 2, 5, 10, 20, 50, 100, 200}
+)
+
+var (
+	providerMetrics = newMetricsRegistry()
+
+	metricRetries = providerMetrics.counter("retries_total",
+		"Errors retried, by the retry predicate that matched them.", "predicate")
+	metricBatchSize = providerMetrics.histogram("batch_size",
+		"Number of requests combined into each batch sent by a batcher.", metricsSizeBuckets, "batcher")
+	metricBatchWaitSeconds = providerMetrics.histogram("batch_wait_seconds",
+		"Time requests spent waiting for their batch to be sent and return.", metricsDurationBuckets, "batcher")
+	metricOperationPolls = providerMetrics.counter("operation_polls_total",
+		"Polls of long-running operations, by service.", "service")
+	metricOperationWaitSeconds = providerMetrics.histogram("operation_wait_seconds",
+		"Time spent waiting for long-running operations to complete, by service.", metricsDurationBuckets, "service")
+	metricPollingWaitPolls = providerMetrics.counter("polling_wait_polls_total",
+		"Polls of resources waiting for an expected state.")
+	metricPollingWaitSeconds = providerMetrics.histogram("polling_wait_seconds",
+		"Time spent polling resources until they reach an expected state.", metricsDurationBuckets)
+	metricIamPolicyConflicts = providerMetrics.counter("iam_policy_conflicts_total",
+		"Writes of IAM policies that failed because the policy was changed concurrently.")
+)
+
+// metricsRegistry holds a set of metrics and where to write them.
+type metricsRegistry struct {
+	mu      sync.Mutex
+	metrics []*metric
+	path    string
+	// changes counts the updates to the metrics, and written the number of
+	// them in the last write.
+	changes uint64
+	written uint64
+}
+
+type metricType string
+
+const (
+	metricTypeCounter   metricType = "counter"
+	metricTypeHistogram metricType = "histogram"
+)
+
+// metric is a counter or histogram, with one series per combination of label
+// values.
+type metric struct {
+	registry   *metricsRegistry
+	name       string
+	help       string
+	metricType metricType
+	labelNames []string
+	// buckets are the upper bounds of a histogram's buckets.
+	buckets []float64
+	series  map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labelValues []string
+	// value is a counter's value or a histogram's sum.
+	value float64
+	count uint64
+	// bucketCounts are the non-cumulative counts of each bucket, plus +Inf.
+	bucketCounts []uint64
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{}
+}
+
+func (r *metricsRegistry) counter(name, help string, labelNames ...string) *metric {
+	return r.register(&metric{
+		name:       name,
+		help:       help,
+		metricType: metricTypeCounter,
+		labelNames: labelNames,
+	})
+}
+
+func (r *metricsRegistry) histogram(name, help string, buckets []float64, labelNames ...string) *metric {
+	return r.register(&metric{
+		name:       name,
+		help:       help,
+		metricType: metricTypeHistogram,
+		labelNames: labelNames,
+		buckets:    buckets,
+	})
+}
+
+func (r *metricsRegistry) register(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m.registry = r
+	m.name = fmt.Sprintf("%s_%s", metricsNamespace, m.name)
+	m.series = make(map[string]*metricSeries)
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// setPath sets where the registry's metrics are written by write.
+func (r *metricsRegistry) setPath(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.path = path
+}
+
+// getSeries returns the series for the given label values, creating it if
+// needed. Must be called with the registry's lock held.
+func (m *metric) getSeries(labelValues []string) *metricSeries {
+	key := strings.Join(labelValues, "\x00")
+	s, ok := m.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: labelValues}
+		if m.metricType == metricTypeHistogram {
+			s.bucketCounts = make([]uint64, len(m.buckets)+1)
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// inc adds one to a counter.
+func (m *metric) inc(labelValues ...string) {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	s := m.getSeries(labelValues)
+	s.value++
+	s.count++
+	m.registry.changes++
+}
+
+// observe records a value in a histogram.
+func (m *metric) observe(v float64, labelValues ...string) {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	s := m.getSeries(labelValues)
+	s.value += v
+	s.count++
+	i := sort.SearchFloat64s(m.buckets, v)
+	s.bucketCounts[i]++
+	m.registry.changes++
+}
+
+// observeDuration records the time since start in a histogram, in seconds.
+func (m *metric) observeDuration(start time.Time, labelValues ...string) {
+	m.observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// addMetricsSupport wraps the CRUD functions of the given resources or data
+// sources to write the provider's metrics once they return.
+func addMetricsSupport(resources map[string]*schema.Resource) {
+	for _, r := range resources {
+		if r.Create != nil {
+			r.Create = schema.CreateFunc(wrapWithMetricsWrite(r.Create))
+		}
+		if r.Read != nil {
+			r.Read = schema.ReadFunc(wrapWithMetricsWrite(r.Read))
+		}
+		if r.Update != nil {
+			r.Update = schema.UpdateFunc(wrapWithMetricsWrite(r.Update))
+		}
+		if r.Delete != nil {
+			r.Delete = schema.DeleteFunc(wrapWithMetricsWrite(r.Delete))
+		}
+	}
+}
+
+func wrapWithMetricsWrite(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		err := f(d, meta)
+		if werr := providerMetrics.writeIfChanged(); werr != nil {
+			log.Printf("[WARN] %s", werr)
+		}
+		return err
+	}
+}
+
+// writeIfChanged writes the metrics unless they haven't changed since they
+// were last written.
+func (r *metricsRegistry) writeIfChanged() error {
+	r.mu.Lock()
+	changed := r.changes != r.written
+	r.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return r.write()
+}
+
+// write writes the metrics to the registry's path, if any. The file is
+// replaced rather than rewritten, so it's never seen half written.
+func (r *metricsRegistry) write() error {
+	r.mu.Lock()
+	path := r.path
+	changes := r.changes
+	r.mu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+
+	var out []byte
+	if strings.HasSuffix(path, ".json") {
+		var err error
+		out, err = r.json()
+		if err != nil {
+			return fmt.Errorf("unable to encode metrics: %s", err)
+		}
+	} else {
+		out = []byte(r.prometheusText())
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write metrics file %q: %s", path, err)
+	}
+	_, err = f.Write(out)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("unable to write metrics file %q: %s", path, err)
+	}
+
+	r.mu.Lock()
+	if changes > r.written {
+		r.written = changes
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// sortedSeries returns a metric's series sorted by label values. Must be
+// called with the registry's lock held.
+func (m *metric) sortedSeries() []*metricSeries {
+	series := make([]*metricSeries, 0, len(m.series))
+	for _, s := range m.series {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return strings.Join(series[i].labelValues, "\x00") < strings.Join(series[j].labelValues, "\x00")
+	})
+	return series
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func prometheusLabels(names, values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// prometheusText returns the metrics in the Prometheus text exposition format.
+func (r *metricsRegistry) prometheusText() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	for _, m := range r.metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.metricType)
+		for _, s := range m.sortedSeries() {
+			if m.metricType == metricTypeCounter {
+				fmt.Fprintf(&b, "%s%s %s\n", m.name, prometheusLabels(m.labelNames, s.labelValues), formatMetricValue(s.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, bound := range m.buckets {
+				cumulative += s.bucketCounts[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, prometheusLabels(m.labelNames, s.labelValues, "le", formatMetricValue(bound)), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, prometheusLabels(m.labelNames, s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", m.name, prometheusLabels(m.labelNames, s.labelValues), formatMetricValue(s.value))
+			fmt.Fprintf(&b, "%s_count%s %d\n", m.name, prometheusLabels(m.labelNames, s.labelValues), s.count)
+		}
+	}
+	return b.String()
+}
+
+type metricJSON struct {
+	Name   string             `json:"name"`
+	Help   string             `json:"help"`
+	Type   metricType         `json:"type"`
+	Series []metricSeriesJSON `json:"series"`
+}
+
+type metricSeriesJSON struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	// Value is a counter's value.
+	Value *float64 `json:"value,omitempty"`
+	// Count, Sum and Buckets describe a histogram. Buckets maps each bucket's
+	// upper bound to the cumulative count of observations up to it.
+	Count   *uint64           `json:"count,omitempty"`
+	Sum     *float64          `json:"sum,omitempty"`
+	Buckets map[string]uint64 `json:"buckets,omitempty"`
+}
+
+// json returns the metrics encoded as JSON.
+func (r *metricsRegistry) json() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := make([]metricJSON, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		mj := metricJSON{
+			Name:   m.name,
+			Help:   m.help,
+			Type:   m.metricType,
+			Series: []metricSeriesJSON{},
+		}
+		for _, s := range m.sortedSeries() {
+			sj := metricSeriesJSON{}
+			if len(m.labelNames) > 0 {
+				sj.Labels = make(map[string]string)
+				for i, name := range m.labelNames {
+					sj.Labels[name] = s.labelValues[i]
+				}
+			}
+
+			value, count := s.value, s.count
+			if m.metricType == metricTypeCounter {
+				sj.Value = &value
+			} else {
+				sj.Count = &count
+				sj.Sum = &value
+				sj.Buckets = make(map[string]uint64)
+				var cumulative uint64
+				for i, bound := range m.buckets {
+					cumulative += s.bucketCounts[i]
+					sj.Buckets[formatMetricValue(bound)] = cumulative
+				}
+				sj.Buckets["+Inf"] = count
+			}
+			mj.Series = append(mj.Series, sj)
+		}
+		metrics = append(metrics, mj)
+	}
+	return json.MarshalIndent(metrics, "", "  ")
+}
+
+// Matches the suffix of the name of a function literal, e.g. ".func1".
+var metricsFuncLiteralSuffixRegex = regexp.MustCompile(`(\.func\d+)+$`)
+
+// retryPredicateName returns a short name identifying a retry predicate in
+// metrics, i.e. the name of the function declaring it.
+func retryPredicateName(pred RetryErrorPredicateFunc) string {
+	f := runtime.FuncForPC(reflect.ValueOf(pred).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return metricsFuncLiteralSuffixRegex.ReplaceAllString(name, "")
+}
+
+// operationWaiterService returns the name of the service a Waiter polls
+// operations for, e.g. "Compute" for a *ComputeOperationWaiter.
+func operationWaiterService(w Waiter) string {
+	t := reflect.TypeOf(w)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "OperationWaiter")
+}
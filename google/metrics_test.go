@@ -0,0 +1,162 @@
+package google
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func testMetricsRegistry() (*metricsRegistry, *metric, *metric) {
+	r := newMetricsRegistry()
+	c := r.counter("test_total", "A test counter.", "service")
+	h := r.histogram("test_seconds", "A test histogram.", []float64{1, 5})
+	c.inc("compute")
+	c.inc("compute")
+	c.inc("storage")
+	h.observe(0.5)
+	h.observe(1)
+	h.observe(3)
+	h.observe(10)
+	return r, c, h
+}
+
+func TestMetricsRegistry_prometheusText(t *testing.T) {
+	r, _, _ := testMetricsRegistry()
+
+	expected := `# HELP terraform_provider_google_test_total A test counter.
+# TYPE terraform_provider_google_test_total counter
+terraform_provider_google_test_total{service="compute"} 2
+terraform_provider_google_test_total{service="storage"} 1
+# HELP terraform_provider_google_test_seconds A test histogram.
+# TYPE terraform_provider_google_test_seconds histogram
+terraform_provider_google_test_seconds_bucket{le="1"} 2
+terraform_provider_google_test_seconds_bucket{le="5"} 3
+terraform_provider_google_test_seconds_bucket{le="+Inf"} 4
+terraform_provider_google_test_seconds_sum 14.5
+terraform_provider_google_test_seconds_count 4
+`
+	if got := r.prometheusText(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestMetricsRegistry_writeJSON(t *testing.T) {
+	r, _, _ := testMetricsRegistry()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	r.setPath(path)
+	if err := r.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metrics []metricJSON
+	if err := json.Unmarshal(b, &metrics); err != nil {
+		t.Fatalf("unable to parse metrics %s: %s", b, err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+
+	counter := metrics[0]
+	if counter.Type != metricTypeCounter || len(counter.Series) != 2 {
+		t.Fatalf("unexpected counter %+v", counter)
+	}
+	if s := counter.Series[0]; s.Labels["service"] != "compute" || s.Value == nil || *s.Value != 2 {
+		t.Errorf("unexpected counter series %+v", s)
+	}
+
+	histogram := metrics[1]
+	if histogram.Type != metricTypeHistogram || len(histogram.Series) != 1 {
+		t.Fatalf("unexpected histogram %+v", histogram)
+	}
+	s := histogram.Series[0]
+	if s.Count == nil || *s.Count != 4 || s.Sum == nil || *s.Sum != 14.5 {
+		t.Errorf("unexpected histogram series %+v", s)
+	}
+	if s.Buckets["1"] != 2 || s.Buckets["5"] != 3 || s.Buckets["+Inf"] != 4 {
+		t.Errorf("unexpected histogram buckets %v", s.Buckets)
+	}
+}
+
+func TestMetricsRegistry_writePrometheusText(t *testing.T) {
+	r, _, _ := testMetricsRegistry()
+	if err := r.write(); err != nil {
+		t.Errorf("expected no error without a path, got %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	r.setPath(path)
+	if err := r.write(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "# HELP") {
+		t.Errorf("expected Prometheus text, got %s", b)
+	}
+}
+
+// Check that metrics are written once a CRUD function changing them returns,
+// and not rewritten by one that doesn't.
+func TestAddMetricsSupport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	providerMetrics.setPath(path)
+	t.Cleanup(func() { providerMetrics.setPath("") })
+
+	r := &schema.Resource{
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			metricPollingWaitPolls.inc()
+			return nil
+		},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			return nil
+		},
+	}
+	addMetricsSupport(map[string]*schema.Resource{"google_test": r})
+
+	if err := r.Create(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected metrics to be written after Create: %s", err)
+	}
+	if !strings.Contains(string(b), "terraform_provider_google_polling_wait_polls_total") {
+		t.Errorf("expected written metrics to include polling_wait_polls_total, got %s", b)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Read(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected unchanged metrics not to be written after Read, got %v", err)
+	}
+}
+
+func TestRetryPredicateName(t *testing.T) {
+	if got := retryPredicateName(is409OperationInProgressError); got != "is409OperationInProgressError" {
+		t.Errorf("unexpected name %q", got)
+	}
+	if got := retryPredicateName(isRetryableErrorCodeFunc([]int{500})); got != "isRetryableErrorCodeFunc" {
+		t.Errorf("unexpected name %q", got)
+	}
+}
+
+func TestOperationWaiterService(t *testing.T) {
+	if got := operationWaiterService(&ComputeOperationWaiter{}); got != "Compute" {
+		t.Errorf("unexpected service %q", got)
+	}
+}
@@ -0,0 +1,323 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const (
+	defaultOperationPollInitialInterval = 1 * time.Second
+	defaultOperationPollMaxInterval     = 30 * time.Second
+
+	// Number of consecutive polls the operation can't be read for before
+	// giving up, as in resource.StateChangeConf.
+	operationPollNotFoundChecks = 20
+)
+
+// operationPollingConfig contains user configuration for how often
+// long-running operations are polled. The interval between polls starts at
+// initialInterval and doubles up to a cap, which is maxInterval unless set
+// for the operation's service in serviceMaxIntervals.
+type operationPollingConfig struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	// serviceMaxIntervals are keyed by the provider's name for a service, e.g.
+	// "container".
+	serviceMaxIntervals map[string]time.Duration
+}
+
+func defaultOperationPollingConfig() *operationPollingConfig {
+	return &operationPollingConfig{
+		initialInterval: defaultOperationPollInitialInterval,
+		maxInterval:     defaultOperationPollMaxInterval,
+	}
+}
+
+func (c *operationPollingConfig) maxIntervalFor(service string) time.Duration {
+	if max, ok := c.serviceMaxIntervals[service]; ok {
+		return max
+	}
+	return c.maxInterval
+}
+
+type operationPollingContextKeyType struct{}
+
+var operationPollingContextKey = operationPollingContextKeyType{}
+
+// contextWithOperationPolling returns a context carrying the polling
+// configuration used by OperationWaitWithCtx.
+func contextWithOperationPolling(ctx context.Context, c *operationPollingConfig) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, operationPollingContextKey, c)
+}
+
+func operationPollingFromContext(ctx context.Context) *operationPollingConfig {
+	if ctx != nil {
+		if c, ok := ctx.Value(operationPollingContextKey).(*operationPollingConfig); ok {
+			return c
+		}
+	}
+	return defaultOperationPollingConfig()
+}
+
+// operationProgressReporter is implemented by waiters whose operations can
+// report how far along they are.
+type operationProgressReporter interface {
+	// operationProgress returns the operation's progress as a percentage, or
+	// -1 if unknown, and its estimated completion time, or the zero time if
+	// unknown.
+	operationProgress() (float64, time.Time)
+}
+
+// operationPoller decides how long to wait between polls of an operation.
+type operationPoller struct {
+	initial time.Duration
+	max     time.Duration
+	current time.Duration
+	start   time.Time
+}
+
+// newOperationPoller returns a poller for an operation of the given service.
+// If pollInterval is set, it further caps the interval between polls.
+func newOperationPoller(c *operationPollingConfig, service string, pollInterval time.Duration) *operationPoller {
+	p := &operationPoller{
+		initial: c.initialInterval,
+		max:     c.maxIntervalFor(service),
+		start:   time.Now(),
+	}
+	if pollInterval > 0 && pollInterval < p.max {
+		p.max = pollInterval
+	}
+	if p.initial > p.max {
+		p.initial = p.max
+	}
+	return p
+}
+
+// next returns the wait before the next poll. It backs off exponentially
+// from the initial interval up to the cap, but when the operation reports its
+// progress or estimated completion time, it waits about half of the estimated
+// remaining time instead, within the same bounds.
+func (p *operationPoller) next(w Waiter, now time.Time) time.Duration {
+	if p.current == 0 {
+		p.current = p.initial
+	} else {
+		p.current *= 2
+	}
+	if p.current > p.max {
+		p.current = p.max
+	}
+
+	remaining, ok := estimatedOperationRemaining(w, p.start, now)
+	if !ok {
+		return p.current
+	}
+	wait := remaining / 2
+	if wait < p.initial {
+		wait = p.initial
+	}
+	if wait > p.max {
+		wait = p.max
+	}
+	log.Printf("[DEBUG] Operation %s estimated to complete in %s, waiting %s before polling again", w.OpName(), remaining, wait)
+	return wait
+}
+
+// estimatedOperationRemaining estimates the time left until an operation
+// started at start completes, from its estimated completion time or by
+// extrapolating its progress.
+func estimatedOperationRemaining(w Waiter, start, now time.Time) (time.Duration, bool) {
+	r, ok := w.(operationProgressReporter)
+	if !ok {
+		return 0, false
+	}
+	percent, eta := r.operationProgress()
+	if !eta.IsZero() {
+		if eta.Before(now) {
+			return 0, true
+		}
+		return eta.Sub(now), true
+	}
+	if percent > 0 && percent < 100 {
+		elapsed := now.Sub(start)
+		return time.Duration(float64(elapsed) * (100 - percent) / percent), true
+	}
+	return 0, false
+}
+
+// waitForState polls an operation with refresh until it reaches one of the
+// waiter's target states. It behaves like resource.StateChangeConf, and
+// returns the same errors, but spaces polls as decided by the poller.
+func (p *operationPoller) waitForState(w Waiter, refresh resource.StateRefreshFunc, timeout time.Duration) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	pending, target := w.PendingStates(), w.TargetStates()
+
+	notFound := 0
+	lastState := ""
+	for {
+		res, state, err := refresh()
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil {
+			notFound++
+			if notFound > operationPollNotFoundChecks {
+				return nil, &resource.NotFoundError{Retries: notFound}
+			}
+		} else {
+			notFound = 0
+			lastState = state
+			if stringInSlice(target, state) {
+				return res, nil
+			}
+			if len(pending) > 0 && !stringInSlice(pending, state) {
+				return nil, &resource.UnexpectedStateError{
+					State:         state,
+					ExpectedState: target,
+				}
+			}
+		}
+
+		now := time.Now()
+		if !now.Before(deadline) {
+			return nil, &resource.TimeoutError{
+				LastState:     lastState,
+				Timeout:       timeout,
+				ExpectedState: target,
+			}
+		}
+		wait := p.next(w, now)
+		if left := deadline.Sub(now); wait > left {
+			wait = left
+		}
+		log.Printf("[TRACE] Waiting %s before next poll of operation %s", wait, w.OpName())
+		time.Sleep(wait)
+	}
+}
+
+// operationPollingServiceKey returns the provider's name for the service an
+// operation waiter polls, e.g. "vpc_access" for "VPCAccess".
+func operationPollingServiceKey(service string) string {
+	switch service {
+	case "SqlAdmin":
+		return "sql"
+	case "DataprocCluster", "DataprocJob", "DataprocDeleteJob":
+		return "dataproc"
+	}
+
+	runes := []rune(service)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// expandProviderOperationPollingConfig reads the provider's operation_polling
+// block.
+func expandProviderOperationPollingConfig(v interface{}) (*operationPollingConfig, error) {
+	c := defaultOperationPollingConfig()
+
+	ls, ok := v.([]interface{})
+	if !ok || len(ls) == 0 || ls[0] == nil {
+		return c, nil
+	}
+	raw := ls[0].(map[string]interface{})
+
+	var err error
+	if v, ok := raw["initial_interval"]; ok && v.(string) != "" {
+		if c.initialInterval, err = time.ParseDuration(v.(string)); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := raw["max_interval"]; ok && v.(string) != "" {
+		if c.maxInterval, err = time.ParseDuration(v.(string)); err != nil {
+			return nil, err
+		}
+	}
+	if c.initialInterval <= 0 || c.maxInterval < c.initialInterval {
+		return nil, fmt.Errorf("operation_polling: max_interval (%s) must be at least initial_interval (%s), which must be positive", c.maxInterval, c.initialInterval)
+	}
+
+	if v, ok := raw["max_interval_by_service"]; ok {
+		for service, d := range v.(map[string]interface{}) {
+			max, err := time.ParseDuration(d.(string))
+			if err != nil {
+				return nil, fmt.Errorf("operation_polling: invalid max interval for service %q: %s", service, err)
+			}
+			if max < c.initialInterval {
+				return nil, fmt.Errorf("operation_polling: max interval for service %q (%s) must be at least initial_interval (%s)", service, max, c.initialInterval)
+			}
+			if c.serviceMaxIntervals == nil {
+				c.serviceMaxIntervals = make(map[string]time.Duration)
+			}
+			c.serviceMaxIntervals[service] = max
+		}
+	}
+	return c, nil
+}
+
+func (w *ComputeOperationWaiter) operationProgress() (float64, time.Time) {
+	if w == nil || w.Op == nil || w.Op.Progress <= 0 {
+		return -1, time.Time{}
+	}
+	return float64(w.Op.Progress), time.Time{}
+}
+
+func (w *CommonOperationWaiter) operationProgress() (float64, time.Time) {
+	if w == nil || len(w.Op.Metadata) == 0 {
+		return -1, time.Time{}
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(w.Op.Metadata, &metadata); err != nil {
+		return -1, time.Time{}
+	}
+	return operationMetadataProgress(metadata)
+}
+
+// operationMetadataProgress reads the progress of an operation from the
+// common fields of long-running operation metadata: a progress percentage
+// given as "progressPercent", "progressPercentage" or "progress" (possibly
+// nested in a "progress" object), and an "estimatedCompletionTime" or
+// "estimatedEndTime".
+func operationMetadataProgress(metadata map[string]interface{}) (float64, time.Time) {
+	percent := -1.0
+	eta := time.Time{}
+
+	fields := []map[string]interface{}{metadata}
+	if nested, ok := metadata["progress"].(map[string]interface{}); ok {
+		fields = append(fields, nested)
+	}
+	for _, m := range fields {
+		for _, key := range []string{"progressPercent", "progressPercentage", "progress", "percent"} {
+			if v, ok := m[key].(float64); ok && percent < 0 {
+				percent = v
+			}
+		}
+		for _, key := range []string{"estimatedCompletionTime", "estimatedEndTime"} {
+			if v, ok := m[key].(string); ok && eta.IsZero() {
+				if t, err := time.Parse(time.RFC3339, v); err == nil {
+					eta = t
+				}
+			}
+		}
+	}
+	return percent, eta
+}
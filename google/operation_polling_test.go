@@ -0,0 +1,208 @@
+package google
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestOperationPoller_backsOffToCap(t *testing.T) {
+	c := &operationPollingConfig{
+		initialInterval: time.Second,
+		maxInterval:     10 * time.Second,
+		serviceMaxIntervals: map[string]time.Duration{
+			"container": time.Minute,
+		},
+	}
+	w := &TestWaiter{}
+	now := time.Now()
+
+	p := newOperationPoller(c, "compute", 0)
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, e := range expected {
+		if got := p.next(w, now); got != e {
+			t.Errorf("poll %d: expected %s, got %s", i, e, got)
+		}
+	}
+
+	p = newOperationPoller(c, "container", 0)
+	for i := 0; i < 10; i++ {
+		p.next(w, now)
+	}
+	if got := p.next(w, now); got != time.Minute {
+		t.Errorf("expected the service cap of 1m, got %s", got)
+	}
+
+	// A set poll interval caps every service.
+	p = newOperationPoller(c, "container", 10*time.Millisecond)
+	if got := p.next(w, now); got != 10*time.Millisecond {
+		t.Errorf("expected 10ms, got %s", got)
+	}
+}
+
+func TestOperationPoller_usesProgress(t *testing.T) {
+	c := &operationPollingConfig{
+		initialInterval: time.Second,
+		maxInterval:     time.Minute,
+	}
+	w := &ComputeOperationWaiter{Op: &compute.Operation{Progress: 25}}
+	p := newOperationPoller(c, "compute", 0)
+
+	// 25% done after 20s leaves an estimated 60s, so wait 30s.
+	if got := p.next(w, p.start.Add(20*time.Second)); got != 30*time.Second {
+		t.Errorf("expected 30s, got %s", got)
+	}
+
+	// Nearly done, poll at the initial interval.
+	w.Op.Progress = 99
+	if got := p.next(w, p.start.Add(20*time.Second)); got != time.Second {
+		t.Errorf("expected 1s, got %s", got)
+	}
+}
+
+func TestOperationPoller_usesEstimatedCompletionTime(t *testing.T) {
+	now := time.Now().UTC()
+	w := &RedisOperationWaiter{}
+	w.Op.Metadata = googleapi.RawMessage(`{"estimatedCompletionTime": "` + now.Add(10*time.Minute).Format(time.RFC3339) + `"}`)
+
+	p := newOperationPoller(&operationPollingConfig{initialInterval: time.Second, maxInterval: 10 * time.Minute}, "redis", 0)
+	if got := p.next(w, now); got < 4*time.Minute || got > 5*time.Minute {
+		t.Errorf("expected about half the remaining 10m, got %s", got)
+	}
+
+	p = newOperationPoller(&operationPollingConfig{initialInterval: time.Second, maxInterval: 2 * time.Minute}, "redis", 0)
+	if got := p.next(w, now); got != 2*time.Minute {
+		t.Errorf("expected the cap of 2m, got %s", got)
+	}
+}
+
+func TestOperationMetadataProgress(t *testing.T) {
+	cases := map[string]struct {
+		Metadata map[string]interface{}
+		Percent  float64
+		Eta      string
+	}{
+		"none": {
+			Metadata: map[string]interface{}{"createTime": "2021-01-01T00:00:00Z"},
+			Percent:  -1,
+		},
+		"top level": {
+			Metadata: map[string]interface{}{"progressPercent": 40.0},
+			Percent:  40,
+		},
+		"nested": {
+			Metadata: map[string]interface{}{"progress": map[string]interface{}{"progressPercent": 60.0}},
+			Percent:  60,
+		},
+		"eta": {
+			Metadata: map[string]interface{}{"estimatedCompletionTime": "2021-01-01T00:10:00Z"},
+			Percent:  -1,
+			Eta:      "2021-01-01T00:10:00Z",
+		},
+	}
+
+	for tn, tc := range cases {
+		percent, eta := operationMetadataProgress(tc.Metadata)
+		if percent != tc.Percent {
+			t.Errorf("%s: expected percent %v, got %v", tn, tc.Percent, percent)
+		}
+		if tc.Eta == "" && !eta.IsZero() || tc.Eta != "" && eta.Format(time.RFC3339) != tc.Eta {
+			t.Errorf("%s: expected eta %q, got %s", tn, tc.Eta, eta)
+		}
+	}
+}
+
+func TestOperationPollingServiceKey(t *testing.T) {
+	cases := map[string]string{
+		"Compute":         "compute",
+		"ServiceUsage":    "service_usage",
+		"VPCAccess":       "vpc_access",
+		"MLEngine":        "ml_engine",
+		"VertexAI":        "vertex_ai",
+		"DialogflowCX":    "dialogflow_cx",
+		"SqlAdmin":        "sql",
+		"DataprocCluster": "dataproc",
+	}
+	for service, expected := range cases {
+		if got := operationPollingServiceKey(service); got != expected {
+			t.Errorf("%s: expected %q, got %q", service, expected, got)
+		}
+	}
+}
+
+type testStateWaiter struct {
+	TestWaiter
+	state string
+}
+
+func (w *testStateWaiter) State() string {
+	return w.state
+}
+
+func (w *testStateWaiter) PendingStates() []string {
+	return []string{"RUNNING"}
+}
+
+func TestOperationPoller_waitForState(t *testing.T) {
+	c := &operationPollingConfig{initialInterval: time.Millisecond, maxInterval: time.Millisecond}
+
+	w := &testStateWaiter{}
+	states := []string{"RUNNING", "RUNNING", "DONE"}
+	polls := 0
+	res, err := newOperationPoller(c, "compute", 0).waitForState(w, func() (interface{}, string, error) {
+		state := states[polls]
+		polls++
+		return "op", state, nil
+	}, time.Minute)
+	if err != nil || res != "op" || polls != 3 {
+		t.Errorf("expected to reach DONE after 3 polls, got %v, %v after %d polls", res, err, polls)
+	}
+
+	_, err = newOperationPoller(c, "compute", 0).waitForState(w, func() (interface{}, string, error) {
+		return "op", "UNKNOWN", nil
+	}, time.Minute)
+	if _, ok := err.(*resource.UnexpectedStateError); !ok {
+		t.Errorf("expected an unexpected state error, got %v", err)
+	}
+
+	_, err = newOperationPoller(c, "compute", 0).waitForState(w, func() (interface{}, string, error) {
+		return "op", "RUNNING", nil
+	}, 20*time.Millisecond)
+	if _, ok := err.(*resource.TimeoutError); !ok {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+}
+
+func TestExpandProviderOperationPollingConfig(t *testing.T) {
+	c, err := expandProviderOperationPollingConfig([]interface{}{
+		map[string]interface{}{
+			"initial_interval":        "2s",
+			"max_interval":            "20s",
+			"max_interval_by_service": map[string]interface{}{"container": "2m"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.initialInterval != 2*time.Second || c.maxIntervalFor("compute") != 20*time.Second || c.maxIntervalFor("container") != 2*time.Minute {
+		t.Errorf("unexpected config %+v", c)
+	}
+
+	c, err = expandProviderOperationPollingConfig([]interface{}{})
+	if err != nil || c.initialInterval != defaultOperationPollInitialInterval || c.maxInterval != defaultOperationPollMaxInterval {
+		t.Errorf("expected defaults, got %+v, %v", c, err)
+	}
+
+	_, err = expandProviderOperationPollingConfig([]interface{}{
+		map[string]interface{}{
+			"initial_interval": "1m",
+			"max_interval":     "10s",
+		},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a max interval below the initial interval")
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -157,47 +159,7 @@ func getCachedConfig(ctx context.Context, d *schema.ResourceData, configureFunc
 		return nil, diag.FromErr(err)
 	}
 	// Defines how VCR will match requests to responses.
-	rec.SetMatcher(func(r *http.Request, i cassette.Request) bool {
-		// Default matcher compares method and URL only
-		if !cassette.DefaultMatcher(r, i) {
-			return false
-		}
-		if r.Body == nil {
-			return true
-		}
-		contentType := r.Header.Get("Content-Type")
-		// If body contains media, don't try to compare
-		if strings.Contains(contentType, "multipart/related") {
-			return true
-		}
-
-		var b bytes.Buffer
-		if _, err := b.ReadFrom(r.Body); err != nil {
-			log.Printf("[DEBUG] Failed to read request body from cassette: %v", err)
-			return false
-		}
-		r.Body = ioutil.NopCloser(&b)
-		reqBody := b.String()
-		// If body matches identically, we are done
-		if reqBody == i.Body {
-			return true
-		}
-
-		// JSON might be the same, but reordered. Try parsing json and comparing
-		if strings.Contains(contentType, "application/json") {
-			var reqJson, cassetteJson interface{}
-			if err := json.Unmarshal([]byte(reqBody), &reqJson); err != nil {
-				log.Printf("[DEBUG] Failed to unmarshall request json: %v", err)
-				return false
-			}
-			if err := json.Unmarshal([]byte(i.Body), &cassetteJson); err != nil {
-				log.Printf("[DEBUG] Failed to unmarshall cassette json: %v", err)
-				return false
-			}
-			return reflect.DeepEqual(reqJson, cassetteJson)
-		}
-		return false
-	})
+	rec.SetMatcher(vcrMatcher)
 	config.client.Transport = rec
 	configs[testName] = config
 	return config, nil
@@ -214,6 +176,11 @@ func closeRecorder(t *testing.T) {
 				t.Error(err)
 			}
 			envPath := os.Getenv("VCR_PATH")
+			if os.Getenv("VCR_MODE") == "RECORDING" {
+				if err := redactVcrCassette(filepath.Join(envPath, vcrFileName(t.Name()))); err != nil {
+					t.Error(err)
+				}
+			}
 			if vcrSource, ok := sources[t.Name()]; ok {
 				err = writeSeedToFile(vcrSource.seed, vcrSeedFile(envPath, t.Name()))
 				if err != nil {
@@ -339,6 +306,236 @@ func writeSeedToFile(seed int64, fileName string) error {
 	return nil
 }
 
+// Headers that may hold credentials, which are redacted from cassettes.
+var vcrSensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Goog-Api-Key"}
+
+// Fields of JSON bodies that may hold credentials, which are redacted from
+// cassettes.
+var vcrSensitiveFields = []string{
+	"accessToken", "access_token", "idToken", "id_token", "refreshToken",
+	"refresh_token", "privateKeyData", "private_key", "clientSecret", "client_secret",
+}
+
+var vcrSensitiveFieldRegexp = regexp.MustCompile(`"(` + strings.Join(vcrSensitiveFields, "|") + `)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// Fields of JSON request bodies that differ between recording and replaying
+// the same request, e.g. because they're read from a response that was
+// replayed for another request of a batch or of a parallel resource, and so
+// aren't compared by vcrMatcher.
+var vcrVolatileFields = append([]string{"etag", "fingerprint", "labelFingerprint", "projectNumber", "requestId"}, vcrSensitiveFields...)
+
+// Patterns of project numbers in URLs and bodies, with the number as their
+// first group.
+var vcrProjectNumberRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`projects/(\d+)\b`),
+	regexp.MustCompile(`"projectNumber"\s*:\s*"?(\d+)\b`),
+	regexp.MustCompile(`\bservice-(\d+)@`),
+	regexp.MustCompile(`\b(\d+)-compute@developer\.gserviceaccount\.com`),
+	regexp.MustCompile(`\b(\d+)@cloud(services|build)\.gserviceaccount\.com`),
+}
+
+// Fields of JSON request bodies holding arrays that are sets, whose order
+// depends on the order batched or parallel requests were sent in, and so are
+// compared regardless of their order by vcrMatcher. Other arrays, e.g. the
+// disks of an instance whose first one is the boot disk, keep their order.
+var vcrSetFields = map[string]bool{
+	"auditConfigs":    true,
+	"auditLogConfigs": true,
+	"bindings":        true,
+	"exemptedMembers": true,
+	"members":         true,
+	"permissions":     true,
+	"serviceIds":      true,
+}
+
+// vcrMatcher matches requests with the same method, URL and body. JSON bodies
+// are compared after normalizing them with normalizeVcrJson, so requests
+// match regardless of the order batched or parallel requests were sent in.
+// Project numbers are ignored, as they're redacted from cassettes.
+func vcrMatcher(r *http.Request, i cassette.Request) bool {
+	if r.Method != i.Method {
+		return false
+	}
+	cassetteUrl, err := url.Parse(i.URL)
+	if err != nil {
+		log.Printf("[DEBUG] Failed to parse cassette URL: %v", err)
+		return false
+	}
+	if normalizeVcrUrl(r.URL) != normalizeVcrUrl(cassetteUrl) {
+		return false
+	}
+	if r.Body == nil {
+		return true
+	}
+	contentType := r.Header.Get("Content-Type")
+	// If body contains media, don't try to compare
+	if strings.Contains(contentType, "multipart/related") {
+		return true
+	}
+
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(r.Body); err != nil {
+		log.Printf("[DEBUG] Failed to read request body from cassette: %v", err)
+		return false
+	}
+	r.Body = ioutil.NopCloser(&b)
+	reqBody := b.String()
+	// If body matches identically, we are done
+	if reqBody == i.Body {
+		return true
+	}
+
+	// JSON might be the same, but reordered. Try parsing json and comparing
+	if strings.Contains(contentType, "application/json") {
+		var reqJson, cassetteJson interface{}
+		if err := json.Unmarshal([]byte(reqBody), &reqJson); err != nil {
+			log.Printf("[DEBUG] Failed to unmarshall request json: %v", err)
+			return false
+		}
+		if err := json.Unmarshal([]byte(i.Body), &cassetteJson); err != nil {
+			log.Printf("[DEBUG] Failed to unmarshall cassette json: %v", err)
+			return false
+		}
+		return reflect.DeepEqual(normalizeVcrJson(reqJson), normalizeVcrJson(cassetteJson))
+	}
+	return false
+}
+
+func normalizeVcrUrl(u *url.URL) string {
+	// Encode sorts query parameters by name.
+	return maskVcrProjectNumbers(fmt.Sprintf("%s://%s%s?%s", u.Scheme, u.Host, u.EscapedPath(), u.Query().Encode()))
+}
+
+// normalizeVcrJson returns v without volatile fields, with project numbers
+// masked and with the arrays of vcrSetFields sorted.
+func normalizeVcrJson(v interface{}) interface{} {
+	return normalizeVcrJsonValue(v, false)
+}
+
+// normalizeVcrJsonValue normalizes v, sorting it if it's the array of a set.
+func normalizeVcrJsonValue(v interface{}, set bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, e := range v {
+			m[k] = normalizeVcrJsonValue(e, vcrSetFields[k])
+		}
+		for _, k := range vcrVolatileFields {
+			delete(m, k)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = normalizeVcrJsonValue(e, false)
+		}
+		if !set {
+			return l
+		}
+
+		keys := make(map[int]string)
+		for i, e := range l {
+			b, _ := json.Marshal(e)
+			keys[i] = string(b)
+		}
+		idx := make([]int, len(l))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return keys[idx[a]] < keys[idx[b]] })
+		sorted := make([]interface{}, len(l))
+		for i, j := range idx {
+			sorted[i] = l[j]
+		}
+		return sorted
+	case string:
+		return maskVcrProjectNumbers(v)
+	}
+	return v
+}
+
+func maskVcrProjectNumbers(s string) string {
+	for _, re := range vcrProjectNumberRegexps {
+		s = replaceVcrFirstGroup(re, s, func(string) string { return "{project_number}" })
+	}
+	return s
+}
+
+// replaceVcrFirstGroup replaces the first group of each match of re in s.
+func replaceVcrFirstGroup(re *regexp.Regexp, s string, repl func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(s[last:m[2]])
+		b.WriteString(repl(s[m[2]:m[3]]))
+		last = m[3]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redactVcrCassette removes credentials and project numbers from the cassette
+// at path, if it was saved. Each project number is replaced by a placeholder
+// of the same length, so the cassette stays consistent when it's replayed.
+func redactVcrCassette(path string) error {
+	c, err := cassette.Load(path)
+	if os.IsNotExist(err) {
+		// Cassettes without interactions aren't saved
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Error loading cassette %s to redact it: %s", path, err)
+	}
+
+	numbers := make(map[string]string)
+	for _, i := range c.Interactions {
+		for _, s := range []string{i.Request.URL, i.Request.Body, i.Response.Body} {
+			for _, re := range vcrProjectNumberRegexps {
+				for _, m := range re.FindAllStringSubmatch(s, -1) {
+					numbers[m[1]] = ""
+				}
+			}
+		}
+	}
+	var sorted []string
+	for n := range numbers {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	for i, n := range sorted {
+		numbers[n] = fmt.Sprintf("%0*d", len(n), i+1)
+	}
+	var numbersRegexp *regexp.Regexp
+	if len(sorted) > 0 {
+		numbersRegexp = regexp.MustCompile(`\b(` + strings.Join(sorted, "|") + `)\b`)
+	}
+
+	redact := func(s string) string {
+		s = vcrSensitiveFieldRegexp.ReplaceAllString(s, `"$1"$2"REDACTED"`)
+		if numbersRegexp != nil {
+			s = numbersRegexp.ReplaceAllStringFunc(s, func(n string) string { return numbers[n] })
+		}
+		return s
+	}
+	for _, i := range c.Interactions {
+		i.Request.URL = redact(i.Request.URL)
+		i.Request.Body = redact(i.Request.Body)
+		i.Response.Body = redact(i.Response.Body)
+		i.Request.Headers = redactVcrHeaders(i.Request.Headers)
+		i.Response.Headers = redactVcrHeaders(i.Response.Headers)
+	}
+	return c.Save()
+}
+
+func redactVcrHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range vcrSensitiveHeaders {
+		if _, ok := h[k]; ok {
+			h.Set(k, "REDACTED")
+		}
+	}
+	return h
+}
+
 func randString(t *testing.T, length int) string {
 	if !isVcrEnabled() {
 		return acctest.RandString(length)
@@ -450,6 +647,174 @@ func TestProvider_loadCredentialsFromJSON(t *testing.T) {
 	}
 }
 
+func TestVcrMatcher(t *testing.T) {
+	recorded := cassette.Request{
+		Method: "POST",
+		URL:    "https://serviceusage.googleapis.com/v1/projects/123456789012/services:batchEnable?alt=json&prettyPrint=false",
+		Body:   `{"serviceIds":["compute.googleapis.com","pubsub.googleapis.com"],"etag":"BwXhqDFpV5s="}`,
+	}
+	cases := map[string]struct {
+		method   string
+		url      string
+		body     string
+		expected bool
+	}{
+		"identical": {
+			method:   "POST",
+			url:      recorded.URL,
+			body:     recorded.Body,
+			expected: true,
+		},
+		"reordered batch and query": {
+			method:   "POST",
+			url:      "https://serviceusage.googleapis.com/v1/projects/123456789012/services:batchEnable?prettyPrint=false&alt=json",
+			body:     `{"etag":"BwXhqDFpV5s=","serviceIds":["pubsub.googleapis.com","compute.googleapis.com"]}`,
+			expected: true,
+		},
+		"volatile field": {
+			method:   "POST",
+			url:      recorded.URL,
+			body:     `{"serviceIds":["compute.googleapis.com","pubsub.googleapis.com"],"etag":"BwXhqDJ3Rn0="}`,
+			expected: true,
+		},
+		"redacted project number": {
+			method:   "POST",
+			url:      "https://serviceusage.googleapis.com/v1/projects/000000000001/services:batchEnable?alt=json&prettyPrint=false",
+			body:     recorded.Body,
+			expected: true,
+		},
+		"different batch": {
+			method: "POST",
+			url:    recorded.URL,
+			body:   `{"serviceIds":["compute.googleapis.com"],"etag":"BwXhqDFpV5s="}`,
+		},
+		"different method": {
+			method: "PUT",
+			url:    recorded.URL,
+			body:   recorded.Body,
+		},
+		"different path": {
+			method: "POST",
+			url:    "https://serviceusage.googleapis.com/v1/projects/my-project/services:batchEnable?alt=json&prettyPrint=false",
+			body:   recorded.Body,
+		},
+	}
+
+	for tn, tc := range cases {
+		r, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Content-Type", "application/json")
+		if got := vcrMatcher(r, recorded); got != tc.expected {
+			t.Errorf("%s: expected match to be %t, got %t", tn, tc.expected, got)
+		}
+		if b, _ := ioutil.ReadAll(r.Body); string(b) != tc.body {
+			t.Errorf("%s: expected request body to be readable after matching", tn)
+		}
+	}
+}
+
+func TestNormalizeVcrJson(t *testing.T) {
+	parse := func(s string) interface{} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return normalizeVcrJson(v)
+	}
+	cases := map[string]struct {
+		a, b     string
+		expected bool
+	}{
+		"reordered set": {
+			a:        `{"policy":{"bindings":[{"role":"roles/viewer","members":["user:b","user:a"]},{"role":"roles/editor","members":["user:a"]}]}}`,
+			b:        `{"policy":{"bindings":[{"role":"roles/editor","members":["user:a"]},{"role":"roles/viewer","members":["user:a","user:b"]}]}}`,
+			expected: true,
+		},
+		"reordered list": {
+			a: `{"disks":[{"boot":true,"source":"boot"},{"source":"data"}]}`,
+			b: `{"disks":[{"source":"data"},{"boot":true,"source":"boot"}]}`,
+		},
+		"reordered list in a set": {
+			a: `{"bindings":[{"role":"roles/viewer","condition":{"args":["a","b"]}}]}`,
+			b: `{"bindings":[{"role":"roles/viewer","condition":{"args":["b","a"]}}]}`,
+		},
+	}
+	for tn, tc := range cases {
+		if got := reflect.DeepEqual(parse(tc.a), parse(tc.b)); got != tc.expected {
+			t.Errorf("%s: expected the normalized bodies to be equal: %t, got %t", tn, tc.expected, got)
+		}
+	}
+}
+
+func TestRedactVcrCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestAccSomething")
+	c := cassette.New(path)
+	c.AddInteraction(&cassette.Interaction{
+		Request: cassette.Request{
+			Method:  "GET",
+			URL:     "https://cloudresourcemanager.googleapis.com/v1/projects/my-project?alt=json",
+			Headers: http.Header{"Authorization": {"Bearer ya29.secret"}, "User-Agent": {"Terraform"}},
+		},
+		Response: cassette.Response{
+			Code: 200,
+			Body: `{"projectNumber": "123456789012", "projectId": "my-project"}`,
+		},
+	})
+	c.AddInteraction(&cassette.Interaction{
+		Request: cassette.Request{
+			Method: "POST",
+			URL:    "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/service-123456789012@gcp-sa-pubsub.iam.gserviceaccount.com:generateAccessToken?alt=json",
+			Body:   `{"scope":["https://www.googleapis.com/auth/cloud-platform"]}`,
+		},
+		Response: cassette.Response{
+			Code:    200,
+			Body:    `{"accessToken":"ya29.secret","expireTime":"2021-01-01T00:00:00Z"}`,
+			Headers: http.Header{"Set-Cookie": {"session=secret"}},
+		},
+	})
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := redactVcrCassette(path); err != nil {
+		t.Fatal(err)
+	}
+	redacted, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path + ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"ya29.secret", "session=secret", "123456789012"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("expected %q to be redacted from the cassette:\n%s", secret, b)
+		}
+	}
+
+	i := redacted.Interactions
+	if got, expected := i[0].Response.Body, `{"projectNumber": "000000000001", "projectId": "my-project"}`; got != expected {
+		t.Errorf("expected response body %s, got %s", expected, got)
+	}
+	if !strings.Contains(i[1].Request.URL, "service-000000000001@") {
+		t.Errorf("expected the project number to be replaced consistently, got %s", i[1].Request.URL)
+	}
+	if got := i[0].Request.Headers.Get("User-Agent"); got != "Terraform" {
+		t.Errorf("expected other headers to be kept, got %q", got)
+	}
+	if got := i[1].Response.Body; got != `{"accessToken":"REDACTED","expireTime":"2021-01-01T00:00:00Z"}` {
+		t.Errorf("expected the access token to be redacted, got %s", got)
+	}
+
+	// Cassettes without interactions aren't saved, so there's nothing to redact
+	if err := redactVcrCassette(filepath.Join(t.TempDir(), "TestAccEmpty")); err != nil {
+		t.Errorf("unexpected error redacting a missing cassette: %s", err)
+	}
+}
+
 func TestAccProviderBasePath_setBasePath(t *testing.T) {
 	t.Parallel()
 
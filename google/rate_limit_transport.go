@@ -0,0 +1,243 @@
+// A http.RoundTripper that limits the rate of requests sent to each API
+// service, using one token bucket per service base path.
+//
+// Limits are configured per service through the provider's rate_limit blocks,
+// e.g. 20 requests/second for the Compute base path. The limiter sits inside the
+// retry transport, so every attempt of a request takes a token. When a
+// response shows the service is throttling us (a 429, or a Retry-After
+// header), the limiter lowers its rate and holds requests back for the time
+// the server asked for, then slowly recovers to the configured rate.
+
+package google
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// The rate is never lowered below this fraction of the configured limit.
+	rateLimitMinRateFraction = 0.1
+	// After being throttled, wait this long before raising the rate again, and
+	// then raise it by rateLimitRecoveryFraction of the limit at each step.
+	rateLimitRecoveryInterval = 10 * time.Second
+	rateLimitRecoveryFraction = 0.1
+)
+
+// rateLimitConfig contains user configuration for limiting the request rate
+// to a single API service.
+type rateLimitConfig struct {
+	// service is the provider's name for the service, e.g. "compute".
+	service string
+	// basePath is the base URL of the service the limit applies to.
+	basePath          string
+	requestsPerSecond float64
+	burst             int
+}
+
+type rateLimitTransport struct {
+	internal http.RoundTripper
+	// limiters are sorted by decreasing base path length, so the most specific
+	// base path matches first.
+	limiters []*serviceRateLimiter
+}
+
+type serviceRateLimiter struct {
+	basePath string
+	bucket   *tokenBucket
+}
+
+// NewTransportWithRateLimits constructs a rateLimitTransport applying the
+// given per-service limits. Requests to services without a limit are sent
+// straight through.
+func NewTransportWithRateLimits(t http.RoundTripper, configs []*rateLimitConfig) *rateLimitTransport {
+	limiters := make([]*serviceRateLimiter, 0, len(configs))
+	for _, c := range configs {
+		limiters = append(limiters, &serviceRateLimiter{
+			basePath: c.basePath,
+			bucket:   newTokenBucket(c.requestsPerSecond, c.burst),
+		})
+	}
+	sort.SliceStable(limiters, func(i, j int) bool {
+		return len(limiters[i].basePath) > len(limiters[j].basePath)
+	})
+
+	return &rateLimitTransport{
+		internal: t,
+		limiters: limiters,
+	}
+}
+
+func (t *rateLimitTransport) limiterFor(req *http.Request) *serviceRateLimiter {
+	url := req.URL.String()
+	for _, l := range t.limiters {
+		if strings.HasPrefix(url, l.basePath) {
+			return l
+		}
+	}
+	return nil
+}
+
+// RoundTrip implements the RoundTripper interface method.
+// It waits for a token from the limiter of the request's service, if any,
+// before sending the request, and adapts the limiter to the response.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiterFor(req)
+	if l == nil {
+		return t.internal.RoundTrip(req)
+	}
+
+	wait := l.bucket.reserve(time.Now())
+	if wait > 0 {
+		log.Printf("[DEBUG] Rate Limit Transport: Waiting %s before sending request to %s", wait, l.basePath)
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.internal.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header, now)
+	if resp.StatusCode == http.StatusTooManyRequests || hasRetryAfter {
+		rate := l.bucket.throttle(now, retryAfter)
+		log.Printf("[DEBUG] Rate Limit Transport: Throttled by %s (code %d), lowered rate to %.2f requests/second", l.basePath, resp.StatusCode, rate)
+	} else if resp.StatusCode < 400 {
+		l.bucket.recover(now)
+	}
+	return resp, err
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header, given
+// either as a number of seconds or as an HTTP date.
+func parseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if t.Before(now) {
+			return 0, true
+		}
+		return t.Sub(now), true
+	}
+	return 0, false
+}
+
+// tokenBucket is a token bucket whose rate can be lowered when the server
+// throttles requests. Tokens may go negative: each reservation takes a token
+// and waits until the bucket has refilled past it.
+type tokenBucket struct {
+	mu sync.Mutex
+	// limit is the configured rate, in tokens per second.
+	limit float64
+	// rate is the current rate, lowered from limit when throttled.
+	rate   float64
+	burst  float64
+	tokens float64
+	// last is the time tokens were last added. It may be in the future while
+	// the bucket is paused for a Retry-After.
+	last       time.Time
+	lastAdjust time.Time
+}
+
+func newTokenBucket(requestsPerSecond float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		limit:  requestsPerSecond,
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// advance adds the tokens accumulated since the last update. Must be called
+// with the lock held.
+func (b *tokenBucket) advance(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+		return
+	}
+	if !now.After(b.last) {
+		return
+	}
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	b.tokens--
+
+	// Tokens are next added at b.last, which is later than now while paused.
+	wait := b.last.Sub(now)
+	if b.tokens < 0 {
+		wait += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// throttle halves the current rate and, if retryAfter is set, holds back
+// further requests until it has passed. It returns the new rate.
+func (b *tokenBucket) throttle(now time.Time, retryAfter time.Duration) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	b.rate = math.Max(b.limit*rateLimitMinRateFraction, b.rate/2)
+	b.lastAdjust = now
+	if retryAfter > 0 {
+		if until := now.Add(retryAfter); until.After(b.last) {
+			b.last = until
+		}
+		b.tokens = math.Min(b.tokens, 0)
+	}
+	return b.rate
+}
+
+// recover raises a lowered rate back toward the configured limit, at most once
+// every rateLimitRecoveryInterval.
+func (b *tokenBucket) recover(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate >= b.limit || now.Sub(b.lastAdjust) < rateLimitRecoveryInterval {
+		return
+	}
+	b.advance(now)
+	b.rate = math.Min(b.limit, b.rate+b.limit*rateLimitRecoveryFraction)
+	b.lastAdjust = now
+}
+
+func (c *rateLimitConfig) String() string {
+	return fmt.Sprintf("%s (%s): %.2f requests/second, burst %d", c.service, c.basePath, c.requestsPerSecond, c.burst)
+}
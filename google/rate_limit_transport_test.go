@@ -0,0 +1,207 @@
+package google
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 2)
+
+	// The burst is available immediately.
+	for i := 0; i < 2; i++ {
+		if wait := b.reserve(now); wait != 0 {
+			t.Fatalf("expected reservation %d within burst to not wait, got %s", i, wait)
+		}
+	}
+
+	// Then tokens come in at 10/s.
+	if wait := b.reserve(now); wait != 100*time.Millisecond {
+		t.Errorf("expected to wait 100ms, got %s", wait)
+	}
+	if wait := b.reserve(now); wait != 200*time.Millisecond {
+		t.Errorf("expected to wait 200ms, got %s", wait)
+	}
+
+	// Once the reservations are paid back, the bucket refills up to the burst.
+	later := now.Add(time.Second)
+	if wait := b.reserve(later); wait != 0 {
+		t.Errorf("expected no wait after refilling, got %s", wait)
+	}
+}
+
+func TestTokenBucket_throttleAndRecover(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(20, 1)
+	b.reserve(now)
+
+	if rate := b.throttle(now, 0); rate != 10 {
+		t.Errorf("expected rate to be halved to 10, got %v", rate)
+	}
+	for i := 0; i < 10; i++ {
+		b.throttle(now, 0)
+	}
+	if b.rate != 2 {
+		t.Errorf("expected rate to be floored at 2, got %v", b.rate)
+	}
+
+	// Too early to recover.
+	b.recover(now.Add(time.Second))
+	if b.rate != 2 {
+		t.Errorf("expected rate to stay at 2, got %v", b.rate)
+	}
+
+	b.recover(now.Add(rateLimitRecoveryInterval))
+	if b.rate != 4 {
+		t.Errorf("expected rate to recover to 4, got %v", b.rate)
+	}
+}
+
+func TestTokenBucket_throttleRetryAfter(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 5)
+
+	b.throttle(now, 3*time.Second)
+	// The rate is now 5/s, and no token is available until 3s from now.
+	if wait := b.reserve(now); wait != 3*time.Second+200*time.Millisecond {
+		t.Errorf("expected to wait 3.2s, got %s", wait)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		Header   string
+		Expected time.Duration
+		Ok       bool
+	}{
+		"missing": {
+			Header: "",
+		},
+		"seconds": {
+			Header:   "30",
+			Expected: 30 * time.Second,
+			Ok:       true,
+		},
+		"http date": {
+			Header:   "Thu, 01 Jul 2021 12:01:00 GMT",
+			Expected: time.Minute,
+			Ok:       true,
+		},
+		"http date in the past": {
+			Header: "Thu, 01 Jul 2021 11:00:00 GMT",
+			Ok:     true,
+		},
+		"invalid": {
+			Header: "soon",
+		},
+	}
+
+	for tn, tc := range cases {
+		h := make(http.Header)
+		if tc.Header != "" {
+			h.Set("Retry-After", tc.Header)
+		}
+		got, ok := parseRetryAfter(h, now)
+		if got != tc.Expected || ok != tc.Ok {
+			t.Errorf("%s: expected (%s, %t), got (%s, %t)", tn, tc.Expected, tc.Ok, got, ok)
+		}
+	}
+}
+
+func TestRateLimitTransport_limitsMatchingService(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	transport := NewTransportWithRateLimits(http.DefaultTransport, []*rateLimitConfig{
+		{
+			service:           "compute",
+			basePath:          ts.URL + "/compute/v1/",
+			requestsPerSecond: 10,
+			burst:             1,
+		},
+	})
+	client := &http.Client{Transport: transport}
+
+	// Unlimited service
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(ts.URL + "/storage/v1/b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected requests to an unlimited service to not wait, took %s", elapsed)
+	}
+
+	// Limited service, 1 request immediately then 1 every 100ms
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(ts.URL + "/compute/v1/projects/p/global/networks")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("expected requests to a limited service to take at least 300ms, took %s", elapsed)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 9 {
+		t.Errorf("expected 9 requests, got %d", got)
+	}
+}
+
+func TestExpandProviderRateLimitConfigs(t *testing.T) {
+	basePathFor := func(service string) (string, bool) {
+		switch service {
+		case "compute":
+			return "https://compute.googleapis.com/compute/v1/", true
+		case "vertex_ai":
+			return "https://{{region}}-aiplatform.googleapis.com/v1/", true
+		}
+		return "", false
+	}
+
+	configs, err := expandProviderRateLimitConfigs([]interface{}{
+		map[string]interface{}{
+			"service":             "compute",
+			"requests_per_second": 2.5,
+			"burst":               0,
+		},
+	}, basePathFor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("expected 1 config, got %d", len(configs))
+	}
+	if configs[0].basePath != "https://compute.googleapis.com/compute/v1/" {
+		t.Errorf("unexpected base path %q", configs[0].basePath)
+	}
+	if configs[0].burst != 3 {
+		t.Errorf("expected burst to default to 3, got %d", configs[0].burst)
+	}
+
+	for _, service := range []string{"unknown", "vertex_ai"} {
+		_, err := expandProviderRateLimitConfigs([]interface{}{
+			map[string]interface{}{
+				"service":             service,
+				"requests_per_second": 1.0,
+			},
+		}, basePathFor)
+		if err == nil {
+			t.Errorf("expected an error for service %q", service)
+		}
+	}
+}
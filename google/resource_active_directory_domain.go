@@ -312,7 +312,7 @@ func resourceActiveDirectoryDomainUpdate(d *schema.ResourceData, meta interface{
 	log.Printf("[DEBUG] Updating Domain %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
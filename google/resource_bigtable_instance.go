@@ -284,7 +284,7 @@ func resourceBigtableInstanceUpdate(d *schema.ResourceData, meta interface{}) er
 	}
 	conf.DisplayName = displayName.(string)
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		conf.Labels = expandLabels(d)
 	}
 
@@ -601,7 +601,7 @@ func resourceCloudFunctionsUpdate(d *schema.ResourceData, meta interface{}) erro
 		updateMaskArr = append(updateMaskArr, "ingressSettings")
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		function.Labels = expandLabels(d)
 		updateMaskArr = append(updateMaskArr, "labels")
 	}
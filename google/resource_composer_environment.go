@@ -599,7 +599,7 @@ func resourceComposerEnvironmentUpdate(d *schema.ResourceData, meta interface{})
 
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		patchEnv := &composer.Environment{Labels: expandLabels(d)}
 		err := resourceComposerEnvironmentPatchField("labels", userAgent, patchEnv, d, tfConfig)
 		if err != nil {
@@ -844,7 +844,7 @@ func resourceComputeDiskUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	d.Partial(true)
 
-	if d.HasChange("label_fingerprint") || d.HasChange("labels") {
+	if d.HasChange("label_fingerprint") || labelsChanged(d) {
 		obj := make(map[string]interface{})
 
 		labelFingerprintProp, err := expandComputeDiskLabelFingerprint(d.Get("label_fingerprint"), d, config)
@@ -450,7 +450,7 @@ func resourceComputeImageUpdate(d *schema.ResourceData, meta interface{}) error
 
 	d.Partial(true)
 
-	if d.HasChange("labels") || d.HasChange("label_fingerprint") {
+	if labelsChanged(d) || d.HasChange("label_fingerprint") {
 		obj := make(map[string]interface{})
 
 		labelsProp, err := expandComputeImageLabels(d.Get("labels"), d, config)
@@ -429,8 +429,7 @@ func resourceComputeInstance() *schema.Resource {
 			"deletion_protection": {
 				Type:        schema.TypeBool,
 				Optional:    true,
-				Default:     false,
-				Description: `Whether deletion protection is enabled on this instance.`,
+				Description: `Whether deletion protection is enabled on this instance. Defaults to the provider's default_deletion_protection, which is only checked by Terraform.`,
 			},
 
 			"enable_display": {
@@ -1272,8 +1271,14 @@ func resourceComputeInstanceRead(d *schema.ResourceData, meta interface{}) error
 	if err := d.Set("min_cpu_platform", instance.MinCpuPlatform); err != nil {
 		return fmt.Errorf("Error setting min_cpu_platform: %s", err)
 	}
-	if err := d.Set("deletion_protection", instance.DeletionProtection); err != nil {
-		return fmt.Errorf("Error setting deletion_protection: %s", err)
+	// deletion_protection is left unset while the instance isn't protected
+	// and it isn't set on the resource, so the provider's default applies.
+	// Instances created while it defaulted to false keep false in state, as
+	// it can't be told apart from an explicit false.
+	if _, ok := d.GetOkExists("deletion_protection"); ok || instance.DeletionProtection {
+		if err := d.Set("deletion_protection", instance.DeletionProtection); err != nil {
+			return fmt.Errorf("Error setting deletion_protection: %s", err)
+		}
 	}
 	if err := d.Set("self_link", ConvertSelfLinkToV1(instance.SelfLink)); err != nil {
 		return fmt.Errorf("Error setting self_link: %s", err)
@@ -1357,32 +1362,13 @@ func resourceComputeInstanceUpdate(d *schema.ResourceData, meta interface{}) err
 			return err
 		}
 
-		// We're retrying for an error 412 where the metadata fingerprint is out of date
-		err = retry(
-			func() error {
-				// retrieve up-to-date metadata from the API in case several updates hit simultaneously. instances
-				// sometimes but not always share metadata fingerprints.
-				instance, err := config.NewComputeBetaClient(userAgent).Instances.Get(project, zone, instance.Name).Do()
-				if err != nil {
-					return fmt.Errorf("Error retrieving metadata: %s", err)
-				}
-
-				metadataV1.Fingerprint = instance.Metadata.Fingerprint
-
-				op, err := config.NewComputeClient(userAgent).Instances.SetMetadata(project, zone, instance.Name, metadataV1).Do()
-				if err != nil {
-					return fmt.Errorf("Error updating metadata: %s", err)
-				}
-
-				opErr := computeOperationWaitTime(config, op, project, "metadata to update", userAgent, d.Timeout(schema.TimeoutUpdate))
-				if opErr != nil {
-					return opErr
-				}
-
-				return nil
-			},
-		)
-
+		// Concurrent writes to the instance's metadata are batched into one
+		// fingerprinted update, retried on fingerprint mismatches.
+		change := metadataChange{
+			replace: flattenMetadata(metadataV1),
+		}
+		reqDesc := fmt.Sprintf("Metadata for instance %q", instance.Name)
+		err = BatchRequestInstanceMetadataChange(config, project, zone, instance.Name, userAgent, change, d.Timeout(schema.TimeoutUpdate), reqDesc)
 		if err != nil {
 			return err
 		}
@@ -1406,7 +1392,7 @@ func resourceComputeInstanceUpdate(d *schema.ResourceData, meta interface{}) err
 		}
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		labels := expandLabels(d)
 		labelFingerprint := d.Get("label_fingerprint").(string)
 		req := compute.InstancesSetLabelsRequest{Labels: labels, LabelFingerprint: labelFingerprint}
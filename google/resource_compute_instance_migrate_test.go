@@ -80,6 +80,24 @@ func TestAccComputeInstanceMigrateState(t *testing.T) {
 				"boot_disk.0.initialize_params.#": "0",
 			},
 		},
+		"keep disabled deletion_protection": {
+			StateVersion: 5,
+			Attributes: map[string]string{
+				"deletion_protection": "false",
+			},
+			Expected: map[string]string{
+				"deletion_protection": "false",
+			},
+		},
+		"keep enabled deletion_protection": {
+			StateVersion: 5,
+			Attributes: map[string]string{
+				"deletion_protection": "true",
+			},
+			Expected: map[string]string{
+				"deletion_protection": "true",
+			},
+		},
 	}
 
 	config := getInitializedConfig(t)
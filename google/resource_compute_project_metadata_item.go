@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"google.golang.org/api/compute/v1"
 )
 
 type metadataPresentBehavior bool
@@ -169,59 +168,11 @@ func resourceComputeProjectMetadataItemDelete(d *schema.ResourceData, meta inter
 }
 
 func updateComputeCommonInstanceMetadata(config *Config, projectID, key, userAgent string, afterVal *string, timeout time.Duration, failIfPresent metadataPresentBehavior) error {
-	updateMD := func() error {
-		lockName := fmt.Sprintf("projects/%s/commoninstancemetadata", projectID)
-		mutexKV.Lock(lockName)
-		defer mutexKV.Unlock(lockName)
-
-		log.Printf("[DEBUG] Loading project metadata: %s", projectID)
-		project, err := config.NewComputeClient(userAgent).Projects.Get(projectID).Do()
-		if err != nil {
-			return fmt.Errorf("Error loading project '%s': %s", projectID, err)
-		}
-
-		md := flattenMetadata(project.CommonInstanceMetadata)
-
-		val, ok := md[key]
-
-		if !ok {
-			if afterVal == nil {
-				// Asked to set no value and we didn't find one - we're done
-				return nil
-			}
-		} else {
-			if failIfPresent {
-				return fmt.Errorf("key %q already present in metadata for project %q. Use `terraform import` to manage it with Terraform", key, projectID)
-			}
-			if afterVal != nil && *afterVal == val {
-				// Asked to set a value and it's already set - we're done.
-				return nil
-			}
-		}
-
-		if afterVal == nil {
-			delete(md, key)
-		} else {
-			md[key] = *afterVal
-		}
-
-		// Attempt to write the new value now
-		op, err := config.NewComputeClient(userAgent).Projects.SetCommonInstanceMetadata(
-			projectID,
-			&compute.Metadata{
-				Fingerprint: project.CommonInstanceMetadata.Fingerprint,
-				Items:       expandComputeMetadata(md),
-			},
-		).Do()
-
-		if err != nil {
-			return err
-		}
-
-		log.Printf("[DEBUG] SetCommonInstanceMetadata: %d (%s)", op.Id, op.SelfLink)
-
-		return computeOperationWaitTime(config, op, project.Name, "SetCommonInstanceMetadata", userAgent, timeout)
+	change := metadataChange{
+		key:           key,
+		value:         afterVal,
+		failIfPresent: failIfPresent,
 	}
-
-	return MetadataRetryWrapper(updateMD)
+	reqDesc := fmt.Sprintf("Project Metadata Item %q for project %q", key, projectID)
+	return BatchRequestProjectMetadataChange(config, projectID, userAgent, change, timeout, reqDesc)
 }
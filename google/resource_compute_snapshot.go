@@ -434,7 +434,7 @@ func resourceComputeSnapshotUpdate(d *schema.ResourceData, meta interface{}) err
 
 	d.Partial(true)
 
-	if d.HasChange("labels") || d.HasChange("label_fingerprint") {
+	if labelsChanged(d) || d.HasChange("label_fingerprint") {
 		obj := make(map[string]interface{})
 
 		labelsProp, err := expandComputeSnapshotLabels(d.Get("labels"), d, config)
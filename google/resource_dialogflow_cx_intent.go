@@ -404,7 +404,7 @@ func resourceDialogflowCXIntentUpdate(d *schema.ResourceData, meta interface{})
 		updateMask = append(updateMask, "isFallback")
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
@@ -377,7 +377,7 @@ func resourceFilestoreInstanceUpdate(d *schema.ResourceData, meta interface{}) e
 		updateMask = append(updateMask, "description")
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
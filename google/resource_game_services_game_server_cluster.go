@@ -321,7 +321,7 @@ func resourceGameServicesGameServerClusterUpdate(d *schema.ResourceData, meta in
 	log.Printf("[DEBUG] Updating GameServerCluster %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
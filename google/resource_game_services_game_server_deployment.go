@@ -255,7 +255,7 @@ func resourceGameServicesGameServerDeploymentUpdate(d *schema.ResourceData, meta
 		updateMask = append(updateMask, "description")
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 	// updateMask is a URL parameter but not present in the schema, so replaceVars
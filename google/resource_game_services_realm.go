@@ -277,7 +277,7 @@ func resourceGameServicesRealmUpdate(d *schema.ResourceData, meta interface{}) e
 	log.Printf("[DEBUG] Updating Realm %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
@@ -400,7 +400,7 @@ func resourceGoogleProjectUpdate(d *schema.ResourceData, meta interface{}) error
 	}
 
 	// Project Labels have changed
-	if ok := d.HasChange("labels"); ok {
+	if labelsChanged(d) {
 		p.Labels = expandLabels(d)
 
 		// Do Update on project
@@ -0,0 +1,94 @@
+package google
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// Test that IAM members can be added to and removed from a role without
+// touching the role's other members
+func TestAccProjectIamMembers_basic(t *testing.T) {
+	t.Parallel()
+
+	org := getTestOrgFromEnv(t)
+	pid := fmt.Sprintf("tf-test-%d", randInt(t))
+	role := "roles/compute.instanceAdmin"
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			// Add a member to a role with a member bound outside of the
+			// members resource
+			{
+				Config: testAccProjectAssociateMembersBasic(pid, pname, org, role, "user:admin@hashicorptest.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectIamRoleMembers(t, pid, role, []string{"user:admin@hashicorptest.com", "user:evanbrown@google.com"}),
+				),
+			},
+			// Add another member
+			{
+				Config: testAccProjectAssociateMembersBasic(pid, pname, org, role, "user:admin@hashicorptest.com", "user:paddy@hashicorp.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectIamRoleMembers(t, pid, role, []string{"user:admin@hashicorptest.com", "user:evanbrown@google.com", "user:paddy@hashicorp.com"}),
+				),
+			},
+			// Remove the first member
+			{
+				Config: testAccProjectAssociateMembersBasic(pid, pname, org, role, "user:paddy@hashicorp.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectIamRoleMembers(t, pid, role, []string{"user:evanbrown@google.com", "user:paddy@hashicorp.com"}),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckProjectIamRoleMembers(t *testing.T, pid, role string, members []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := googleProviderConfig(t)
+		p, err := getProjectIamPolicy(pid, config)
+		if err != nil {
+			return err
+		}
+		for _, b := range p.Bindings {
+			if b.Role == role && b.Condition == nil {
+				if !compareBindings([]*cloudresourcemanager.Binding{b}, []*cloudresourcemanager.Binding{{Role: role, Members: members}}) {
+					return fmt.Errorf("Expected members %v for role %q, got %v", members, role, b.Members)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("No binding found for role %q", role)
+	}
+}
+
+func testAccProjectAssociateMembersBasic(pid, name, org, role string, members ...string) string {
+	quoted := make([]string, len(members))
+	for i, m := range members {
+		quoted[i] = fmt.Sprintf("%q", m)
+	}
+	return fmt.Sprintf(`
+resource "google_project" "acceptance" {
+  project_id = "%s"
+  name       = "%s"
+  org_id     = "%s"
+}
+
+resource "google_project_iam_member" "external" {
+  project = google_project.acceptance.project_id
+  role    = "%s"
+  member  = "user:evanbrown@google.com"
+}
+
+resource "google_project_iam_members" "acceptance" {
+  project = google_project.acceptance.project_id
+  role    = google_project_iam_member.external.role
+  members = [%s]
+}
+`, pid, name, org, role, strings.Join(quoted, ", "))
+}
@@ -230,7 +230,7 @@ func resourceHealthcareConsentStoreUpdate(d *schema.ResourceData, meta interface
 		updateMask = append(updateMask, "enableConsentCreateOnUpdate")
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 	// updateMask is a URL parameter but not present in the schema, so replaceVars
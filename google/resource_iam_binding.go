@@ -15,9 +15,10 @@ import (
 
 var iamBindingSchema = map[string]*schema.Schema{
 	"role": {
-		Type:     schema.TypeString,
-		Required: true,
-		ForceNew: true,
+		Type:         schema.TypeString,
+		Required:     true,
+		ForceNew:     true,
+		ValidateFunc: validateIamRole,
 	},
 	"members": {
 		Type:     schema.TypeSet,
@@ -39,9 +40,10 @@ var iamBindingSchema = map[string]*schema.Schema{
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"expression": {
-					Type:     schema.TypeString,
-					Required: true,
-					ForceNew: true,
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     true,
+					ValidateFunc: validateIamConditionExpression,
 				},
 				"title": {
 					Type:     schema.TypeString,
@@ -69,11 +71,12 @@ func ResourceIamBinding(parentSpecificSchema map[string]*schema.Schema, newUpdat
 // Resource that batches requests to the same IAM policy across multiple IAM fine-grained resources
 func ResourceIamBindingWithBatching(parentSpecificSchema map[string]*schema.Schema, newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser resourceIdParserFunc, enableBatching bool) *schema.Resource {
 	return &schema.Resource{
-		Create: resourceIamBindingCreateUpdate(newUpdaterFunc, enableBatching),
-		Read:   resourceIamBindingRead(newUpdaterFunc),
-		Update: resourceIamBindingCreateUpdate(newUpdaterFunc, enableBatching),
-		Delete: resourceIamBindingDelete(newUpdaterFunc, enableBatching),
-		Schema: mergeSchemas(iamBindingSchema, parentSpecificSchema),
+		Create:        resourceIamBindingCreateUpdate(newUpdaterFunc, enableBatching),
+		Read:          resourceIamBindingRead(newUpdaterFunc),
+		Update:        resourceIamBindingCreateUpdate(newUpdaterFunc, enableBatching),
+		Delete:        resourceIamBindingDelete(newUpdaterFunc, enableBatching),
+		Schema:        mergeSchemas(iamBindingSchema, parentSpecificSchema),
+		CustomizeDiff: iamGuardrailsCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: iamBindingImport(newUpdaterFunc, resourceIdParser),
 		},
@@ -101,7 +104,7 @@ func resourceIamBindingCreateUpdate(newUpdaterFunc newResourceIamUpdaterFunc, en
 			err = BatchRequestModifyIamPolicy(updater, modifyF, config, fmt.Sprintf(
 				"Set IAM Binding for role %q on %q", binding.Role, updater.DescribeResource()))
 		} else {
-			err = iamPolicyReadModifyWrite(updater, modifyF)
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
 		}
 		if err != nil {
 			return err
@@ -126,7 +129,7 @@ func resourceIamBindingRead(newUpdaterFunc newResourceIamUpdaterFunc) schema.Rea
 
 		eBinding := getResourceIamBinding(d)
 		eCondition := conditionKeyFromCondition(eBinding.Condition)
-		p, err := iamPolicyReadWithRetry(updater)
+		p, err := iamPolicyReadWithRetry(config, updater)
 		if err != nil {
 			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q with IAM Binding (Role %q)", updater.DescribeResource(), eBinding.Role))
 		}
@@ -213,7 +216,7 @@ func iamBindingImport(newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser
 		if err != nil {
 			return nil, err
 		}
-		p, err := iamPolicyReadWithRetry(updater)
+		p, err := iamPolicyReadWithRetry(config, updater)
 		if err != nil {
 			return nil, err
 		}
@@ -268,7 +271,7 @@ func resourceIamBindingDelete(newUpdaterFunc newResourceIamUpdaterFunc, enableBa
 			err = BatchRequestModifyIamPolicy(updater, modifyF, config, fmt.Sprintf(
 				"Delete IAM Binding for role %q on %q", binding.Role, updater.DescribeResource()))
 		} else {
-			err = iamPolicyReadModifyWrite(updater, modifyF)
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
 		}
 		if err != nil {
 			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q for IAM binding with role %q", updater.DescribeResource(), binding.Role))
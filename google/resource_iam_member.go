@@ -23,9 +23,10 @@ func iamMemberCaseDiffSuppress(k, old, new string, d *schema.ResourceData) bool
 
 var IamMemberBaseSchema = map[string]*schema.Schema{
 	"role": {
-		Type:     schema.TypeString,
-		Required: true,
-		ForceNew: true,
+		Type:         schema.TypeString,
+		Required:     true,
+		ForceNew:     true,
+		ValidateFunc: validateIamRole,
 	},
 	"member": {
 		Type:             schema.TypeString,
@@ -42,9 +43,10 @@ var IamMemberBaseSchema = map[string]*schema.Schema{
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"expression": {
-					Type:     schema.TypeString,
-					Required: true,
-					ForceNew: true,
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     true,
+					ValidateFunc: validateIamConditionExpression,
 				},
 				"title": {
 					Type:     schema.TypeString,
@@ -109,7 +111,7 @@ func iamMemberImport(newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser
 		if err != nil {
 			return nil, err
 		}
-		p, err := iamPolicyReadWithRetry(updater)
+		p, err := iamPolicyReadWithRetry(config, updater)
 		if err != nil {
 			return nil, err
 		}
@@ -153,10 +155,11 @@ func ResourceIamMember(parentSpecificSchema map[string]*schema.Schema, newUpdate
 
 func ResourceIamMemberWithBatching(parentSpecificSchema map[string]*schema.Schema, newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser resourceIdParserFunc, enableBatching bool) *schema.Resource {
 	return &schema.Resource{
-		Create: resourceIamMemberCreate(newUpdaterFunc, enableBatching),
-		Read:   resourceIamMemberRead(newUpdaterFunc),
-		Delete: resourceIamMemberDelete(newUpdaterFunc, enableBatching),
-		Schema: mergeSchemas(IamMemberBaseSchema, parentSpecificSchema),
+		Create:        resourceIamMemberCreate(newUpdaterFunc, enableBatching),
+		Read:          resourceIamMemberRead(newUpdaterFunc),
+		Delete:        resourceIamMemberDelete(newUpdaterFunc, enableBatching),
+		Schema:        mergeSchemas(IamMemberBaseSchema, parentSpecificSchema),
+		CustomizeDiff: iamGuardrailsCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: iamMemberImport(newUpdaterFunc, resourceIdParser),
 		},
@@ -195,7 +198,7 @@ func resourceIamMemberCreate(newUpdaterFunc newResourceIamUpdaterFunc, enableBat
 			err = BatchRequestModifyIamPolicy(updater, modifyF, config,
 				fmt.Sprintf("Create IAM Members %s %+v for %q", memberBind.Role, memberBind.Members[0], updater.DescribeResource()))
 		} else {
-			err = iamPolicyReadModifyWrite(updater, modifyF)
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
 		}
 		if err != nil {
 			return err
@@ -219,7 +222,7 @@ func resourceIamMemberRead(newUpdaterFunc newResourceIamUpdaterFunc) schema.Read
 
 		eMember := getResourceIamMember(d)
 		eCondition := conditionKeyFromCondition(eMember.Condition)
-		p, err := iamPolicyReadWithRetry(updater)
+		p, err := iamPolicyReadWithRetry(config, updater)
 		if err != nil {
 			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q with IAM Member: Role %q Member %q", updater.DescribeResource(), eMember.Role, eMember.Members[0]))
 		}
@@ -289,7 +292,7 @@ func resourceIamMemberDelete(newUpdaterFunc newResourceIamUpdaterFunc, enableBat
 			err = BatchRequestModifyIamPolicy(updater, modifyF, config,
 				fmt.Sprintf("Delete IAM Members %s %s for %q", memberBind.Role, memberBind.Members[0], updater.DescribeResource()))
 		} else {
-			err = iamPolicyReadModifyWrite(updater, modifyF)
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
 		}
 		if err != nil {
 			return handleNotFoundError(err, d, fmt.Sprintf("Resource %s for IAM Member (role %q, %q)", updater.GetResourceId(), memberBind.Members[0], memberBind.Role))
@@ -0,0 +1,185 @@
+package google
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// ResourceIamMembers returns an additive IAM resource managing a set of members
+// for a role (and condition). Unlike ResourceIamBinding, it only adds and
+// removes the members it manages, leaving members added outside of the
+// resource bound to the role.
+func ResourceIamMembers(parentSpecificSchema map[string]*schema.Schema, newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser resourceIdParserFunc) *schema.Resource {
+	return ResourceIamMembersWithBatching(parentSpecificSchema, newUpdaterFunc, resourceIdParser, IamBatchingDisabled)
+}
+
+// Resource that batches requests to the same IAM policy across multiple IAM fine-grained resources
+func ResourceIamMembersWithBatching(parentSpecificSchema map[string]*schema.Schema, newUpdaterFunc newResourceIamUpdaterFunc, resourceIdParser resourceIdParserFunc, enableBatching bool) *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceIamMembersCreateUpdate(newUpdaterFunc, enableBatching),
+		Read:          resourceIamMembersRead(newUpdaterFunc),
+		Update:        resourceIamMembersCreateUpdate(newUpdaterFunc, enableBatching),
+		Delete:        resourceIamMembersDelete(newUpdaterFunc, enableBatching),
+		Schema:        mergeSchemas(iamBindingSchema, parentSpecificSchema),
+		CustomizeDiff: iamGuardrailsCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			// Imported resources don't manage any members until they're
+			// applied, as there's no way to tell which of the role's members
+			// should be managed.
+			State: iamBindingImport(newUpdaterFunc, resourceIdParser),
+		},
+		UseJSONNumber: true,
+	}
+}
+
+// getResourceIamMembersChange returns the bindings of members to add to and
+// remove from the policy for the resource's planned members.
+func getResourceIamMembersChange(d *schema.ResourceData) (add, remove *cloudresourcemanager.Binding) {
+	o, n := d.GetChange("members")
+	removed := o.(*schema.Set).Difference(n.(*schema.Set)).List()
+
+	add = getResourceIamBinding(d)
+	remove = &cloudresourcemanager.Binding{
+		Members:   convertStringArr(removed),
+		Role:      add.Role,
+		Condition: add.Condition,
+	}
+	return add, remove
+}
+
+// modifyIamMembers returns a modifier adding and removing members from a
+// binding, leaving the binding's other members as they are.
+func modifyIamMembers(add, remove *cloudresourcemanager.Binding) iamPolicyModifyFunc {
+	return func(ep *cloudresourcemanager.Policy) error {
+		bindings := ep.Bindings
+		if len(remove.Members) > 0 {
+			bindings = subtractFromBindings(bindings, remove)
+		}
+		if len(add.Members) > 0 {
+			bindings = mergeBindings(append(bindings, add))
+		}
+		ep.Bindings = bindings
+		ep.Version = iamPolicyVersion
+		return nil
+	}
+}
+
+func resourceIamMembersCreateUpdate(newUpdaterFunc newResourceIamUpdaterFunc, enableBatching bool) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		config := meta.(*Config)
+		updater, err := newUpdaterFunc(d, config)
+		if err != nil {
+			return err
+		}
+
+		add, remove := getResourceIamMembersChange(d)
+		modifyF := modifyIamMembers(add, remove)
+
+		if enableBatching {
+			err = BatchRequestModifyIamPolicy(updater, modifyF, config, fmt.Sprintf(
+				"Set IAM Members for role %q on %q", add.Role, updater.DescribeResource()))
+		} else {
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
+		}
+		if err != nil {
+			return err
+		}
+
+		d.SetId(updater.GetResourceId() + "/" + add.Role)
+		if k := conditionKeyFromCondition(add.Condition); !k.Empty() {
+			d.SetId(d.Id() + "/" + k.String())
+		}
+		return resourceIamMembersRead(newUpdaterFunc)(d, meta)
+	}
+}
+
+func resourceIamMembersRead(newUpdaterFunc newResourceIamUpdaterFunc) schema.ReadFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		config := meta.(*Config)
+
+		updater, err := newUpdaterFunc(d, config)
+		if err != nil {
+			return err
+		}
+
+		eBinding := getResourceIamBinding(d)
+		eCondition := conditionKeyFromCondition(eBinding.Condition)
+		p, err := iamPolicyReadWithRetry(config, updater)
+		if err != nil {
+			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q with IAM Members (Role %q)", updater.DescribeResource(), eBinding.Role))
+		}
+		log.Print(spew.Sprintf("[DEBUG] Retrieved policy for %s: %#v", updater.DescribeResource(), p))
+		log.Printf("[DEBUG] Looking for binding with role %q and condition %#v", eBinding.Role, eCondition)
+
+		var binding *cloudresourcemanager.Binding
+		for _, b := range p.Bindings {
+			if b.Role == eBinding.Role && conditionKeyFromCondition(b.Condition) == eCondition {
+				binding = b
+				break
+			}
+		}
+
+		// Only the members managed by this resource are read, so members bound
+		// to the role outside of Terraform don't show as a diff. Managed
+		// members removed outside of Terraform are dropped, to be added back.
+		members := []string{}
+		if binding == nil {
+			log.Printf("[DEBUG] Binding for role %q and condition %#v not found in policy for %s, assuming it has no members.", eBinding.Role, eCondition, updater.DescribeResource())
+		} else {
+			managed := make(map[string]struct{}, len(eBinding.Members))
+			for _, m := range eBinding.Members {
+				managed[strings.ToLower(m)] = struct{}{}
+			}
+			for _, m := range binding.Members {
+				if _, ok := managed[strings.ToLower(m)]; ok {
+					members = append(members, m)
+				}
+			}
+			if err := d.Set("condition", flattenIamCondition(binding.Condition)); err != nil {
+				return fmt.Errorf("Error setting condition: %s", err)
+			}
+		}
+
+		if err := d.Set("role", eBinding.Role); err != nil {
+			return fmt.Errorf("Error setting role: %s", err)
+		}
+		if err := d.Set("members", members); err != nil {
+			return fmt.Errorf("Error setting members: %s", err)
+		}
+		if err := d.Set("etag", p.Etag); err != nil {
+			return fmt.Errorf("Error setting etag: %s", err)
+		}
+		return nil
+	}
+}
+
+func resourceIamMembersDelete(newUpdaterFunc newResourceIamUpdaterFunc, enableBatching bool) schema.DeleteFunc {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		config := meta.(*Config)
+
+		updater, err := newUpdaterFunc(d, config)
+		if err != nil {
+			return err
+		}
+
+		binding := getResourceIamBinding(d)
+		modifyF := modifyIamMembers(&cloudresourcemanager.Binding{Role: binding.Role}, binding)
+
+		if enableBatching {
+			err = BatchRequestModifyIamPolicy(updater, modifyF, config, fmt.Sprintf(
+				"Delete IAM Members for role %q on %q", binding.Role, updater.DescribeResource()))
+		} else {
+			err = iamPolicyReadModifyWrite(config, updater, modifyF)
+		}
+		if err != nil {
+			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q for IAM members with role %q", updater.DescribeResource(), binding.Role))
+		}
+
+		return nil
+	}
+}
@@ -44,7 +44,8 @@ func ResourceIamPolicy(parentSpecificSchema map[string]*schema.Schema, newUpdate
 		Update: ResourceIamPolicyUpdate(newUpdaterFunc),
 		Delete: ResourceIamPolicyDelete(newUpdaterFunc),
 
-		Schema: mergeSchemas(IamPolicyBaseSchema, parentSpecificSchema),
+		Schema:        mergeSchemas(IamPolicyBaseSchema, parentSpecificSchema),
+		CustomizeDiff: iamGuardrailsCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: iamPolicyImport(resourceIdParser),
 		},
@@ -61,7 +62,7 @@ func ResourceIamPolicyCreate(newUpdaterFunc newResourceIamUpdaterFunc) schema.Cr
 			return err
 		}
 
-		if err = setIamPolicyData(d, updater); err != nil {
+		if err = setIamPolicyData(d, config, updater); err != nil {
 			return err
 		}
 
@@ -79,7 +80,7 @@ func ResourceIamPolicyRead(newUpdaterFunc newResourceIamUpdaterFunc) schema.Read
 			return err
 		}
 
-		policy, err := iamPolicyReadWithRetry(updater)
+		policy, err := iamPolicyReadWithRetry(config, updater)
 		if err != nil {
 			return handleNotFoundError(err, d, fmt.Sprintf("Resource %q with IAM Policy", updater.DescribeResource()))
 		}
@@ -105,7 +106,7 @@ func ResourceIamPolicyUpdate(newUpdaterFunc newResourceIamUpdaterFunc) schema.Up
 		}
 
 		if d.HasChange("policy_data") {
-			if err := setIamPolicyData(d, updater); err != nil {
+			if err := setIamPolicyData(d, config, updater); err != nil {
 				return err
 			}
 		}
@@ -138,11 +139,14 @@ func ResourceIamPolicyDelete(newUpdaterFunc newResourceIamUpdaterFunc) schema.De
 	}
 }
 
-func setIamPolicyData(d *schema.ResourceData, updater ResourceIamUpdater) error {
+func setIamPolicyData(d *schema.ResourceData, config *Config, updater ResourceIamUpdater) error {
 	policy, err := unmarshalIamPolicy(d.Get("policy_data").(string))
 	if err != nil {
 		return fmt.Errorf("'policy_data' is not valid for %s: %s", updater.DescribeResource(), err)
 	}
+	if err := config.IamGuardrails.checkBindings(policy.Bindings); err != nil {
+		return err
+	}
 	policy.Version = iamPolicyVersion
 
 	err = updater.SetResourceIamPolicy(policy)
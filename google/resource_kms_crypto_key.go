@@ -306,7 +306,7 @@ func resourceKMSCryptoKeyUpdate(d *schema.ResourceData, meta interface{}) error
 	log.Printf("[DEBUG] Updating CryptoKey %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
@@ -674,7 +674,7 @@ func resourcePubsubSubscriptionUpdate(d *schema.ResourceData, meta interface{})
 	log.Printf("[DEBUG] Updating Subscription %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
@@ -393,7 +393,7 @@ func resourceSecretManagerSecretUpdate(d *schema.ResourceData, meta interface{})
 	log.Printf("[DEBUG] Updating Secret %q: %#v", d.Id(), obj)
 	updateMask := []string{}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		updateMask = append(updateMask, "labels")
 	}
 
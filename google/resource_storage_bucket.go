@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gammazero/workerpool"
@@ -33,6 +34,7 @@ func resourceStorageBucket() *schema.Resource {
 		},
 		CustomizeDiff: customdiff.All(
 			customdiff.ForceNewIfChange("retention_policy.0.is_locked", isPolicyLocked),
+			validateForceDestroyOptions,
 		),
 
 		Schema: map[string]*schema.Schema{
@@ -72,6 +74,42 @@ func resourceStorageBucket() *schema.Resource {
 				Description: `When deleting a bucket, this boolean option will delete all contained objects. If you try to delete a bucket that contains objects, Terraform will fail that run.`,
 			},
 
+			"force_destroy_options": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"concurrency": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  `The number of objects deleted at the same time. Defaults to the number of CPUs minus one.`,
+						},
+						"rate_limit": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, forceDestroyMaxRateLimit),
+							Description:  `The maximum number of objects deleted per second, up to 10000. Defaults to no limit.`,
+						},
+						"mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      forceDestroyModeAll,
+							ValidateFunc: validation.StringInSlice([]string{forceDestroyModeAll, forceDestroyModeNoncurrentVersions, forceDestroyModePrefixes}, false),
+							Description:  `Which objects force_destroy deletes: ALL of them, only NONCURRENT_VERSIONS, or the objects under PREFIXES. Deleting the bucket fails if objects are left.`,
+						},
+						"prefixes": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: `The prefixes of the objects deleted when mode is PREFIXES.`,
+						},
+					},
+				},
+				Description: `Settings for deleting the bucket's objects when force_destroy is true.`,
+			},
+
 			"labels": {
 				Type:        schema.TypeMap,
 				Optional:    true,
@@ -561,18 +599,24 @@ func resourceStorageBucketUpdate(d *schema.ResourceData, meta interface{}) error
 		}
 	}
 
-	if d.HasChange("labels") {
+	if labelsChanged(d) {
 		sb.Labels = expandLabels(d)
 		if len(sb.Labels) == 0 {
 			sb.NullFields = append(sb.NullFields, "Labels")
 		}
 
 		// To delete a label using PATCH, we have to explicitly set its value
-		// to null.
-		old, _ := d.GetChange("labels")
-		for k := range old.(map[string]interface{}) {
-			if _, ok := sb.Labels[k]; !ok {
-				sb.NullFields = append(sb.NullFields, fmt.Sprintf("Labels.%s", k))
+		// to null. Default labels removed from the provider are only in the
+		// old terraform_labels.
+		oldLabels, _ := d.GetChange("labels")
+		oldTerraformLabels, _ := d.GetChange(terraformLabelsKey)
+		removed := make(map[string]bool)
+		for _, old := range []interface{}{oldLabels, oldTerraformLabels} {
+			for k := range old.(map[string]interface{}) {
+				if _, ok := sb.Labels[k]; !ok && !removed[k] {
+					removed[k] = true
+					sb.NullFields = append(sb.NullFields, fmt.Sprintf("Labels.%s", k))
+				}
 			}
 		}
 	}
@@ -747,10 +791,42 @@ func resourceStorageBucketDelete(d *schema.ResourceData, meta interface{}) error
 
 	// Get the bucket
 	bucket := d.Get("name").(string)
+	opts := expandForceDestroyOptions(d)
 
 	var listError, deleteObjectError error
 	for deleteObjectError == nil {
-		res, err := config.NewStorageClient(userAgent).Objects.List(bucket).Versions(true).Do()
+		// The objects are checked a page at a time before any is deleted, as
+		// buckets can hold more objects than fit in memory.
+		var holds bucketObjectHolds
+		var checkErr error
+		err := forEachBucketObjectsPageToDestroy(config, userAgent, bucket, opts, func(objects []*storage.Object) error {
+			if d.Get("retention_policy.0.is_locked").(bool) {
+				for _, item := range objects {
+					expiration, err := time.Parse(time.RFC3339, item.RetentionExpirationTime)
+					if err != nil {
+						checkErr = err
+						return err
+					}
+					if expiration.After(time.Now()) {
+						checkErr = errors.New("Bucket '" + d.Get("name").(string) + "' contains objects that have not met the retention period yet and cannot be deleted.")
+						log.Printf("Error! %s : %s\n\n", bucket, checkErr)
+						return checkErr
+					}
+				}
+			}
+
+			if !d.Get("force_destroy").(bool) {
+				checkErr = fmt.Errorf("Error trying to delete bucket %s containing objects without `force_destroy` set to true", bucket)
+				log.Printf("Error! %s : %s\n\n", bucket, checkErr)
+				return checkErr
+			}
+
+			holds.add(objects)
+			return nil
+		})
+		if checkErr != nil {
+			return checkErr
+		}
 		if err != nil {
 			log.Printf("Error listing contents of bucket %s: %v", bucket, err)
 			// If we can't list the contents, try deleting the bucket anyway in case it's empty
@@ -758,62 +834,20 @@ func resourceStorageBucketDelete(d *schema.ResourceData, meta interface{}) error
 			break
 		}
 
-		if len(res.Items) == 0 {
+		if holds.total == 0 {
 			break // 0 items, bucket empty
 		}
 
-		if d.Get("retention_policy.0.is_locked").(bool) {
-			for _, item := range res.Items {
-				expiration, err := time.Parse(time.RFC3339, item.RetentionExpirationTime)
-				if err != nil {
-					return err
-				}
-				if expiration.After(time.Now()) {
-					deleteErr := errors.New("Bucket '" + d.Get("name").(string) + "' contains objects that have not met the retention period yet and cannot be deleted.")
-					log.Printf("Error! %s : %s\n\n", bucket, deleteErr)
-					return deleteErr
-				}
-			}
+		// Held objects can't be deleted, so nothing is deleted unless every
+		// object can be.
+		if err := holds.err(bucket); err != nil {
+			return err
 		}
 
-		if !d.Get("force_destroy").(bool) {
-			deleteErr := fmt.Errorf("Error trying to delete bucket %s containing objects without `force_destroy` set to true", bucket)
-			log.Printf("Error! %s : %s\n\n", bucket, deleteErr)
-			return deleteErr
-		}
 		// GCS requires that a bucket be empty (have no objects or object
 		// versions) before it can be deleted.
 		log.Printf("[DEBUG] GCS Bucket attempting to forceDestroy\n\n")
-
-		// Create a workerpool for parallel deletion of resources. In the
-		// future, it would be great to expose Terraform's global parallelism
-		// flag here, but that's currently reserved for core use. Testing
-		// shows that NumCPUs-1 is the most performant on average networks.
-		//
-		// The challenge with making this user-configurable is that the
-		// configuration would reside in the Terraform configuration file,
-		// decreasing its portability. Ideally we'd want this to connect to
-		// Terraform's top-level -parallelism flag, but that's not plumbed nor
-		// is it scheduled to be plumbed to individual providers.
-		wp := workerpool.New(runtime.NumCPU() - 1)
-
-		for _, object := range res.Items {
-			log.Printf("[DEBUG] Found %s", object.Name)
-			object := object
-
-			wp.Submit(func() {
-				log.Printf("[TRACE] Attempting to delete %s", object.Name)
-				if err := config.NewStorageClient(userAgent).Objects.Delete(bucket, object.Name).Generation(object.Generation).Do(); err != nil {
-					deleteObjectError = err
-					log.Printf("[ERR] Failed to delete storage object %s: %s", object.Name, err)
-				} else {
-					log.Printf("[TRACE] Successfully deleted %s", object.Name)
-				}
-			})
-		}
-
-		// Wait for everything to finish.
-		wp.StopWait()
+		deleteObjectError = deleteBucketObjects(config, userAgent, bucket, holds.total, opts)
 	}
 
 	// remove empty bucket
@@ -833,6 +867,9 @@ func resourceStorageBucketDelete(d *schema.ResourceData, meta interface{}) error
 	if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 409 && strings.Contains(gerr.Message, "not empty") && deleteObjectError != nil {
 		return fmt.Errorf("could not delete non-empty bucket due to error when deleting contents: %v", deleteObjectError)
 	}
+	if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 409 && strings.Contains(gerr.Message, "not empty") && d.Get("force_destroy").(bool) && opts.mode != forceDestroyModeAll {
+		return fmt.Errorf("could not delete bucket %s: it contains objects that force_destroy_options.0.mode %s doesn't delete", bucket, opts.mode)
+	}
 	if err != nil {
 		log.Printf("Error deleting bucket %s: %v", bucket, err)
 		return err
@@ -842,6 +879,207 @@ func resourceStorageBucketDelete(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
+const (
+	forceDestroyModeAll                = "ALL"
+	forceDestroyModeNoncurrentVersions = "NONCURRENT_VERSIONS"
+	forceDestroyModePrefixes           = "PREFIXES"
+
+	// How often the progress of deleting a bucket's objects is logged.
+	forceDestroyProgressInterval = 1000
+	// The number of held objects listed in the error deleting a bucket.
+	forceDestroyMaxHeldObjects = 10
+	// The highest rate_limit, which keeps the interval between two deletes
+	// well above zero.
+	forceDestroyMaxRateLimit = 10000
+)
+
+// forceDestroyOptions are the settings of force_destroy_options.
+type forceDestroyOptions struct {
+	concurrency int
+	// rateLimit is the maximum number of objects deleted per second, or 0.
+	rateLimit int
+	mode      string
+	prefixes  []string
+}
+
+func expandForceDestroyOptions(d *schema.ResourceData) forceDestroyOptions {
+	// Testing shows that NumCPUs-1 is the most performant on average
+	// networks.
+	opts := forceDestroyOptions{
+		concurrency: runtime.NumCPU() - 1,
+		mode:        forceDestroyModeAll,
+	}
+	if v, ok := d.GetOk("force_destroy_options"); ok && v.([]interface{})[0] != nil {
+		raw := v.([]interface{})[0].(map[string]interface{})
+		if c := raw["concurrency"].(int); c > 0 {
+			opts.concurrency = c
+		}
+		opts.rateLimit = raw["rate_limit"].(int)
+		opts.mode = raw["mode"].(string)
+		opts.prefixes = convertStringArr(raw["prefixes"].([]interface{}))
+	}
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+	// Without force_destroy, any object stops the bucket from being deleted.
+	if !d.Get("force_destroy").(bool) {
+		opts.mode = forceDestroyModeAll
+	}
+	return opts
+}
+
+func validateForceDestroyOptions(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	mode := d.Get("force_destroy_options.0.mode").(string)
+	prefixes := d.Get("force_destroy_options.0.prefixes").([]interface{})
+	if mode == forceDestroyModePrefixes && len(prefixes) == 0 && d.NewValueKnown("force_destroy_options.0.prefixes") {
+		return fmt.Errorf("force_destroy_options.0.prefixes must be set when force_destroy_options.0.mode is %s", forceDestroyModePrefixes)
+	}
+	if mode != forceDestroyModePrefixes && len(prefixes) > 0 {
+		return fmt.Errorf("force_destroy_options.0.prefixes can only be set when force_destroy_options.0.mode is %s", forceDestroyModePrefixes)
+	}
+	return nil
+}
+
+// forEachBucketObjectsPageToDestroy calls f with each page of the object
+// versions of bucket that force_destroy deletes, stopping at the first error
+// f returns.
+func forEachBucketObjectsPageToDestroy(config *Config, userAgent, bucket string, opts forceDestroyOptions, f func([]*storage.Object) error) error {
+	prefixes := []string{""}
+	if opts.mode == forceDestroyModePrefixes {
+		prefixes = opts.prefixes
+	}
+
+	for _, prefix := range prefixes {
+		call := config.NewStorageClient(userAgent).Objects.List(bucket).Versions(true)
+		if prefix != "" {
+			call.Prefix(prefix)
+		}
+		err := call.Pages(config.context, func(res *storage.Objects) error {
+			objects := make([]*storage.Object, 0, len(res.Items))
+			for _, object := range res.Items {
+				// Noncurrent versions are the ones that were deleted.
+				if opts.mode == forceDestroyModeNoncurrentVersions && object.TimeDeleted == "" {
+					continue
+				}
+				objects = append(objects, object)
+			}
+			if len(objects) == 0 {
+				return nil
+			}
+			return f(objects)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bucketObjectHolds counts the objects added to it, and the ones under a
+// hold, keeping the names of the first few held ones.
+type bucketObjectHolds struct {
+	total int
+	count int
+	held  []string
+}
+
+func (h *bucketObjectHolds) add(objects []*storage.Object) {
+	for _, object := range objects {
+		h.total++
+		var holds []string
+		if object.TemporaryHold {
+			holds = append(holds, "temporary hold")
+		}
+		if object.EventBasedHold {
+			holds = append(holds, "event-based hold")
+		}
+		if len(holds) == 0 {
+			continue
+		}
+		h.count++
+		if len(h.held) < forceDestroyMaxHeldObjects {
+			h.held = append(h.held, fmt.Sprintf("%s (%s)", object.Name, strings.Join(holds, ", ")))
+		}
+	}
+}
+
+// err returns an error summarizing the objects that are under a hold, if
+// any.
+func (h *bucketObjectHolds) err(bucket string) error {
+	if h.count == 0 {
+		return nil
+	}
+
+	summary := h.held
+	if h.count > len(h.held) {
+		summary = append(h.held[:len(h.held):len(h.held)], fmt.Sprintf("and %d more", h.count-len(h.held)))
+	}
+	return fmt.Errorf("Error trying to delete bucket %s: %d of its %d objects are under a hold, and no objects were deleted. Release the holds to delete the bucket:\n  %s", bucket, h.count, h.total, strings.Join(summary, "\n  "))
+}
+
+// deleteBucketObjects deletes the objects force_destroy deletes from bucket a
+// page at a time, each page in parallel, and returns the first error listing
+// or deleting one. total is the number of objects expected, for logging
+// progress.
+func deleteBucketObjects(config *Config, userAgent, bucket string, total int, opts forceDestroyOptions) error {
+	// The rate limit is shared by the workers, which each wait for a tick
+	// before deleting an object.
+	var ticks <-chan time.Time
+	if opts.rateLimit > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.rateLimit))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var mu sync.Mutex
+	var deleted, failed int
+	var deleteErr error
+	log.Printf("[INFO] Deleting %d objects from bucket %s, %d at a time", total, bucket, opts.concurrency)
+	listErr := forEachBucketObjectsPageToDestroy(config, userAgent, bucket, opts, func(objects []*storage.Object) error {
+		wp := workerpool.New(opts.concurrency)
+		for _, object := range objects {
+			object := object
+			wp.Submit(func() {
+				if ticks != nil {
+					<-ticks
+				}
+				log.Printf("[TRACE] Attempting to delete %s", object.Name)
+				err := config.NewStorageClient(userAgent).Objects.Delete(bucket, object.Name).Generation(object.Generation).Do()
+				if isGoogleApiErrorWithCode(err, 404) {
+					err = nil
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failed++
+					if deleteErr == nil {
+						deleteErr = err
+					}
+					log.Printf("[ERR] Failed to delete storage object %s: %s", object.Name, err)
+					return
+				}
+				deleted++
+				if deleted%forceDestroyProgressInterval == 0 {
+					log.Printf("[INFO] Deleted %d of %d objects from bucket %s", deleted, total, bucket)
+				}
+			})
+		}
+
+		// Wait for the page to finish before listing the next one.
+		wp.StopWait()
+		return deleteErr
+	})
+	log.Printf("[INFO] Deleted %d of %d objects from bucket %s, %d failed", deleted, total, bucket, failed)
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if listErr != nil {
+		return fmt.Errorf("Error listing objects of bucket %s: %s", bucket, listErr)
+	}
+	return nil
+}
+
 func resourceStorageBucketStateImporter(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 	// We need to support project/bucket_name and bucket_name formats. This will allow
 	// importing a bucket that is in a different project than the provider default.
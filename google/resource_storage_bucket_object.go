@@ -2,13 +2,16 @@ package google
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"crypto/md5"
 	"encoding/base64"
@@ -25,6 +28,10 @@ func resourceStorageBucketObject() *schema.Resource {
 		Update: resourceStorageBucketObjectUpdate,
 		Delete: resourceStorageBucketObjectDelete,
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"bucket": {
 				Type:        schema.TypeString,
@@ -120,6 +127,14 @@ func resourceStorageBucketObject() *schema.Resource {
 				// 2. Compare the computed md5 hash with the hash stored in Cloud Storage
 				// 3. Don't suppress the diff iff they don't match
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					// Composite objects don't have an MD5 hash, so their
+					// CRC32C hash is compared instead.
+					if old == "" && d.Get("crc32c").(string) != "" {
+						if source, ok := d.GetOkExists("source"); ok {
+							return getFileCrc32cHash(source.(string)) == d.Get("crc32c").(string)
+						}
+					}
+
 					localMd5Hash := ""
 					if source, ok := d.GetOkExists("source"); ok {
 						localMd5Hash = getFileMd5Hash(source.(string))
@@ -179,6 +194,26 @@ func resourceStorageBucketObject() *schema.Resource {
 				Description: `User-provided metadata, in key/value pairs.`,
 			},
 
+			"chunk_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.All(validation.IntAtLeast(storageUploadChunkGranularity), validation.IntDivisibleBy(storageUploadChunkGranularity)),
+				Description:  `The size in bytes of the chunks a source larger than it is uploaded in, with a resumable upload that continues after transient errors. Must be a multiple of 262144 (256 KiB). Defaults to 16 MiB.`,
+			},
+
+			"parallel_composite_upload": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: `Whether to upload a source larger than chunk_size as parts of chunk_size in parallel, which are then composed into the object. Composite objects don't have an MD5 hash.`,
+			},
+
+			"upload_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  `The number of parts of a parallel composite upload uploaded at the same time. Defaults to 4.`,
+			},
+
 			"self_link": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -228,13 +263,21 @@ func resourceStorageBucketObjectCreate(d *schema.ResourceData, meta interface{})
 	bucket := d.Get("bucket").(string)
 	name := d.Get("name").(string)
 	var media io.Reader
+	var source *os.File
+	var size int64
 
 	if v, ok := d.GetOk("source"); ok {
 		var err error
-		media, err = os.Open(v.(string))
+		source, err = os.Open(v.(string))
 		if err != nil {
 			return err
 		}
+		defer source.Close()
+		info, err := source.Stat()
+		if err != nil {
+			return err
+		}
+		media, size = source, info.Size()
 	} else if v, ok := d.GetOk("content"); ok {
 		media = bytes.NewReader([]byte(v.(string)))
 	} else {
@@ -284,6 +327,41 @@ func resourceStorageBucketObjectCreate(d *schema.ResourceData, meta interface{})
 		object.TemporaryHold = v.(bool)
 	}
 
+	chunkSize := int64(googleapi.DefaultUploadChunkSize)
+	if v, ok := d.GetOk("chunk_size"); ok {
+		chunkSize = int64(v.(int))
+	}
+
+	// Sources larger than a chunk are uploaded by the provider, rather than
+	// the client library, which gives up on a chunk after 32 seconds of
+	// retries.
+	if source != nil && size > chunkSize {
+		ctx, cancel := context.WithTimeout(config.context, d.Timeout(schema.TimeoutCreate))
+		defer cancel()
+		uploader := &storageObjectUploader{
+			config:      config,
+			userAgent:   userAgent,
+			ctx:         ctx,
+			bucket:      bucket,
+			chunkSize:   chunkSize,
+			concurrency: 4,
+		}
+		if v, ok := d.GetOk("upload_concurrency"); ok {
+			uploader.concurrency = v.(int)
+		}
+
+		object.Name = name
+		if d.Get("parallel_composite_upload").(bool) {
+			_, err = uploader.uploadComposite(object, source, size)
+		} else {
+			_, err = uploader.uploadResumable(object, source, size)
+		}
+		if err != nil {
+			return err
+		}
+		return resourceStorageBucketObjectRead(d, meta)
+	}
+
 	insertCall := objectsService.Insert(bucket, object)
 	insertCall.Name(name)
 	insertCall.Media(media)
@@ -307,6 +385,11 @@ func resourceStorageBucketObjectUpdate(d *schema.ResourceData, meta interface{})
 	bucket := d.Get("bucket").(string)
 	name := d.Get("name").(string)
 
+	// The upload settings only apply when the object is created.
+	if !d.HasChanges("event_based_hold", "temporary_hold") {
+		return nil
+	}
+
 	objectsService := storage.NewObjectsService(config.NewStorageClient(userAgent))
 	getCall := objectsService.Get(bucket, name)
 
@@ -448,6 +531,22 @@ func getFileMd5Hash(filename string) string {
 	return getContentMd5Hash(data)
 }
 
+func getFileCrc32cHash(filename string) string {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Printf("[WARN] Failed to read source file %q. Cannot compute crc32c hash for it.", filename)
+		return ""
+	}
+	defer f.Close()
+
+	hash, err := storageCrc32c(f)
+	if err != nil {
+		log.Printf("[WARN] Failed to compute crc32c hash for %q: %v", filename, err)
+		return ""
+	}
+	return hash
+}
+
 func getContentMd5Hash(content []byte) string {
 	h := md5.New()
 	if _, err := h.Write(content); err != nil {
@@ -1,10 +1,13 @@
 package google
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -359,6 +362,80 @@ func TestAccStorageObject_holds(t *testing.T) {
 	})
 }
 
+func TestFakeGoogleApi_storageBucketObjectUploads(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	testFakeApiApply(t, config, "google_storage_bucket", nil, map[string]interface{}{
+		"name":     "my-bucket",
+		"location": "US",
+	})
+
+	// 34 chunks of 256 KiB, the last one partial, are composed in two
+	// batches.
+	data := make([]byte, 33*storageUploadChunkGranularity+1000)
+	rand.New(rand.NewSource(1)).Read(data)
+	testFile := getNewTmpTestFile(t, "tf-test-upload")
+	defer os.Remove(testFile.Name())
+	if _, err := testFile.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	testFile.Close()
+
+	// Resumable uploads continue after errors that stored part of a chunk.
+	api.mu.Lock()
+	api.uploadFailures = 3
+	api.mu.Unlock()
+	resumable := testFakeApiApply(t, config, "google_storage_bucket_object", nil, map[string]interface{}{
+		"bucket":     "my-bucket",
+		"name":       "resumable",
+		"source":     testFile.Name(),
+		"chunk_size": 4 * storageUploadChunkGranularity,
+	})
+	if media, _ := api.storageObjectMedia("my-bucket", "resumable"); !bytes.Equal(media, data) {
+		t.Errorf("expected the resumable upload to upload the file, got %d bytes", len(media))
+	}
+	api.mu.Lock()
+	if api.uploadFailures != 0 {
+		t.Errorf("expected the upload to fail 3 times, %d failures are left", api.uploadFailures)
+	}
+	api.mu.Unlock()
+	if got, expected := resumable.Attributes["md5hash"], getContentMd5Hash(data); got != expected {
+		t.Errorf("expected md5hash %s, got %s", expected, got)
+	}
+
+	composite := testFakeApiApply(t, config, "google_storage_bucket_object", nil, map[string]interface{}{
+		"bucket":                    "my-bucket",
+		"name":                      "composite",
+		"source":                    testFile.Name(),
+		"content_type":              "application/octet-stream",
+		"chunk_size":                storageUploadChunkGranularity,
+		"parallel_composite_upload": true,
+		"upload_concurrency":        3,
+	})
+	if media, _ := api.storageObjectMedia("my-bucket", "composite"); !bytes.Equal(media, data) {
+		t.Errorf("expected the composite upload to upload the file, got %d bytes", len(media))
+	}
+	obj, _ := api.get("storage/v1/b/my-bucket/o/composite")
+	if obj["componentCount"] != float64(34) || obj["contentType"] != "application/octet-stream" {
+		t.Errorf("expected a composite object of 34 components, got %v", obj)
+	}
+	if got := composite.Attributes["crc32c"]; got != getFileCrc32cHash(testFile.Name()) {
+		t.Errorf("expected the crc32c of the file, got %s", got)
+	}
+	api.mu.Lock()
+	for k := range api.resources {
+		if strings.Contains(k, ".tfpart-") {
+			t.Errorf("expected the parts to be deleted, found %s", k)
+		}
+	}
+	api.mu.Unlock()
+
+	testFakeApiDestroy(t, config, "google_storage_bucket_object", resumable)
+	testFakeApiDestroy(t, config, "google_storage_bucket_object", composite)
+}
+
 func testAccCheckGoogleStorageObject(t *testing.T, bucket, object, md5 string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		config := googleProviderConfig(t)
@@ -0,0 +1,601 @@
+package google
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gammazero/workerpool"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/storage/v1"
+)
+
+func resourceStorageBucketObjectsSync() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceStorageBucketObjectsSyncCreate,
+		Read:   resourceStorageBucketObjectsSyncRead,
+		Update: resourceStorageBucketObjectsSyncUpdate,
+		Delete: resourceStorageBucketObjectsSyncDelete,
+
+		CustomizeDiff: resourceStorageBucketObjectsSyncDiff,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: `The name of the bucket to sync the files to.`,
+			},
+
+			"source_dir": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: `The local directory to sync to the bucket.`,
+			},
+
+			"prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: `A prefix added to the names of the objects, e.g. "assets/". Objects are named with the prefix followed by the path of their file relative to source_dir.`,
+			},
+
+			"include": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateSyncGlob},
+				Description: `Globs of the files to sync, relative to source_dir, e.g. "**/*.html". Defaults to every file.`,
+			},
+
+			"exclude": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateSyncGlob},
+				Description: `Globs of the files not to sync, relative to source_dir. Takes precedence over include.`,
+			},
+
+			"cache_control": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: `The Cache-Control directive of the objects whose files match a glob. The first matching pattern is used.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pattern": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateSyncGlob,
+							Description:  `A glob of files, relative to source_dir.`,
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: `The Cache-Control directive, e.g. "public, max-age=3600".`,
+						},
+					},
+				},
+			},
+
+			"parallelism": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  `The number of objects to upload or delete at the same time.`,
+			},
+
+			"manifest": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: `The objects synced to the bucket.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"md5hash": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"crc32c": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"content_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"cache_control": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+// syncObject is an entry of the manifest of a google_storage_bucket_objects_sync.
+type syncObject struct {
+	Name         string
+	Md5Hash      string
+	Crc32c       string
+	ContentType  string
+	CacheControl string
+}
+
+func (o syncObject) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          o.Name,
+		"md5hash":       o.Md5Hash,
+		"crc32c":        o.Crc32c,
+		"content_type":  o.ContentType,
+		"cache_control": o.CacheControl,
+	}
+}
+
+// expandSyncManifest returns the entries of a manifest by object name.
+func expandSyncManifest(v interface{}) map[string]syncObject {
+	manifest := make(map[string]syncObject)
+	if v == nil {
+		return manifest
+	}
+	for _, raw := range v.(*schema.Set).List() {
+		m := raw.(map[string]interface{})
+		o := syncObject{
+			Name:         m["name"].(string),
+			Md5Hash:      m["md5hash"].(string),
+			Crc32c:       m["crc32c"].(string),
+			ContentType:  m["content_type"].(string),
+			CacheControl: m["cache_control"].(string),
+		}
+		manifest[o.Name] = o
+	}
+	return manifest
+}
+
+func flattenSyncManifest(manifest map[string]syncObject) []interface{} {
+	names := make([]string, 0, len(manifest))
+	for name := range manifest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		result = append(result, manifest[name].toMap())
+	}
+	return result
+}
+
+// syncGlobMatch reports whether name, a slash separated path, matches
+// pattern. "**" matches any number of path segments, and the other segments
+// are matched with path.Match.
+func syncGlobMatch(pattern, name string) (bool, error) {
+	return syncGlobMatchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func syncGlobMatchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if ok, err := syncGlobMatchSegments(pattern[1:], name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(pattern[0], name[0]); !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+func validateSyncGlob(v interface{}, k string) (ws []string, errors []error) {
+	for _, segment := range strings.Split(v.(string), "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			errors = append(errors, fmt.Errorf("%q is not a valid glob: %s", k, err))
+			return
+		}
+	}
+	return
+}
+
+func syncGlobMatchAny(patterns []interface{}, name string) (bool, error) {
+	for _, p := range patterns {
+		ok, err := syncGlobMatch(p.(string), name)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// syncSettings are the fields that decide which files are synced, and how.
+type syncSettings struct {
+	SourceDir    string
+	Prefix       string
+	Include      []interface{}
+	Exclude      []interface{}
+	CacheControl []interface{}
+}
+
+// syncSettingsFrom reads the settings of a resource from its ResourceData or
+// ResourceDiff.
+func syncSettingsFrom(d interface{ Get(string) interface{} }) syncSettings {
+	return syncSettings{
+		SourceDir:    d.Get("source_dir").(string),
+		Prefix:       d.Get("prefix").(string),
+		Include:      d.Get("include").([]interface{}),
+		Exclude:      d.Get("exclude").([]interface{}),
+		CacheControl: d.Get("cache_control").([]interface{}),
+	}
+}
+
+// localPath returns the path of the file synced to the object name.
+func (s syncSettings) localPath(name string) string {
+	return filepath.Join(s.SourceDir, filepath.FromSlash(strings.TrimPrefix(name, s.Prefix)))
+}
+
+// buildSyncManifest walks the source directory, and returns the manifest of
+// the objects its files should be synced to.
+func buildSyncManifest(s syncSettings) (map[string]syncObject, error) {
+	manifest := make(map[string]syncObject)
+	err := filepath.Walk(s.SourceDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(p); err != nil {
+				return err
+			}
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.SourceDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if len(s.Include) > 0 {
+			if ok, err := syncGlobMatchAny(s.Include, rel); !ok || err != nil {
+				return err
+			}
+		}
+		if ok, err := syncGlobMatchAny(s.Exclude, rel); ok || err != nil {
+			return err
+		}
+
+		o, err := hashSyncFile(p)
+		if err != nil {
+			return err
+		}
+		o.Name = s.Prefix + rel
+		for _, raw := range s.CacheControl {
+			cc := raw.(map[string]interface{})
+			if ok, err := syncGlobMatch(cc["pattern"].(string), rel); err != nil {
+				return err
+			} else if ok {
+				o.CacheControl = cc["value"].(string)
+				break
+			}
+		}
+		manifest[o.Name] = o
+		return nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error reading source_dir %q: {{err}}", s.SourceDir), err)
+	}
+	return manifest, nil
+}
+
+// hashSyncFile returns the base64 MD5 and CRC32C hashes of a file, as Cloud
+// Storage reports them, and its content type.
+func hashSyncFile(p string) (syncObject, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return syncObject{}, err
+	}
+	defer f.Close()
+
+	md5Hash := md5.New()
+	crc32cHash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	// The content type is detected from the extension, or else the first
+	// 512 bytes of the file.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return syncObject{}, err
+	}
+	head = head[:n]
+	w := io.MultiWriter(md5Hash, crc32cHash)
+	w.Write(head) // nolint: errcheck
+	if _, err := io.Copy(w, f); err != nil {
+		return syncObject{}, err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(p))
+	if contentType == "" {
+		contentType = http.DetectContentType(head)
+	}
+	return syncObject{
+		Md5Hash:     base64.StdEncoding.EncodeToString(md5Hash.Sum(nil)),
+		Crc32c:      base64.StdEncoding.EncodeToString(crc32cHash.Sum(nil)),
+		ContentType: contentType,
+	}, nil
+}
+
+// resourceStorageBucketObjectsSyncDiff plans the manifest from the files in
+// source_dir, so that adding, changing or removing a file shows up as a
+// change to the resource.
+func resourceStorageBucketObjectsSyncDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for _, k := range []string{"source_dir", "prefix", "include", "exclude", "cache_control"} {
+		if !d.NewValueKnown(k) {
+			return d.SetNewComputed("manifest")
+		}
+	}
+
+	manifest, err := buildSyncManifest(syncSettingsFrom(d))
+	if err != nil {
+		return err
+	}
+	if d.Id() != "" && reflect.DeepEqual(expandSyncManifest(d.Get("manifest")), manifest) {
+		return nil
+	}
+	return d.SetNew("manifest", flattenSyncManifest(manifest))
+}
+
+func resourceStorageBucketObjectsSyncCreate(d *schema.ResourceData, meta interface{}) error {
+	// The ID is set first so the objects that were uploaded are kept in
+	// state if the sync fails.
+	d.SetId(fmt.Sprintf("%s/%s", d.Get("bucket").(string), d.Get("prefix").(string)))
+	if err := syncStorageBucketObjects(d, meta); err != nil {
+		return err
+	}
+
+	return resourceStorageBucketObjectsSyncRead(d, meta)
+}
+
+func resourceStorageBucketObjectsSyncUpdate(d *schema.ResourceData, meta interface{}) error {
+	if err := syncStorageBucketObjects(d, meta); err != nil {
+		return err
+	}
+
+	return resourceStorageBucketObjectsSyncRead(d, meta)
+}
+
+// syncStorageBucketObjects uploads the objects of the planned manifest that
+// are new or changed, and deletes the objects that were removed from it.
+func syncStorageBucketObjects(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	bucket := d.Get("bucket").(string)
+	settings := syncSettingsFrom(d)
+	o, n := d.GetChange("manifest")
+	oldManifest, newManifest := expandSyncManifest(o), expandSyncManifest(n)
+	// The manifest is unknown if any of the settings were during the plan.
+	if len(newManifest) == 0 {
+		if newManifest, err = buildSyncManifest(settings); err != nil {
+			return err
+		}
+	}
+
+	var uploads, deletes []syncObject
+	for name, obj := range newManifest {
+		if old, ok := oldManifest[name]; !ok || old != obj {
+			uploads = append(uploads, obj)
+		}
+	}
+	for name, obj := range oldManifest {
+		if _, ok := newManifest[name]; !ok {
+			deletes = append(deletes, obj)
+		}
+	}
+	log.Printf("[DEBUG] Syncing %s to bucket %s: %d objects to upload, %d to delete", settings.SourceDir, bucket, len(uploads), len(deletes))
+
+	// The SDK saves the manifest even if the sync fails, so it's set to the
+	// objects that were actually synced, and the objects that failed to
+	// upload or delete are synced again on the next apply.
+	var mu sync.Mutex
+	synced := make(map[string]syncObject, len(oldManifest))
+	for name, obj := range oldManifest {
+		synced[name] = obj
+	}
+	objectsService := storage.NewObjectsService(config.NewStorageClient(userAgent))
+	err = runSyncObjects(d.Get("parallelism").(int), uploads, func(obj syncObject) error {
+		if err := uploadSyncObject(objectsService, bucket, settings.localPath(obj.Name), obj); err != nil {
+			return err
+		}
+		mu.Lock()
+		synced[obj.Name] = obj
+		mu.Unlock()
+		return nil
+	})
+	if err == nil {
+		err = runSyncObjects(d.Get("parallelism").(int), deletes, func(obj syncObject) error {
+			if err := deleteSyncObject(objectsService, bucket, obj.Name); err != nil {
+				return err
+			}
+			mu.Lock()
+			delete(synced, obj.Name)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err != nil {
+		if setErr := d.Set("manifest", flattenSyncManifest(synced)); setErr != nil {
+			return fmt.Errorf("Error setting manifest: %s", setErr)
+		}
+		return err
+	}
+
+	// Read refreshes the objects of the manifest.
+	if err := d.Set("manifest", flattenSyncManifest(newManifest)); err != nil {
+		return fmt.Errorf("Error setting manifest: %s", err)
+	}
+	return nil
+}
+
+// runSyncObjects calls f for each object, parallelism at a time, and returns
+// the errors it returned.
+func runSyncObjects(parallelism int, objects []syncObject, f func(syncObject) error) error {
+	var mu sync.Mutex
+	var errs []string
+	wp := workerpool.New(parallelism)
+	for _, obj := range objects {
+		obj := obj
+		wp.Submit(func() {
+			if err := f(obj); err != nil {
+				mu.Lock()
+				errs = append(errs, err.Error())
+				mu.Unlock()
+			}
+		})
+	}
+	wp.StopWait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("Error syncing objects:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func uploadSyncObject(objectsService *storage.ObjectsService, bucket, p string, obj syncObject) error {
+	// Check the file wasn't changed since the plan before overwriting the
+	// object, as the planned hashes are stored in state.
+	current, err := hashSyncFile(p)
+	if err != nil {
+		return fmt.Errorf("Error uploading object %s: %s", obj.Name, err)
+	}
+	if current.Md5Hash != obj.Md5Hash || current.Crc32c != obj.Crc32c {
+		return fmt.Errorf("Error uploading object %s: %s changed after the plan was made", obj.Name, p)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("Error uploading object %s: %s", obj.Name, err)
+	}
+	defer f.Close()
+
+	// Cloud Storage rejects the upload if the file changes while it's read.
+	object := &storage.Object{
+		Bucket:       bucket,
+		Name:         obj.Name,
+		ContentType:  obj.ContentType,
+		CacheControl: obj.CacheControl,
+		Md5Hash:      obj.Md5Hash,
+		Crc32c:       obj.Crc32c,
+	}
+	log.Printf("[TRACE] Uploading %s to %s", p, obj.Name)
+	res, err := objectsService.Insert(bucket, object).Media(f, googleapi.ContentType(obj.ContentType)).Do()
+	if err != nil {
+		return fmt.Errorf("Error uploading object %s: %s", obj.Name, err)
+	}
+	if res.Crc32c != "" && res.Crc32c != obj.Crc32c {
+		return fmt.Errorf("Error uploading object %s: expected crc32c %s, got %s", obj.Name, obj.Crc32c, res.Crc32c)
+	}
+	return nil
+}
+
+func deleteSyncObject(objectsService *storage.ObjectsService, bucket, name string) error {
+	log.Printf("[TRACE] Deleting %s", name)
+	if err := objectsService.Delete(bucket, name).Do(); err != nil {
+		if isGoogleApiErrorWithCode(err, 404) {
+			log.Printf("[WARN] Bucket Object %q was already deleted", name)
+			return nil
+		}
+		return fmt.Errorf("Error deleting object %s: %s", name, err)
+	}
+	return nil
+}
+
+func resourceStorageBucketObjectsSyncRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	bucket := d.Get("bucket").(string)
+	manifest := expandSyncManifest(d.Get("manifest"))
+
+	// Listing the objects under the prefix takes a request per thousand
+	// objects, rather than one per object.
+	remote := make(map[string]syncObject)
+	call := config.NewStorageClient(userAgent).Objects.List(bucket).Prefix(d.Get("prefix").(string))
+	call.Fields("nextPageToken", "items(name,md5Hash,crc32c,contentType,cacheControl)")
+	err = call.Pages(config.context, func(objects *storage.Objects) error {
+		for _, o := range objects.Items {
+			if _, ok := manifest[o.Name]; ok {
+				remote[o.Name] = syncObject{
+					Name:         o.Name,
+					Md5Hash:      o.Md5Hash,
+					Crc32c:       o.Crc32c,
+					ContentType:  o.ContentType,
+					CacheControl: o.CacheControl,
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("Storage Bucket Objects Sync %q", d.Id()))
+	}
+
+	// Objects that were changed or deleted outside of Terraform are synced
+	// again on the next apply.
+	if err := d.Set("manifest", flattenSyncManifest(remote)); err != nil {
+		return fmt.Errorf("Error setting manifest: %s", err)
+	}
+
+	return nil
+}
+
+func resourceStorageBucketObjectsSyncDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	bucket := d.Get("bucket").(string)
+	var objects []syncObject
+	for _, obj := range expandSyncManifest(d.Get("manifest")) {
+		objects = append(objects, obj)
+	}
+
+	objectsService := storage.NewObjectsService(config.NewStorageClient(userAgent))
+	return runSyncObjects(d.Get("parallelism").(int), objects, func(obj syncObject) error {
+		return deleteSyncObject(objectsService, bucket, obj.Name)
+	})
+}
@@ -0,0 +1,285 @@
+package google
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestSyncGlobMatch(t *testing.T) {
+	cases := []struct {
+		Pattern  string
+		Name     string
+		Expected bool
+	}{
+		{"*.html", "index.html", true},
+		{"*.html", "docs/index.html", false},
+		{"**/*.html", "index.html", true},
+		{"**/*.html", "docs/a/index.html", true},
+		{"docs/**", "docs/a/b.css", true},
+		{"docs/**", "assets/a.css", false},
+		{"docs/**/b.css", "docs/b.css", true},
+		{"docs/*/b.css", "docs/b.css", false},
+		{"img/?.png", "img/a.png", true},
+		{"img/[ab].png", "img/c.png", false},
+	}
+
+	for _, tc := range cases {
+		got, err := syncGlobMatch(tc.Pattern, tc.Name)
+		if err != nil {
+			t.Errorf("%q, %q: unexpected error: %s", tc.Pattern, tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Errorf("%q, %q: expected %t, got %t", tc.Pattern, tc.Name, tc.Expected, got)
+		}
+	}
+
+	if _, errs := validateSyncGlob("img/[a.png", "include.0"); len(errs) == 0 {
+		t.Errorf("expected an invalid glob to fail validation")
+	}
+}
+
+// testSyncSourceDir creates a directory holding files, by their slash
+// separated path.
+func testSyncSourceDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "objects-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuildSyncManifest(t *testing.T) {
+	dir := testSyncSourceDir(t, map[string]string{
+		"index.html":      "<html></html>",
+		"docs/README":     "hello world",
+		"docs/draft.html": "<p>draft</p>",
+		"img/logo.png":    "\x89PNG\r\n\x1a\n",
+	})
+
+	manifest, err := buildSyncManifest(syncSettings{
+		SourceDir: dir,
+		Prefix:    "site/",
+		Exclude:   []interface{}{"**/draft.*"},
+		CacheControl: []interface{}{
+			map[string]interface{}{"pattern": "**/*.html", "value": "no-cache"},
+			map[string]interface{}{"pattern": "**", "value": "public, max-age=3600"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]syncObject{
+		"site/index.html": {
+			Name:         "site/index.html",
+			Md5Hash:      getContentMd5Hash([]byte("<html></html>")),
+			Crc32c:       "jrK9vw==",
+			ContentType:  "text/html; charset=utf-8",
+			CacheControl: "no-cache",
+		},
+		"site/docs/README": {
+			Name:         "site/docs/README",
+			Md5Hash:      "XrY7u+Ae7tCTyyK7j1rNww==",
+			Crc32c:       "yZRlqg==",
+			ContentType:  "text/plain; charset=utf-8",
+			CacheControl: "public, max-age=3600",
+		},
+		"site/img/logo.png": {
+			Name:         "site/img/logo.png",
+			Md5Hash:      getContentMd5Hash([]byte("\x89PNG\r\n\x1a\n")),
+			Crc32c:       "BPWu7w==",
+			ContentType:  "image/png",
+			CacheControl: "public, max-age=3600",
+		},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected manifest:\n%v\ngot:\n%v", expected, manifest)
+	}
+
+	manifest, err = buildSyncManifest(syncSettings{SourceDir: dir, Include: []interface{}{"docs/**"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 2 || manifest["docs/README"].Name == "" || manifest["docs/draft.html"].Name == "" {
+		t.Errorf("expected only the files under docs to be included, got %v", manifest)
+	}
+}
+
+func TestFakeGoogleApi_storageBucketObjectsSync(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	testFakeApiApply(t, config, "google_storage_bucket", nil, map[string]interface{}{
+		"name":          "my-bucket",
+		"location":      "US",
+		"force_destroy": true,
+	})
+
+	dir := testSyncSourceDir(t, map[string]string{
+		"index.html": "<html></html>",
+		"a/b/c.txt":  "c",
+		"a/d.txt":    "d",
+		"skip.tmp":   "tmp",
+	})
+	syncConfig := map[string]interface{}{
+		"bucket":      "my-bucket",
+		"source_dir":  dir,
+		"prefix":      "site/",
+		"exclude":     []interface{}{"*.tmp"},
+		"parallelism": 2,
+	}
+	state := testFakeApiApply(t, config, "google_storage_bucket_objects_sync", nil, syncConfig)
+	if got := state.Attributes["manifest.#"]; got != "3" {
+		t.Errorf("expected 3 objects in the manifest, got %s", got)
+	}
+	if media, _ := api.storageObjectMedia("my-bucket", "site/a/b/c.txt"); string(media) != "c" {
+		t.Errorf("expected site/a/b/c.txt to be uploaded, got %q", media)
+	}
+	if _, ok := api.storageObjectMedia("my-bucket", "site/skip.tmp"); ok {
+		t.Errorf("expected site/skip.tmp to be excluded")
+	}
+
+	// Changed files are uploaded, and deleted files are deleted.
+	if err := ioutil.WriteFile(filepath.Join(dir, "a", "d.txt"), []byte("dd"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	state = testFakeApiApply(t, config, "google_storage_bucket_objects_sync", state, syncConfig)
+	if media, _ := api.storageObjectMedia("my-bucket", "site/a/d.txt"); string(media) != "dd" {
+		t.Errorf("expected site/a/d.txt to be updated, got %q", media)
+	}
+	if _, ok := api.storageObjectMedia("my-bucket", "site/a/b/c.txt"); ok {
+		t.Errorf("expected site/a/b/c.txt to be deleted")
+	}
+
+	// Objects deleted outside of Terraform are uploaded again.
+	api.mu.Lock()
+	delete(api.resources, "storage/v1/b/my-bucket/o/site/index.html")
+	delete(api.media, "storage/v1/b/my-bucket/o/site/index.html")
+	api.mu.Unlock()
+	r := Provider().ResourcesMap["google_storage_bucket_objects_sync"]
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, config)
+	if diags.HasError() {
+		t.Fatalf("error refreshing: %v", diags)
+	}
+	if got := state.Attributes["manifest.#"]; got != "1" {
+		t.Errorf("expected the deleted object to be removed from the manifest, got %v", state.Attributes)
+	}
+	state = testFakeApiApply(t, config, "google_storage_bucket_objects_sync", state, syncConfig)
+	if _, ok := api.storageObjectMedia("my-bucket", "site/index.html"); !ok {
+		t.Errorf("expected site/index.html to be uploaded again")
+	}
+
+	testFakeApiDestroy(t, config, "google_storage_bucket_objects_sync", state)
+	for _, name := range []string{"site/index.html", "site/a/d.txt"} {
+		if _, ok := api.storageObjectMedia("my-bucket", name); ok {
+			t.Errorf("expected %s to be deleted", name)
+		}
+	}
+}
+
+func TestFakeGoogleApi_storageBucketObjectsSyncFailure(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	testFakeApiApply(t, config, "google_storage_bucket", nil, map[string]interface{}{
+		"name":          "my-bucket",
+		"location":      "US",
+		"force_destroy": true,
+	})
+
+	dir := testSyncSourceDir(t, map[string]string{
+		"a.txt": "a",
+		"b.txt": "b",
+		"c.txt": "c",
+	})
+	syncConfig := map[string]interface{}{
+		"bucket":     "my-bucket",
+		"source_dir": dir,
+	}
+	state := testFakeApiApply(t, config, "google_storage_bucket_objects_sync", nil, syncConfig)
+
+	ctx := context.Background()
+	r := Provider().ResourcesMap["google_storage_bucket_objects_sync"]
+
+	// Files changed after the plan aren't uploaded, and keep their old
+	// hashes in the manifest.
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("aa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(syncConfig), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("bbb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, diags := r.Apply(ctx, state, diff, config)
+	if !diags.HasError() {
+		t.Fatalf("expected the sync to fail")
+	}
+	if media, _ := api.storageObjectMedia("my-bucket", "b.txt"); string(media) != "b" {
+		t.Errorf("expected b.txt not to be uploaded after it changed, got %q", media)
+	}
+	manifest := expandSyncManifest(r.Data(state).Get("manifest"))
+	if got, expected := manifest["a.txt"].Md5Hash, getContentMd5Hash([]byte("aa")); got != expected {
+		t.Errorf("expected a.txt to be in the manifest with md5 %s, got %s", expected, got)
+	}
+	if got, expected := manifest["b.txt"].Md5Hash, getContentMd5Hash([]byte("b")); got != expected {
+		t.Errorf("expected b.txt to keep its old md5 %s, got %s", expected, got)
+	}
+	state = testFakeApiApply(t, config, "google_storage_bucket_objects_sync", state, syncConfig)
+	if media, _ := api.storageObjectMedia("my-bucket", "b.txt"); string(media) != "bbb" {
+		t.Errorf("expected b.txt to be uploaded on the next apply, got %q", media)
+	}
+
+	// Objects that fail to be deleted stay in the manifest.
+	if err := os.Remove(filepath.Join(dir, "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	api.mu.Lock()
+	api.resources["storage/v1/b/my-bucket/o/c.txt"]["temporaryHold"] = true
+	api.mu.Unlock()
+	diff, err = r.Diff(ctx, state, terraform.NewResourceConfigRaw(syncConfig), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, diags = r.Apply(ctx, state, diff, config)
+	if !diags.HasError() {
+		t.Fatalf("expected the sync to fail")
+	}
+	if _, ok := expandSyncManifest(r.Data(state).Get("manifest"))["c.txt"]; !ok {
+		t.Errorf("expected c.txt to stay in the manifest after failing to be deleted")
+	}
+	api.mu.Lock()
+	api.resources["storage/v1/b/my-bucket/o/c.txt"]["temporaryHold"] = false
+	api.mu.Unlock()
+	testFakeApiApply(t, config, "google_storage_bucket_objects_sync", state, syncConfig)
+	if _, ok := api.storageObjectMedia("my-bucket", "c.txt"); ok {
+		t.Errorf("expected c.txt to be deleted on the next apply")
+	}
+}
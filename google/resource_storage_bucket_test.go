@@ -2,9 +2,11 @@ package google
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +21,90 @@ func testBucketName(t *testing.T) string {
 	return fmt.Sprintf("%s-%d", "tf-test-bucket", randInt(t))
 }
 
+func TestFakeGoogleApi_storageBucketForceDestroy(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeGoogleApi(t)
+	config := api.config(t)
+	r := Provider().ResourcesMap["google_storage_bucket"]
+
+	bucketConfig := map[string]interface{}{
+		"name":          "my-bucket",
+		"location":      "US",
+		"force_destroy": true,
+	}
+	bucket := testFakeApiApply(t, config, "google_storage_bucket", nil, bucketConfig)
+	for _, name := range []string{"keep.txt", "tmp/a", "tmp/b", "logs/c"} {
+		api.putStorageObject("my-bucket", name, map[string]interface{}{}, []byte(name))
+	}
+	api.putStorageObject("my-bucket", "old.txt", map[string]interface{}{"timeDeleted": "2021-01-01T00:00:00Z"}, []byte("old"))
+	api.putStorageObject("my-bucket", "held.txt", map[string]interface{}{"temporaryHold": true}, []byte("held"))
+
+	destroy := func() string {
+		_, diags := r.Apply(context.Background(), bucket, &terraform.InstanceDiff{Destroy: true}, config)
+		if diags.HasError() {
+			return diags[0].Summary
+		}
+		return ""
+	}
+	exists := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := api.storageObjectMedia("my-bucket", name); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Nothing is deleted while an object is held.
+	if err := destroy(); !strings.Contains(err, "1 of its 6 objects are under a hold") || !strings.Contains(err, "held.txt (temporary hold)") {
+		t.Errorf("expected deleting the bucket to fail with a summary of the held objects, got %q", err)
+	}
+	if !exists("keep.txt", "tmp/a", "tmp/b", "logs/c", "old.txt", "held.txt") {
+		t.Errorf("expected no objects to be deleted")
+	}
+	api.mu.Lock()
+	api.resources["storage/v1/b/my-bucket/o/held.txt"]["temporaryHold"] = false
+	api.mu.Unlock()
+
+	bucketConfig["force_destroy_options"] = []interface{}{map[string]interface{}{"mode": "NONCURRENT_VERSIONS"}}
+	bucket = testFakeApiApply(t, config, "google_storage_bucket", bucket, bucketConfig)
+	if err := destroy(); !strings.Contains(err, "NONCURRENT_VERSIONS doesn't delete") {
+		t.Errorf("expected deleting the bucket to fail as it's not empty, got %q", err)
+	}
+	if exists("old.txt") || !exists("keep.txt", "tmp/a", "held.txt") {
+		t.Errorf("expected only the noncurrent versions to be deleted")
+	}
+
+	bucketConfig["force_destroy_options"] = []interface{}{map[string]interface{}{"mode": "PREFIXES", "prefixes": []interface{}{"tmp/", "logs/"}}}
+	bucket = testFakeApiApply(t, config, "google_storage_bucket", bucket, bucketConfig)
+	if err := destroy(); !strings.Contains(err, "PREFIXES doesn't delete") {
+		t.Errorf("expected deleting the bucket to fail as it's not empty, got %q", err)
+	}
+	if exists("tmp/a") || exists("tmp/b") || exists("logs/c") || !exists("keep.txt", "held.txt") {
+		t.Errorf("expected only the objects under the prefixes to be deleted")
+	}
+
+	bucketConfig["force_destroy_options"] = []interface{}{map[string]interface{}{"mode": "PREFIXES"}}
+	if _, err := r.Diff(context.Background(), bucket, terraform.NewResourceConfigRaw(bucketConfig), config); err == nil || !strings.Contains(err.Error(), "prefixes must be set") {
+		t.Errorf("expected the PREFIXES mode without prefixes to be invalid, got %v", err)
+	}
+
+	bucketConfig["force_destroy_options"] = []interface{}{map[string]interface{}{"rate_limit": 2000000000}}
+	if diags := r.Validate(terraform.NewResourceConfigRaw(bucketConfig)); !diags.HasError() {
+		t.Errorf("expected a rate_limit above %d to be invalid", forceDestroyMaxRateLimit)
+	}
+
+	bucketConfig["force_destroy_options"] = []interface{}{map[string]interface{}{"concurrency": 2, "rate_limit": 50}}
+	bucket = testFakeApiApply(t, config, "google_storage_bucket", bucket, bucketConfig)
+	if err := destroy(); err != "" {
+		t.Errorf("expected the bucket to be deleted, got %q", err)
+	}
+	if _, ok := api.get("storage/v1/b/my-bucket"); ok {
+		t.Errorf("expected the bucket to be deleted")
+	}
+}
+
 func TestAccStorageBucket_basic(t *testing.T) {
 	t.Parallel()
 
@@ -36,16 +36,51 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/http/httputil"
+	"regexp"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"google.golang.org/api/googleapi"
 )
 
 const defaultRetryTransportTimeoutSec = 90
 
+const (
+	retryBackoffFibonacci   = "fibonacci"
+	retryBackoffExponential = "exponential"
+	retryBackoffConstant    = "constant"
+)
+
+const defaultRetryInitialBackoff = 500 * time.Millisecond
+
+// retryConfig contains user configuration for controlling how the
+// retryTransport backs off between attempts and which additional errors it
+// retries.
+type retryConfig struct {
+	// maxAttempts is the total number of attempts made for a request,
+	// including the first one. Zero means no limit other than the timeout.
+	maxAttempts    int
+	initialBackoff time.Duration
+	// maxBackoff caps the wait between two attempts. Zero means no cap.
+	maxBackoff time.Duration
+	// jitter randomizes each wait by up to this fraction of it, in [0, 1].
+	jitter          float64
+	backoffStrategy string
+	// retryPredicates are applied on top of defaultErrorRetryPredicates.
+	retryPredicates []RetryErrorPredicateFunc
+}
+
+func defaultRetryConfig() *retryConfig {
+	return &retryConfig{
+		initialBackoff:  defaultRetryInitialBackoff,
+		backoffStrategy: retryBackoffFibonacci,
+	}
+}
+
 // NewTransportWithDefaultRetries constructs a default retryTransport that will retry common temporary errors
 func NewTransportWithDefaultRetries(t http.RoundTripper) *retryTransport {
 	return &retryTransport{
@@ -54,12 +89,45 @@ func NewTransportWithDefaultRetries(t http.RoundTripper) *retryTransport {
 	}
 }
 
+// NewTransportWithRetryConfig constructs a retryTransport that retries common
+// temporary errors and any additional errors given in the config, backing off
+// between attempts as configured.
+func NewTransportWithRetryConfig(t http.RoundTripper, config *retryConfig) *retryTransport {
+	if config == nil {
+		return NewTransportWithDefaultRetries(t)
+	}
+
+	predicates := make([]RetryErrorPredicateFunc, 0, len(defaultErrorRetryPredicates)+len(config.retryPredicates))
+	predicates = append(predicates, defaultErrorRetryPredicates...)
+	predicates = append(predicates, config.retryPredicates...)
+	return &retryTransport{
+		retryPredicates: predicates,
+		internal:        t,
+		config:          config,
+	}
+}
+
 // Helper method to create a shallow copy of an HTTP client with a shallow-copied retryTransport
 // s.t. the base HTTP transport is the same (i.e. client connection pools are shared, retryPredicates are different)
+//
+// The retryTransport of the base client is copied along with its config, so
+// the provider's retry settings apply to the copy too. A base client without
+// one is wrapped in a new retryTransport with the default retries.
 func ClientWithAdditionalRetries(baseClient *http.Client, predicates ...RetryErrorPredicateFunc) *http.Client {
 	copied := *baseClient
-	baseRetryTransport := NewTransportWithDefaultRetries(baseClient.Transport)
-	copied.Transport = baseRetryTransport.WithAddedPredicates(predicates...)
+	switch t := baseClient.Transport.(type) {
+	case *retryTransport:
+		copied.Transport = t.WithAddedPredicates(predicates...)
+	case headerTransportLayer:
+		if rt, ok := t.baseTransit.(*retryTransport); ok {
+			t.baseTransit = rt.WithAddedPredicates(predicates...)
+			copied.Transport = t
+			break
+		}
+		copied.Transport = NewTransportWithDefaultRetries(t).WithAddedPredicates(predicates...)
+	default:
+		copied.Transport = NewTransportWithDefaultRetries(baseClient.Transport).WithAddedPredicates(predicates...)
+	}
 	return &copied
 }
 
@@ -67,13 +135,64 @@ func ClientWithAdditionalRetries(baseClient *http.Client, predicates ...RetryErr
 // predicates but same wrapped http.RoundTripper
 func (t *retryTransport) WithAddedPredicates(predicates ...RetryErrorPredicateFunc) *retryTransport {
 	copyT := *t
-	copyT.retryPredicates = append(t.retryPredicates, predicates...)
+	copyT.retryPredicates = make([]RetryErrorPredicateFunc, 0, len(t.retryPredicates)+len(predicates))
+	copyT.retryPredicates = append(copyT.retryPredicates, t.retryPredicates...)
+	copyT.retryPredicates = append(copyT.retryPredicates, predicates...)
 	return &copyT
 }
 
 type retryTransport struct {
 	retryPredicates []RetryErrorPredicateFunc
 	internal        http.RoundTripper
+	config          *retryConfig
+}
+
+func (t *retryTransport) retryConfig() *retryConfig {
+	if t.config == nil {
+		return defaultRetryConfig()
+	}
+	return t.config
+}
+
+// retryBackoff computes the successive waits between attempts of one request.
+type retryBackoff struct {
+	config   *retryConfig
+	current  time.Duration
+	previous time.Duration
+}
+
+func newRetryBackoff(config *retryConfig) *retryBackoff {
+	return &retryBackoff{config: config}
+}
+
+// next returns the time to wait before the next attempt.
+func (b *retryBackoff) next() time.Duration {
+	initial := b.config.initialBackoff
+	switch {
+	case b.current == 0:
+		b.current = initial
+		b.previous = initial
+	case b.config.backoffStrategy == retryBackoffConstant:
+		b.current = initial
+	case b.config.backoffStrategy == retryBackoffExponential:
+		b.current = b.current * 2
+	default:
+		// Fibonacci backoff - 0.5, 1, 1.5, 2.5, 4, 6.5, 10.5, ...
+		last := b.current
+		b.current = b.current + b.previous
+		b.previous = last
+	}
+
+	if b.config.maxBackoff > 0 && b.current > b.config.maxBackoff {
+		b.current = b.config.maxBackoff
+	}
+
+	wait := b.current
+	if b.config.jitter > 0 {
+		delta := float64(wait) * b.config.jitter
+		wait = wait + time.Duration(delta*(2*rand.Float64()-1))
+	}
+	return wait
 }
 
 // RoundTrip implements the RoundTripper interface method.
@@ -93,9 +212,9 @@ func (t *retryTransport) RoundTrip(req *http.Request) (resp *http.Response, resp
 		}()
 	}
 
+	config := t.retryConfig()
 	attempts := 0
-	backoff := time.Millisecond * 500
-	nextBackoff := time.Millisecond * 500
+	backoff := newRetryBackoff(config)
 
 	// VCR depends on the original request body being consumed, so
 	// consume here. Since this won't affect the request itself,
@@ -121,10 +240,24 @@ Retry:
 
 		log.Printf("[DEBUG] Retry Transport: request attempt %d", attempts)
 		// Do the wrapped Roundtrip. This is one request in the retry loop.
+		attemptCtx, span := startSpan(newRequest.Context(), fmt.Sprintf("%s attempt %d", httpSpanName(req.Method, req.URL), attempts+1), spanKindClient)
+		newRequest = newRequest.WithContext(context.WithValue(attemptCtx, retryAttemptContextKey, attempts+1))
+		if span != nil {
+			// Propagate the attempt's span to the API.
+			newRequest.Header = newRequest.Header.Clone()
+			newRequest.Header.Set("traceparent", span.traceparent())
+			span.setAttribute("retry.attempt", attempts+1)
+		}
 		resp, respErr = t.internal.RoundTrip(newRequest)
 		attempts++
 
 		retryErr := t.checkForRetryableError(resp, respErr)
+		setHTTPSpanAttributes(span, newRequest, resp)
+		if retryErr != nil {
+			span.finish(retryErr.Err)
+		} else {
+			span.finish(nil)
+		}
 		if retryErr == nil {
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, last request was successful")
 			break Retry
@@ -134,18 +267,31 @@ Retry:
 			break Retry
 		}
 
-		log.Printf("[DEBUG] Retry Transport: Waiting %s before trying request again", backoff)
+		if config.maxAttempts > 0 && attempts >= config.maxAttempts {
+			log.Printf("[DEBUG] Retry Transport: Stopping retries, reached max attempts (%d): %s", config.maxAttempts, retryErr.Err)
+			break Retry
+		}
+
+		wait := backoff.next()
+		// Never retry sooner than the server asked us to.
+		if hint := serverRetryHint(resp, retryErr.Err, time.Now()); hint != nil {
+			log.Printf("[DEBUG] Retry Transport: Server asked to wait %s before retrying (%s)", hint.delay, hint.source)
+			if hint.delay > wait {
+				wait = hint.delay
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			log.Printf("[DEBUG] Retry Transport: Stopping retries, waiting %s would exceed the request deadline: %s", wait, retryErr.Err)
+			break Retry
+		}
+
+		log.Printf("[DEBUG] Retry Transport: Waiting %s before trying request again", wait)
 		select {
 		case <-ctx.Done():
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, context done: %v", ctx.Err())
 			break Retry
-		case <-time.After(backoff):
-			log.Printf("[DEBUG] Retry Transport: Finished waiting %s before next retry", backoff)
-
-			// Fibonnaci backoff - 0.5, 1, 1.5, 2.5, 4, 6.5, 10.5, ...
-			lastBackoff := backoff
-			backoff = backoff + nextBackoff
-			nextBackoff = lastBackoff
+		case <-time.After(wait):
+			log.Printf("[DEBUG] Retry Transport: Finished waiting %s before next retry", wait)
 			continue
 		}
 	}
@@ -153,6 +299,129 @@ Retry:
 	return resp, respErr
 }
 
+// retryHint is a minimum wait before retrying that the server gave along with
+// a retryable error.
+type retryHint struct {
+	delay time.Duration
+	// source describes where the hint came from, for logging.
+	source string
+}
+
+const (
+	retryInfoDetailType    = "type.googleapis.com/google.rpc.RetryInfo"
+	errorInfoDetailType    = "type.googleapis.com/google.rpc.ErrorInfo"
+	quotaFailureDetailType = "type.googleapis.com/google.rpc.QuotaFailure"
+)
+
+// Quota limit names end in the window they are counted over, e.g.
+// "ReadRequestsPerMinutePerProject" or "defaultPerDayPerProject".
+var quotaLimitWindowRegex = regexp.MustCompile(`Per(Second|Minute|HundredSeconds|Hour|Day)`)
+
+var quotaLimitWindows = map[string]time.Duration{
+	"Second":         time.Second,
+	"Minute":         time.Minute,
+	"HundredSeconds": 100 * time.Second,
+	"Hour":           time.Hour,
+	"Day":            24 * time.Hour,
+}
+
+// serverRetryHint returns the longest wait asked for by the server in the
+// response's Retry-After header, a google.rpc.RetryInfo detail in the error,
+// or a quota-exceeded google.rpc.ErrorInfo naming a quota limit. In the last
+// case, the wait is the window the quota limit resets over. It returns nil if
+// the server gave no hint.
+func serverRetryHint(resp *http.Response, err error, now time.Time) *retryHint {
+	var hint *retryHint
+	consider := func(delay time.Duration, source string) {
+		if hint == nil || delay > hint.delay {
+			hint = &retryHint{delay: delay, source: source}
+		}
+	}
+
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header, now); ok {
+			consider(delay, "Retry-After header")
+		}
+	}
+
+	gerr, ok := errwrap.GetType(err, &googleapi.Error{}).(*googleapi.Error)
+	if !ok || gerr == nil {
+		return hint
+	}
+
+	hasRetryInfo := false
+	for _, detail := range gerr.Details {
+		m, ok := detail.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m["@type"] != retryInfoDetailType {
+			continue
+		}
+		if v, ok := m["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(v); err == nil {
+				hasRetryInfo = true
+				consider(delay, "RetryInfo")
+			}
+		}
+	}
+
+	// An explicit RetryInfo is more precise than the quota window.
+	if hasRetryInfo {
+		return hint
+	}
+	for _, limit := range quotaLimitsExceeded(gerr) {
+		match := quotaLimitWindowRegex.FindStringSubmatch(limit)
+		if match == nil {
+			continue
+		}
+		consider(quotaLimitWindows[match[1]], fmt.Sprintf("quota limit %q exceeded", limit))
+	}
+	return hint
+}
+
+// quotaLimitsExceeded returns the names of the quota limits reported as
+// exceeded in a googleapi error's ErrorInfo and QuotaFailure details.
+func quotaLimitsExceeded(gerr *googleapi.Error) []string {
+	var limits []string
+	for _, detail := range gerr.Details {
+		m, ok := detail.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch m["@type"] {
+		case errorInfoDetailType:
+			metadata, ok := m["metadata"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if limit, ok := metadata["quota_limit"].(string); ok && limit != "" {
+				limits = append(limits, limit)
+			}
+		case quotaFailureDetailType:
+			violations, ok := m["violations"].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, v := range violations {
+				violation, ok := v.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				// The limit is only named in the free-form subject or
+				// description, e.g. "quota_limit:ReadRequestsPerMinutePerProject".
+				for _, field := range []string{"subject", "description"} {
+					if text, ok := violation[field].(string); ok && quotaLimitWindowRegex.MatchString(text) {
+						limits = append(limits, text)
+						break
+					}
+				}
+			}
+		}
+	}
+	return limits
+}
+
 // copyHttpRequest provides an copy of the given HTTP request for one RoundTrip.
 // If the request has a non-empty body (io.ReadCloser), the body is deep copied
 // so it can be consumed.
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/hashicorp/errwrap"
 	"google.golang.org/api/googleapi"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -168,6 +170,367 @@ func TestRetryTransport_DoesNotRetryEmptyGetBody(t *testing.T) {
 	testRetryTransport_checkFailedWhileRetrying(t, resp, err)
 }
 
+// Check that retries stop once the configured number of attempts is reached,
+// well before the context times out.
+func TestRetryTransport_MaxAttempts(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(testRetryTransportCodeRetry)
+		if _, err := w.Write([]byte(fmt.Sprintf("Code: %d", testRetryTransportCodeRetry))); err != nil {
+			t.Errorf("[ERROR] unable to write to response writer: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport = &retryTransport{
+		internal:        http.DefaultTransport,
+		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+		config: &retryConfig{
+			maxAttempts:     3,
+			initialBackoff:  time.Millisecond,
+			backoffStrategy: retryBackoffConstant,
+		},
+	}
+
+	ctx, cc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cc()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unable to construct err: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	testRetryTransport_checkFailedWhileRetrying(t, resp, err)
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+// Check that a client with additional retries keeps the retry config of the
+// client it's copied from, and doesn't change that client's retries.
+func TestClientWithAdditionalRetries_KeepsRetryConfig(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(testRetryTransportCodeFailure)
+		if _, err := w.Write([]byte(fmt.Sprintf("Code: %d", testRetryTransportCodeFailure))); err != nil {
+			t.Errorf("[ERROR] unable to write to response writer: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	baseClient := ts.Client()
+	baseClient.Transport = newTransportWithHeaders(NewTransportWithRetryConfig(http.DefaultTransport, &retryConfig{
+		maxAttempts:     2,
+		initialBackoff:  time.Millisecond,
+		backoffStrategy: retryBackoffConstant,
+	}))
+	client := ClientWithAdditionalRetries(baseClient, func(err error) (bool, string) {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == testRetryTransportCodeFailure {
+			return true, "retrying failure code"
+		}
+		return false, ""
+	})
+
+	ctx, cc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cc()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unable to construct err: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected response error, got actual error for doing request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != testRetryTransportCodeFailure {
+		t.Errorf("expected status code %d, got %d", testRetryTransportCodeFailure, resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("expected 2 attempts with the additional retries, got %d", got)
+	}
+
+	atomic.StoreInt32(&attempts, 0)
+	req, err = http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unable to construct err: %v", err)
+	}
+	resp, err = baseClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected base client not to retry, got error: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected 1 attempt with the base client, got %d", got)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	ms := time.Millisecond
+	cases := map[string]struct {
+		config   *retryConfig
+		expected []time.Duration
+	}{
+		"default fibonacci": {
+			config:   defaultRetryConfig(),
+			expected: []time.Duration{500 * ms, 1000 * ms, 1500 * ms, 2500 * ms, 4000 * ms, 6500 * ms},
+		},
+		"fibonacci with cap": {
+			config: &retryConfig{
+				initialBackoff:  100 * ms,
+				maxBackoff:      400 * ms,
+				backoffStrategy: retryBackoffFibonacci,
+			},
+			expected: []time.Duration{100 * ms, 200 * ms, 300 * ms, 400 * ms, 400 * ms},
+		},
+		"exponential": {
+			config: &retryConfig{
+				initialBackoff:  100 * ms,
+				backoffStrategy: retryBackoffExponential,
+			},
+			expected: []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, 1600 * ms},
+		},
+		"exponential with cap": {
+			config: &retryConfig{
+				initialBackoff:  100 * ms,
+				maxBackoff:      time.Second,
+				backoffStrategy: retryBackoffExponential,
+			},
+			expected: []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, 1000 * ms, 1000 * ms},
+		},
+		"constant": {
+			config: &retryConfig{
+				initialBackoff:  250 * ms,
+				backoffStrategy: retryBackoffConstant,
+			},
+			expected: []time.Duration{250 * ms, 250 * ms, 250 * ms},
+		},
+	}
+
+	for tn, tc := range cases {
+		b := newRetryBackoff(tc.config)
+		for i, expected := range tc.expected {
+			if got := b.next(); got != expected {
+				t.Errorf("%s: expected wait %d to be %s, got %s", tn, i, expected, got)
+			}
+		}
+	}
+}
+
+func TestRetryBackoff_jitter(t *testing.T) {
+	b := newRetryBackoff(&retryConfig{
+		initialBackoff:  time.Second,
+		jitter:          0.5,
+		backoffStrategy: retryBackoffConstant,
+	})
+	for i := 0; i < 100; i++ {
+		got := b.next()
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("expected jittered wait to be within 50%% of 1s, got %s", got)
+		}
+	}
+}
+
+// Check that the retry waits at least as long as the server's Retry-After.
+func TestRetryTransport_HonorsRetryAfter(t *testing.T) {
+	var firstReqTime, secondReqTime time.Time
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			firstReqTime = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(testRetryTransportCodeRetry)
+			return
+		}
+		secondReqTime = time.Now()
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport = &retryTransport{
+		internal:        http.DefaultTransport,
+		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+		config: &retryConfig{
+			initialBackoff:  time.Millisecond,
+			backoffStrategy: retryBackoffConstant,
+		},
+	}
+
+	resp, err := client.Get(ts.URL)
+	testRetryTransport_checkSuccess(t, resp, err)
+	if waited := secondReqTime.Sub(firstReqTime); waited < time.Second {
+		t.Errorf("expected retry to wait at least 1s, waited %s", waited)
+	}
+}
+
+// Check that retries stop right away when the server asks for a wait longer
+// than the time left before the request's deadline.
+func TestRetryTransport_RetryAfterPastDeadline(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(testRetryTransportCodeRetry)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport = &retryTransport{
+		internal:        http.DefaultTransport,
+		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+	}
+
+	ctx, cc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cc()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unable to construct err: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	testRetryTransport_checkFailedWhileRetrying(t, resp, err)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to stop without waiting, took %s", elapsed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestServerRetryHint(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		Header   string
+		Err      error
+		Expected time.Duration
+		NoHint   bool
+	}{
+		"no hint": {
+			Err:    &googleapi.Error{Code: 503},
+			NoHint: true,
+		},
+		"retry after header": {
+			Header:   "7",
+			Err:      &googleapi.Error{Code: 503},
+			Expected: 7 * time.Second,
+		},
+		"retry info": {
+			Err: &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+						"retryDelay": "12.500s",
+					},
+				},
+			},
+			Expected: 12500 * time.Millisecond,
+		},
+		"longest hint wins": {
+			Header: "20",
+			Err: &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+						"retryDelay": "5s",
+					},
+				},
+			},
+			Expected: 20 * time.Second,
+		},
+		"quota limit window": {
+			Err: &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type":  "type.googleapis.com/google.rpc.ErrorInfo",
+						"reason": "RATE_LIMIT_EXCEEDED",
+						"metadata": map[string]interface{}{
+							"quota_metric": "compute.googleapis.com/read_requests",
+							"quota_limit":  "ReadRequestsPerMinutePerProject",
+						},
+					},
+				},
+			},
+			Expected: time.Minute,
+		},
+		"quota failure violation": {
+			Err: &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type": "type.googleapis.com/google.rpc.QuotaFailure",
+						"violations": []interface{}{
+							map[string]interface{}{
+								"subject":     "project:123",
+								"description": "Quota exceeded for quota limit 'defaultPerHundredSecondsPerProject'",
+							},
+						},
+					},
+				},
+			},
+			Expected: 100 * time.Second,
+		},
+		"retry info preferred over quota window": {
+			Err: &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type": "type.googleapis.com/google.rpc.ErrorInfo",
+						"metadata": map[string]interface{}{
+							"quota_limit": "WriteRequestsPerMinutePerProject",
+						},
+					},
+					map[string]interface{}{
+						"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+						"retryDelay": "3s",
+					},
+				},
+			},
+			Expected: 3 * time.Second,
+		},
+		"wrapped error": {
+			Err: errwrap.Wrapf("Error creating: {{err}}", &googleapi.Error{
+				Code: 429,
+				Details: []interface{}{
+					map[string]interface{}{
+						"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+						"retryDelay": "2s",
+					},
+				},
+			}),
+			Expected: 2 * time.Second,
+		},
+	}
+
+	for tn, tc := range cases {
+		resp := &http.Response{Header: make(http.Header)}
+		if tc.Header != "" {
+			resp.Header.Set("Retry-After", tc.Header)
+		}
+		hint := serverRetryHint(resp, tc.Err, now)
+		if tc.NoHint {
+			if hint != nil {
+				t.Errorf("%s: expected no hint, got %+v", tn, hint)
+			}
+			continue
+		}
+		if hint == nil {
+			t.Errorf("%s: expected a hint of %s, got none", tn, tc.Expected)
+			continue
+		}
+		if hint.delay != tc.Expected {
+			t.Errorf("%s: expected a hint of %s, got %s (%s)", tn, tc.Expected, hint.delay, hint.source)
+		}
+	}
+}
+
 // handlers
 func testRetryTransportHandler_noRetries(t *testing.T, code int) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +45,7 @@ func isRetryableError(topErr error, customPredicates ...RetryErrorPredicateFunc)
 		for _, pred := range retryPredicates {
 			if predRetry, predReason := pred(werr); predRetry {
 				log.Printf("[DEBUG] Dismissed an error as retryable. %s - %s", predReason, werr)
+				metricRetries.inc(retryPredicateName(pred))
 				isRetryable = true
 				return
 			}
@@ -0,0 +1,334 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gammazero/workerpool"
+	"github.com/hashicorp/errwrap"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/storage/v1"
+)
+
+const (
+	// Resumable uploads are sent in chunks of a multiple of 256 KiB.
+	storageUploadChunkGranularity = 256 * 1024
+	// The maximum number of objects a compose request can combine.
+	storageComposeMaxSources = 32
+)
+
+// storageObjectUploader uploads objects in resumable upload sessions, which
+// continue from the last byte the server stored after a transient error, and
+// can upload objects as parts in parallel that are then composed.
+type storageObjectUploader struct {
+	config    *Config
+	userAgent string
+	// ctx bounds the whole upload, including its retries.
+	ctx    context.Context
+	bucket string
+	// chunkSize is the size of each request of a resumable upload, and of
+	// the parts of a parallel composite upload.
+	chunkSize int64
+	// concurrency is the number of parts uploaded at the same time.
+	concurrency int
+}
+
+// storageCrc32c returns the CRC32C checksum of r's data, encoded as Cloud
+// Storage reports it: the base64 of its big-endian bytes.
+func storageCrc32c(r io.Reader) (string, error) {
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// remaining returns how long the upload has left to retry requests for.
+func (u *storageObjectUploader) remaining() time.Duration {
+	deadline, ok := u.ctx.Deadline()
+	if !ok {
+		return time.Hour
+	}
+	if d := time.Until(deadline); d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+func (u *storageObjectUploader) uploadUrl(query url.Values) (string, error) {
+	base, err := url.Parse(u.config.StorageBasePath)
+	if err != nil {
+		return "", err
+	}
+	// The upload endpoint is at /upload followed by the API's base path.
+	ref := &url.URL{Path: "/upload" + base.Path + "b/" + u.bucket + "/o", RawQuery: query.Encode()}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func (u *storageObjectUploader) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", u.userAgent)
+	return u.config.client.Do(req.WithContext(u.ctx))
+}
+
+// uploadResumable uploads size bytes of media to object, and checks the
+// object's crc32c matches the media's.
+func (u *storageObjectUploader) uploadResumable(object *storage.Object, media io.ReaderAt, size int64) (*storage.Object, error) {
+	crc, err := storageCrc32c(io.NewSectionReader(media, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	var session string
+	err = retryTimeDuration(func() (err error) {
+		session, err = u.startResumableSession(object, size)
+		return err
+	}, u.remaining())
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error starting resumable upload of object %s: {{err}}", object.Name), err)
+	}
+
+	var offset int64
+	var res *storage.Object
+	for res == nil {
+		err := retryTimeDuration(func() error {
+			var err error
+			offset, res, err = u.uploadChunk(session, media, offset, size)
+			if err != nil && isRetryableError(err) {
+				// The server may have stored some or none of the chunk.
+				log.Printf("[DEBUG] Resuming upload of object %s after error: %s", object.Name, err)
+				if o, r, qerr := u.uploadStatus(session, size); qerr == nil {
+					offset, res = o, r
+					if res != nil {
+						return nil
+					}
+				}
+			}
+			return err
+		}, u.remaining())
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("Error uploading object %s: {{err}}", object.Name), err)
+		}
+		log.Printf("[DEBUG] Uploaded %d of %d bytes of object %s", offset, size, object.Name)
+	}
+
+	if res.Crc32c != crc {
+		return nil, fmt.Errorf("Error uploading object %s: expected crc32c %s, got %s", object.Name, crc, res.Crc32c)
+	}
+	return res, nil
+}
+
+// startResumableSession returns the URI of a new resumable upload session.
+func (u *storageObjectUploader) startResumableSession(object *storage.Object, size int64) (string, error) {
+	rawurl, err := u.uploadUrl(url.Values{"uploadType": {"resumable"}, "alt": {"json"}})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", rawurl, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	if object.ContentType != "" {
+		req.Header.Set("X-Upload-Content-Type", object.ContentType)
+	}
+
+	resp, err := u.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer googleapi.CloseBody(resp)
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", fmt.Errorf("the response starting the upload has no Location")
+	}
+	return session, nil
+}
+
+// uploadChunk sends the chunk of media at offset, and returns the offset the
+// upload continues from, or the object once it's complete.
+func (u *storageObjectUploader) uploadChunk(session string, media io.ReaderAt, offset, size int64) (int64, *storage.Object, error) {
+	n := u.chunkSize
+	if offset+n > size {
+		n = size - offset
+	}
+	chunk := make([]byte, n)
+	if _, err := media.ReadAt(chunk, offset); err != nil && err != io.EOF {
+		return offset, nil, err
+	}
+
+	req, err := http.NewRequest("PUT", session, bytes.NewReader(chunk))
+	if err != nil {
+		return offset, nil, err
+	}
+	if n == 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+n-1, size))
+	}
+	return u.resumableResponse(req, offset)
+}
+
+// uploadStatus asks the server how much of the upload it stored.
+func (u *storageObjectUploader) uploadStatus(session string, size int64) (int64, *storage.Object, error) {
+	req, err := http.NewRequest("PUT", session, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	return u.resumableResponse(req, 0)
+}
+
+// resumableResponse sends a request of a resumable upload session. The
+// server answers with a 308 and the range of bytes it stored while the
+// upload is incomplete, and with the object once it's complete.
+func (u *storageObjectUploader) resumableResponse(req *http.Request, offset int64) (int64, *storage.Object, error) {
+	resp, err := u.do(req)
+	if err != nil {
+		return offset, nil, err
+	}
+	defer googleapi.CloseBody(resp)
+
+	if resp.StatusCode == http.StatusPermanentRedirect {
+		// The range is "bytes=0-N", or missing if nothing was stored.
+		r := resp.Header.Get("Range")
+		if r == "" {
+			return 0, nil, nil
+		}
+		end, err := strconv.ParseInt(r[strings.LastIndex(r, "-")+1:], 10, 64)
+		if err != nil {
+			return offset, nil, fmt.Errorf("unexpected Range %q in upload response", r)
+		}
+		return end + 1, nil, nil
+	}
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return offset, nil, err
+	}
+	res := &storage.Object{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return offset, nil, err
+	}
+	return offset, res, nil
+}
+
+// uploadComposite uploads media as parts of chunkSize in parallel, composes
+// them into object, and deletes the parts. The object is checked end to end
+// with crc32c, as composite objects don't have an MD5 hash.
+func (u *storageObjectUploader) uploadComposite(object *storage.Object, media io.ReaderAt, size int64) (*storage.Object, error) {
+	type part struct {
+		name   string
+		offset int64
+		size   int64
+	}
+	var parts []part
+	id := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for offset := int64(0); offset < size; offset += u.chunkSize {
+		n := u.chunkSize
+		if offset+n > size {
+			n = size - offset
+		}
+		parts = append(parts, part{fmt.Sprintf("%s.tfpart-%s-%d", object.Name, id, len(parts)), offset, n})
+	}
+	crc, err := storageCrc32c(io.NewSectionReader(media, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	objectsService := storage.NewObjectsService(u.config.NewStorageClient(u.userAgent))
+	defer func() {
+		wp := workerpool.New(u.concurrency)
+		for _, p := range parts {
+			p := p
+			wp.Submit(func() {
+				if err := objectsService.Delete(u.bucket, p.name).Context(u.ctx).Do(); err != nil && !isGoogleApiErrorWithCode(err, 404) {
+					log.Printf("[WARN] Failed to delete part %s of object %s: %s", p.name, object.Name, err)
+				}
+			})
+		}
+		wp.StopWait()
+	}()
+
+	// Each part is uploaded in a single chunk, as it's chunkSize at most.
+	var mu sync.Mutex
+	var errs []string
+	wp := workerpool.New(u.concurrency)
+	for _, p := range parts {
+		p := p
+		wp.Submit(func() {
+			partObject := &storage.Object{
+				Bucket:       u.bucket,
+				Name:         p.name,
+				ContentType:  object.ContentType,
+				StorageClass: object.StorageClass,
+				KmsKeyName:   object.KmsKeyName,
+			}
+			log.Printf("[TRACE] Uploading part %s of object %s", p.name, object.Name)
+			if _, err := u.uploadResumable(partObject, io.NewSectionReader(media, p.offset, p.size), p.size); err != nil {
+				mu.Lock()
+				errs = append(errs, err.Error())
+				mu.Unlock()
+			}
+		})
+	}
+	wp.StopWait()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Error uploading parts of object %s:\n%s", object.Name, strings.Join(errs, "\n"))
+	}
+
+	// A compose request combines 32 objects at most, so objects with more
+	// parts are composed in batches, each adding to the previous batches.
+	var res *storage.Object
+	for i := 0; i < len(parts); {
+		var sources []*storage.ComposeRequestSourceObjects
+		if i > 0 {
+			sources = append(sources, &storage.ComposeRequestSourceObjects{Name: object.Name, Generation: res.Generation})
+		}
+		for ; i < len(parts) && len(sources) < storageComposeMaxSources; i++ {
+			sources = append(sources, &storage.ComposeRequestSourceObjects{Name: parts[i].name})
+		}
+
+		destination := *object
+		destination.KmsKeyName = ""
+		if i < len(parts) {
+			// Held objects can't be replaced, so holds are set by the last
+			// request.
+			destination.TemporaryHold = false
+			destination.EventBasedHold = false
+		}
+		call := objectsService.Compose(u.bucket, object.Name, &storage.ComposeRequest{Destination: &destination, SourceObjects: sources}).Context(u.ctx)
+		if object.KmsKeyName != "" {
+			call.KmsKeyName(object.KmsKeyName)
+		}
+		err := retryTimeDuration(func() (err error) {
+			res, err = call.Do()
+			return err
+		}, u.remaining())
+		if err != nil {
+			return nil, fmt.Errorf("Error composing object %s: %s", object.Name, err)
+		}
+	}
+
+	if res.Crc32c != crc {
+		return nil, fmt.Errorf("Error composing object %s: expected crc32c %s, got %s", object.Name, crc, res.Crc32c)
+	}
+	return res, nil
+}
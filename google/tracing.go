@@ -0,0 +1,509 @@
+// Optional tracing of a provider run as OpenTelemetry spans, exported in the
+// OTLP/JSON encoding to a file and/or an OTLP/HTTP collector endpoint.
+//
+// Resource and data source CRUD functions are traced as parent spans, with
+// the API calls made by sendRequestWithTimeout, each attempt made by the retry
+// transport and the polling of long-running operations by OperationWait as
+// their children. The span of an HTTP request is propagated to the API in a
+// W3C traceparent header.
+//
+// CRUD functions share the provider's *Config, and most API calls are made
+// through client libraries without a context, so the span of a CRUD function
+// is found from the user agent of its requests instead: generateUserAgentString
+// tags the user agent generated from the function's *schema.ResourceData with
+// the span, and the header transport removes the tag from each request, and
+// passes the span to the transports below it in the request's context.
+//
+// Tracing is enabled with the provider's trace_file and trace_endpoint fields,
+// or the GOOGLE_TRACE_FILE and GOOGLE_TRACE_ENDPOINT environment variables.
+
+package google
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	tracingServiceName = "terraform-provider-google"
+	// Spans are exported once this many have ended, and whenever a root span,
+	// e.g. the span of a CRUD function, ends. The provider process can be
+	// killed at any point once Terraform is done with it, so nothing is left
+	// to be exported on shutdown.
+	tracingExportBatchSize = 256
+)
+
+// Span kinds, as defined by OTLP.
+type spanKind int
+
+const (
+	spanKindInternal spanKind = 1
+	spanKindClient   spanKind = 3
+)
+
+// tracingConfig contains user configuration for exporting spans.
+type tracingConfig struct {
+	// file is the path of a file to append OTLP/JSON export requests to, one
+	// per line.
+	file string
+	// endpoint is the base URL of an OTLP/HTTP collector, e.g.
+	// "http://localhost:4318". Spans are posted to its /v1/traces path.
+	endpoint string
+}
+
+// tracer records ended spans and exports them in batches.
+type tracer struct {
+	mu       sync.Mutex
+	config   tracingConfig
+	pending  []*span
+	file     *os.File
+	client   *http.Client
+	exporter func(body []byte) error
+}
+
+var (
+	providerTracerMu sync.Mutex
+	providerTracer   *tracer
+)
+
+// enableTracing sets up the tracer used by the provider. Spans are recorded by
+// the first configuration enabling tracing.
+func enableTracing(c tracingConfig) error {
+	providerTracerMu.Lock()
+	defer providerTracerMu.Unlock()
+
+	if providerTracer != nil {
+		return nil
+	}
+	t := &tracer{
+		config: c,
+		// The collector must not be called through the traced transports.
+		client: cleanhttp.DefaultClient(),
+	}
+	if c.file != "" {
+		f, err := os.OpenFile(c.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to open trace file %q: %s", c.file, err)
+		}
+		t.file = f
+	}
+	t.exporter = t.export
+	providerTracer = t
+	return nil
+}
+
+func getTracer() *tracer {
+	providerTracerMu.Lock()
+	defer providerTracerMu.Unlock()
+	return providerTracer
+}
+
+// span is a single traced operation.
+type span struct {
+	tracer       *tracer
+	traceID      [16]byte
+	spanID       [8]byte
+	parentSpanID [8]byte
+	name         string
+	kind         spanKind
+	start        time.Time
+	end          time.Time
+
+	mu         sync.Mutex
+	attributes map[string]interface{}
+	err        error
+}
+
+type spanContextKeyType struct{}
+
+var spanContextKey = spanContextKeyType{}
+
+func spanFromContext(ctx context.Context) *span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanContextKey).(*span)
+	return s
+}
+
+// contextWithSpan returns a context carrying s as the parent of spans started
+// from it.
+func contextWithSpan(ctx context.Context, s *span) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey, s)
+}
+
+// startSpan starts a span as a child of the span in ctx, if any, and returns a
+// context carrying it. If tracing is disabled it returns ctx and a nil span,
+// whose methods are no-ops.
+func startSpan(ctx context.Context, name string, kind spanKind) (context.Context, *span) {
+	t := getTracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &span{
+		tracer:     t,
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+	if parent := spanFromContext(ctx); parent != nil {
+		s.traceID = parent.traceID
+		s.parentSpanID = parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	return contextWithSpan(ctx, s), s
+}
+
+// setAttribute sets an attribute of the span. Values are strings, ints,
+// floats or bools.
+func (s *span) setAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// finish ends the span, recording err as its status.
+func (s *span) finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.err = err
+	s.mu.Unlock()
+	s.tracer.record(s)
+}
+
+// traceparent returns the W3C Trace Context header value identifying the span.
+func (s *span) traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.traceID[:]), hex.EncodeToString(s.spanID[:]))
+}
+
+func (t *tracer) record(s *span) {
+	t.mu.Lock()
+	t.pending = append(t.pending, s)
+	full := len(t.pending) >= tracingExportBatchSize
+	t.mu.Unlock()
+
+	if full || s.parentSpanID == [8]byte{} {
+		if err := t.flush(); err != nil {
+			log.Printf("[WARN] Tracing: %s", err)
+		}
+	}
+}
+
+func (t *tracer) flush() error {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(otlpExportRequest(spans))
+	if err != nil {
+		return fmt.Errorf("unable to encode spans: %s", err)
+	}
+	return t.exporter(body)
+}
+
+// export writes an encoded export request to the configured file and
+// collector.
+func (t *tracer) export(body []byte) error {
+	if t.file != nil {
+		t.mu.Lock()
+		_, err := t.file.Write(append(body, '\n'))
+		t.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("unable to write spans to %q: %s", t.config.file, err)
+		}
+	}
+
+	if t.config.endpoint != "" {
+		exportURL := strings.TrimSuffix(t.config.endpoint, "/") + "/v1/traces"
+		resp, err := t.client.Post(exportURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("unable to export spans to %q: %s", exportURL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("unable to export spans to %q: got status %s", exportURL, resp.Status)
+		}
+	}
+	return nil
+}
+
+// The OTLP/JSON encoding of an ExportTraceServiceRequest. IDs are hex
+// encoded, and 64 bit integers are encoded as strings.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              spanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	// Code is 0 for unset, 1 for ok and 2 for error.
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func otlpAttribute(key string, v interface{}) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	switch v := v.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case int:
+		i := strconv.Itoa(v)
+		kv.Value.IntValue = &i
+	case float64:
+		kv.Value.DoubleValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	default:
+		str := fmt.Sprintf("%v", v)
+		kv.Value.StringValue = &str
+	}
+	return kv
+}
+
+func otlpExportRequest(spans []*span) *otlpTraces {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		encodedSpan := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.parentSpanID != [8]byte{} {
+			encodedSpan.ParentSpanID = hex.EncodeToString(s.parentSpanID[:])
+		}
+		keys := make([]string, 0, len(s.attributes))
+		for k := range s.attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodedSpan.Attributes = append(encodedSpan.Attributes, otlpAttribute(k, s.attributes[k]))
+		}
+		if s.err != nil {
+			encodedSpan.Status = otlpStatus{Code: 2, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, encodedSpan)
+	}
+
+	return &otlpTraces{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{otlpAttribute("service.name", tracingServiceName)},
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: tracingServiceName},
+						Spans: encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+// httpSpanName names the span of a request to u, with the resource IDs in its
+// path replaced by placeholders, e.g.
+// "GET compute.googleapis.com/compute/v1/projects/{project}/global/networks".
+func httpSpanName(method string, u *url.URL) string {
+	template, _ := auditLogURLTemplate(u.EscapedPath())
+	return fmt.Sprintf("%s %s%s", method, u.Host, template)
+}
+
+// setHTTPSpanAttributes sets the attributes describing a request and its
+// response on a span.
+func setHTTPSpanAttributes(s *span, req *http.Request, resp *http.Response) {
+	if s == nil {
+		return
+	}
+	s.setAttribute("http.method", req.Method)
+	s.setAttribute("http.host", req.URL.Host)
+	template, resource := auditLogURLTemplate(req.URL.EscapedPath())
+	s.setAttribute("http.route", template)
+	if resource != "" {
+		s.setAttribute("gcp.resource", resource)
+	}
+	if resp != nil {
+		s.setAttribute("http.status_code", resp.StatusCode)
+	}
+}
+
+// addTracingSupport wraps the CRUD functions of the given resources or data
+// sources in spans when tracing is enabled.
+func addTracingSupport(resources map[string]*schema.Resource) {
+	for name, r := range resources {
+		if r.Create != nil {
+			r.Create = schema.CreateFunc(wrapWithSpan(name, "Create", r.Create))
+		}
+		if r.Read != nil {
+			r.Read = schema.ReadFunc(wrapWithSpan(name, "Read", r.Read))
+		}
+		if r.Update != nil {
+			r.Update = schema.UpdateFunc(wrapWithSpan(name, "Update", r.Update))
+		}
+		if r.Delete != nil {
+			r.Delete = schema.DeleteFunc(wrapWithSpan(name, "Delete", r.Delete))
+		}
+	}
+}
+
+// wrapWithSpan traces f in a span named after the resource and operation. The
+// span is the parent of the requests f makes while it runs.
+func wrapWithSpan(name, op string, f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		if getTracer() == nil {
+			return f(d, meta)
+		}
+
+		_, span := startSpan(context.Background(), fmt.Sprintf("%s %s", name, op), spanKindInternal)
+		span.setAttribute("terraform.resource.type", name)
+		span.setAttribute("terraform.operation", op)
+		if id := d.Id(); id != "" {
+			span.setAttribute("terraform.resource.id", id)
+		}
+
+		tag := hex.EncodeToString(span.spanID[:])
+		resourceDataSpans.Store(d, span)
+		userAgentSpans.Store(tag, span)
+		err := f(d, meta)
+		resourceDataSpans.Delete(d)
+		userAgentSpans.Delete(tag)
+
+		if err == nil && d.Id() != "" {
+			span.setAttribute("terraform.resource.id", d.Id())
+		}
+		span.finish(err)
+		return err
+	}
+}
+
+// userAgentSpanPrefix precedes the ID of a span in a user agent tagged with
+// it.
+const userAgentSpanPrefix = "terraform-span/"
+
+var (
+	// resourceDataSpans are the spans of the running CRUD functions, by
+	// their *schema.ResourceData.
+	resourceDataSpans sync.Map
+	// userAgentSpans are the same spans, by their hex encoded ID.
+	userAgentSpans sync.Map
+)
+
+// userAgentWithSpan tags userAgent with the span of the CRUD function d was
+// given to, if it's traced.
+func userAgentWithSpan(userAgent string, d TerraformResourceData) string {
+	v, ok := resourceDataSpans.Load(d)
+	if !ok {
+		return userAgent
+	}
+	return fmt.Sprintf("%s %s%s", userAgent, userAgentSpanPrefix, hex.EncodeToString(v.(*span).spanID[:]))
+}
+
+// splitUserAgentSpan returns userAgent without its span tag, and the span it
+// was tagged with if the span hasn't ended.
+func splitUserAgentSpan(userAgent string) (string, *span) {
+	i := strings.Index(userAgent, " "+userAgentSpanPrefix)
+	if i < 0 {
+		return userAgent, nil
+	}
+	tag := userAgent[i+1+len(userAgentSpanPrefix):]
+	rest := ""
+	if j := strings.Index(tag, " "); j >= 0 {
+		tag, rest = tag[:j], tag[j:]
+	}
+	var s *span
+	if v, ok := userAgentSpans.Load(tag); ok {
+		s = v.(*span)
+	}
+	return userAgent[:i] + rest, s
+}
+
+// contextWithUserAgentSpan returns ctx carrying the span userAgent is tagged
+// with, if any, as the parent of spans started from it.
+func contextWithUserAgentSpan(ctx context.Context, userAgent string) context.Context {
+	_, s := splitUserAgentSpan(userAgent)
+	return contextWithSpan(ctx, s)
+}
@@ -0,0 +1,241 @@
+package google
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// setTestTracer enables tracing for the duration of a test, returning a
+// function that exports and decodes the recorded spans.
+func setTestTracer(t *testing.T) func() []otlpSpan {
+	var exported []otlpSpan
+	tr := &tracer{}
+	tr.exporter = func(body []byte) error {
+		var req otlpTraces
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("unable to decode export request %s: %s", body, err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				exported = append(exported, ss.Spans...)
+			}
+		}
+		return nil
+	}
+
+	providerTracerMu.Lock()
+	providerTracer = tr
+	providerTracerMu.Unlock()
+	t.Cleanup(func() {
+		providerTracerMu.Lock()
+		providerTracer = nil
+		providerTracerMu.Unlock()
+	})
+
+	return func() []otlpSpan {
+		if err := tr.flush(); err != nil {
+			t.Fatal(err)
+		}
+		return exported
+	}
+}
+
+func TestStartSpan_disabled(t *testing.T) {
+	ctx, s := startSpan(nil, "test", spanKindInternal)
+	if s != nil || ctx != nil {
+		t.Errorf("expected no span when tracing is disabled")
+	}
+	// Methods of a nil span are no-ops.
+	s.setAttribute("key", "value")
+	s.finish(nil)
+}
+
+func TestStartSpan_parentAndExport(t *testing.T) {
+	export := setTestTracer(t)
+
+	ctx, parent := startSpan(nil, "parent", spanKindInternal)
+	_, child := startSpan(ctx, "child", spanKindClient)
+	child.setAttribute("http.status_code", 404)
+	child.finish(errors.New("not found"))
+	parent.finish(nil)
+
+	spans := export()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID || p.ParentSpanID != "" {
+		t.Errorf("expected child to be in the parent's trace: parent %+v, child %+v", p, c)
+	}
+	if c.Kind != spanKindClient || c.Status.Code != 2 || c.Status.Message != "not found" {
+		t.Errorf("unexpected child span %+v", c)
+	}
+	if len(c.Attributes) != 1 || c.Attributes[0].Key != "http.status_code" || *c.Attributes[0].Value.IntValue != "404" {
+		t.Errorf("unexpected child attributes %+v", c.Attributes)
+	}
+	if p.Status.Code != 1 {
+		t.Errorf("expected parent status to be ok, got %+v", p.Status)
+	}
+}
+
+// Check that spans are exported as soon as their root span ends.
+func TestStartSpan_exportsOnRootEnd(t *testing.T) {
+	setTestTracer(t)
+	tr := getTracer()
+
+	ctx, root := startSpan(nil, "root", spanKindInternal)
+	_, child := startSpan(ctx, "child", spanKindClient)
+	child.finish(nil)
+	if got := len(tr.pending); got != 1 {
+		t.Errorf("expected the child span to be pending until its root ends, got %d pending", got)
+	}
+	root.finish(nil)
+	if got := len(tr.pending); got != 0 {
+		t.Errorf("expected spans to be exported once the root span ends, got %d pending", got)
+	}
+}
+
+func TestRetryTransport_tracesAttempts(t *testing.T) {
+	export := setTestTracer(t)
+
+	var traceparents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(testRetryTransportCodeRetry)
+			return
+		}
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport = &retryTransport{
+		internal:        http.DefaultTransport,
+		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+	}
+
+	ctx, parent := startSpan(nil, "parent", spanKindInternal)
+	req, err := http.NewRequest("GET", ts.URL+"/compute/v1/projects/p/global/networks", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	testRetryTransport_checkSuccess(t, resp, err)
+	parent.finish(nil)
+
+	spans := export()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	for i, s := range spans[:2] {
+		if s.ParentSpanID != spans[2].SpanID {
+			t.Errorf("expected attempt %d to be a child of the request span", i+1)
+		}
+		if !strings.HasSuffix(s.Name, "/compute/v1/projects/{project}/global/networks attempt "+string(rune('1'+i))) {
+			t.Errorf("unexpected span name %q", s.Name)
+		}
+		if expected := "00-" + s.TraceID + "-" + s.SpanID + "-01"; traceparents[i] != expected {
+			t.Errorf("expected traceparent %q, got %q", expected, traceparents[i])
+		}
+	}
+	if spans[0].Status.Code != 2 || spans[1].Status.Code != 1 {
+		t.Errorf("expected first attempt to fail and second to succeed, got %+v and %+v", spans[0].Status, spans[1].Status)
+	}
+}
+
+func TestAddTracingSupport(t *testing.T) {
+	export := setTestTracer(t)
+
+	var userAgents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+	client := ts.Client()
+	client.Transport = newTransportWithHeaders(&retryTransport{internal: http.DefaultTransport})
+
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+		},
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			userAgent, err := generateUserAgentString(d, "terraform-provider-google")
+			if err != nil {
+				return err
+			}
+			// Requests made without a context, as client libraries make
+			// them, are children of the resource's span.
+			req, err := http.NewRequest("GET", ts.URL+"/compute/v1/projects/p/global/networks", nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("User-Agent", userAgent)
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			d.SetId("foo")
+			return nil
+		},
+	}
+	addTracingSupport(map[string]*schema.Resource{"google_test": r})
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"name": "foo"})
+	if err := r.Create(d, &Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(userAgents) != 1 || userAgents[0] != "terraform-provider-google" {
+		t.Errorf("expected the span tag to be removed from the user agent, got %q", userAgents)
+	}
+	spans := export()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, parent := spans[0], spans[1]
+	if parent.Name != "google_test Create" || child.ParentSpanID != parent.SpanID {
+		t.Errorf("unexpected spans: parent %+v, child %+v", parent, child)
+	}
+
+	// The tag of an ended span is removed, but has no span.
+	tagged := "terraform-provider-google " + userAgentSpanPrefix + parent.SpanID + " my-module"
+	if userAgent, s := splitUserAgentSpan(tagged); userAgent != "terraform-provider-google my-module" || s != nil {
+		t.Errorf("unexpected user agent %q and span %v", userAgent, s)
+	}
+}
+
+func TestOperationWaiterContext(t *testing.T) {
+	setTestTracer(t)
+
+	_, s := startSpan(nil, "parent", spanKindInternal)
+	tag := hex.EncodeToString(s.spanID[:])
+	userAgentSpans.Store(tag, s)
+	defer userAgentSpans.Delete(tag)
+
+	polling := &operationPollingConfig{}
+	w := &RedisOperationWaiter{
+		Config:    &Config{context: contextWithOperationPolling(context.Background(), polling)},
+		UserAgent: "terraform-provider-google " + userAgentSpanPrefix + tag,
+	}
+	ctx := operationWaiterContext(w)
+	if spanFromContext(ctx) != s {
+		t.Errorf("expected the context to carry the span of the waiter's user agent")
+	}
+	if operationPollingFromContext(ctx) != polling {
+		t.Errorf("expected the context to carry the waiter's polling configuration")
+	}
+}
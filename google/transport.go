@@ -60,7 +60,13 @@ func sendRequestWithTimeout(config *Config, method, project, rawurl, userAgent s
 	}
 
 	var res *http.Response
-	err := retryTimeDuration(
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	_, span := startSpan(contextWithUserAgentSpan(config.context, userAgent), httpSpanName(method, u), spanKindClient)
+	span.setAttribute("gcp.project", project)
+	err = retryTimeDuration(
 		func() error {
 			var buf bytes.Buffer
 			if body != nil {
@@ -80,6 +86,9 @@ func sendRequestWithTimeout(config *Config, method, project, rawurl, userAgent s
 			}
 
 			req.Header = reqHeaders
+			if span != nil {
+				req = req.WithContext(contextWithSpan(req.Context(), span))
+			}
 			res, err = config.client.Do(req)
 			if err != nil {
 				return err
@@ -95,6 +104,7 @@ func sendRequestWithTimeout(config *Config, method, project, rawurl, userAgent s
 		timeout,
 		errorRetryPredicates...,
 	)
+	span.finish(err)
 	if err != nil {
 		return nil, err
 	}
@@ -468,10 +468,10 @@ func generateUserAgentString(d TerraformResourceData, currentUserAgent string) (
 	}
 
 	if m.ModuleName != "" {
-		return strings.Join([]string{currentUserAgent, m.ModuleName}, " "), nil
+		return userAgentWithSpan(strings.Join([]string{currentUserAgent, m.ModuleName}, " "), d), nil
 	}
 
-	return currentUserAgent, nil
+	return userAgentWithSpan(currentUserAgent, d), nil
 }
 
 func SnakeToPascalCase(s string) string {